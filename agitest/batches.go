@@ -0,0 +1,319 @@
+package agitest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+type (
+	mockFile struct {
+		ID        string `json:"id"`
+		Object    string `json:"object"`
+		Bytes     int    `json:"bytes"`
+		CreatedAt int64  `json:"created_at"`
+		Filename  string `json:"filename"`
+		Purpose   string `json:"purpose"`
+		content   []byte
+	}
+
+	mockBatch struct {
+		ID               string            `json:"id"`
+		Object           string            `json:"object"`
+		Endpoint         string            `json:"endpoint"`
+		InputFileID      string            `json:"input_file_id"`
+		CompletionWindow string            `json:"completion_window"`
+		Status           string            `json:"status"`
+		OutputFileID     string            `json:"output_file_id,omitempty"`
+		ErrorFileID      string            `json:"error_file_id,omitempty"`
+		CreatedAt        int64             `json:"created_at"`
+		CompletedAt      int64             `json:"completed_at,omitempty"`
+		CancelledAt      int64             `json:"cancelled_at,omitempty"`
+		RequestCounts    map[string]int    `json:"request_counts"`
+		Metadata         map[string]string `json:"metadata,omitempty"`
+		polls            int
+	}
+)
+
+// SetBatchPolls batch在第polls次查询时变为completed, 之前一直是in_progress. 默认为2, 用于测试轮询
+func (my *Server) SetBatchPolls(polls int) {
+	my.m.Lock()
+	my.batchPolls = polls
+	my.m.Unlock()
+}
+
+func (my *Server) handleFiles(w http.ResponseWriter, r *http.Request, path string) {
+	var rest = strings.TrimPrefix(strings.TrimPrefix(path, PathFiles), "/")
+	switch {
+	case rest == "" && r.Method == http.MethodPost:
+		my.handleUploadFile(w, r)
+	case rest == "" && r.Method == http.MethodGet:
+		var purpose = r.URL.Query().Get("purpose")
+		my.m.Lock()
+		var data = make([]*mockFile, 0, len(my.files))
+		for _, file := range my.files {
+			if purpose == "" || file.Purpose == purpose {
+				data = append(data, file)
+			}
+		}
+		my.m.Unlock()
+
+		sort.Slice(data, func(i, j int) bool { return data[i].ID < data[j].ID })
+		writeJson(w, map[string]any{"object": "list", "data": data})
+	case strings.HasSuffix(rest, "/content") && r.Method == http.MethodGet:
+		my.m.Lock()
+		var file = my.files[strings.TrimSuffix(rest, "/content")]
+		my.m.Unlock()
+
+		if file == nil {
+			writeError(w, ErrorReply(http.StatusNotFound, "file not found"), 0)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/jsonl")
+		_, _ = w.Write(file.content)
+	case r.Method == http.MethodDelete:
+		my.m.Lock()
+		var file = my.files[rest]
+		delete(my.files, rest)
+		my.m.Unlock()
+
+		if file == nil {
+			writeError(w, ErrorReply(http.StatusNotFound, "file not found"), 0)
+			return
+		}
+
+		writeJson(w, map[string]any{"id": rest, "object": "file", "deleted": true})
+	default:
+		writeError(w, ErrorReply(http.StatusNotFound, "not found: "+r.URL.Path), 0)
+	}
+}
+
+func (my *Server) handleUploadFile(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		writeError(w, ErrorReply(http.StatusBadRequest, err.Error()), 0)
+		return
+	}
+
+	var file, header, err1 = r.FormFile("file")
+	if err1 != nil || r.FormValue("purpose") == "" {
+		writeError(w, ErrorReply(http.StatusBadRequest, "purpose and file are required"), 0)
+		return
+	}
+	defer file.Close()
+
+	var content, _ = io.ReadAll(file)
+	var created = my.addFile(header.Filename, r.FormValue("purpose"), content)
+	writeJson(w, created)
+}
+
+// addFile 加锁并保存文件
+func (my *Server) addFile(filename string, purpose string, content []byte) *mockFile {
+	my.m.Lock()
+	defer my.m.Unlock()
+
+	my.nextId++
+	var file = &mockFile{
+		ID:        fmt.Sprintf("file-%d", my.nextId),
+		Object:    "file",
+		Bytes:     len(content),
+		CreatedAt: time.Now().Unix(),
+		Filename:  filename,
+		Purpose:   purpose,
+		content:   content,
+	}
+
+	my.files[file.ID] = file
+	return file
+}
+
+func (my *Server) handleBatches(w http.ResponseWriter, r *http.Request, path string, body []byte) {
+	var rest = strings.TrimPrefix(strings.TrimPrefix(path, PathBatches), "/")
+	switch {
+	case rest == "" && r.Method == http.MethodPost:
+		my.handleCreateBatch(w, body)
+	case rest == "" && r.Method == http.MethodGet:
+		my.m.Lock()
+		var data = make([]*mockBatch, 0, len(my.batches))
+		for _, batch := range my.batches {
+			data = append(data, cloneBatch(batch))
+		}
+		my.m.Unlock()
+
+		sort.Slice(data, func(i, j int) bool { return data[i].ID > data[j].ID })
+		writeJson(w, map[string]any{"object": "list", "data": data, "has_more": false})
+	case strings.HasSuffix(rest, "/cancel") && r.Method == http.MethodPost:
+		my.m.Lock()
+		var batch = my.batches[strings.TrimSuffix(rest, "/cancel")]
+		if batch != nil && batch.Status == "in_progress" {
+			batch.Status = "cancelled"
+			batch.CancelledAt = time.Now().Unix()
+		}
+		var snapshot = cloneBatch(batch)
+		my.m.Unlock()
+
+		my.writeBatch(w, snapshot)
+	case r.Method == http.MethodGet:
+		my.m.Lock()
+		var batch = my.batches[rest]
+		if batch != nil && batch.Status == "in_progress" {
+			batch.polls++
+			if batch.polls >= my.batchPolls {
+				batch.Status = "completed"
+				batch.CompletedAt = time.Now().Unix()
+			}
+		}
+		var snapshot = cloneBatch(batch)
+		my.m.Unlock()
+
+		my.writeBatch(w, snapshot)
+	default:
+		writeError(w, ErrorReply(http.StatusNotFound, "not found: "+r.URL.Path), 0)
+	}
+}
+
+// handleCreateBatch 立即执行输入文件中的所有请求, 结果在batch变为completed之后才对外可见
+func (my *Server) handleCreateBatch(w http.ResponseWriter, body []byte) {
+	var request struct {
+		InputFileID      string            `json:"input_file_id"`
+		Endpoint         string            `json:"endpoint"`
+		CompletionWindow string            `json:"completion_window"`
+		Metadata         map[string]string `json:"metadata"`
+	}
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		writeError(w, ErrorReply(http.StatusBadRequest, err.Error()), 0)
+		return
+	}
+
+	my.m.Lock()
+	var input = my.files[request.InputFileID]
+	my.m.Unlock()
+
+	if input == nil {
+		writeError(w, ErrorReply(http.StatusBadRequest, "input file not found"), 0)
+		return
+	}
+
+	var output, failures bytes.Buffer
+	var total, failed = 0, 0
+	var scanner = bufio.NewScanner(bytes.NewReader(input.content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var line struct {
+			CustomID string      `json:"custom_id"`
+			Body     ChatRequest `json:"body"`
+		}
+
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			writeError(w, ErrorReply(http.StatusBadRequest, "invalid input file: "+err.Error()), 0)
+			return
+		}
+
+		total++
+		var reply = my.nextReply(&line.Body)
+		var id = fmt.Sprintf("batch_req_%d", total)
+		if reply.StatusCode != 0 && reply.StatusCode != http.StatusOK {
+			failed++
+			_ = json.NewEncoder(&failures).Encode(map[string]any{
+				"id":        id,
+				"custom_id": line.CustomID,
+				"response": map[string]any{
+					"status_code": reply.StatusCode,
+					"body":        map[string]any{"error": map[string]any{"message": reply.ErrorMessage}},
+				},
+			})
+			continue
+		}
+
+		if reply.Usage == nil {
+			reply.Usage = estimateUsage(&line.Body, reply.Content)
+		}
+
+		_ = json.NewEncoder(&output).Encode(map[string]any{
+			"id":        id,
+			"custom_id": line.CustomID,
+			"response": map[string]any{
+				"status_code": http.StatusOK,
+				"body": map[string]any{
+					"id":      "chatcmpl-" + id,
+					"object":  "chat.completion",
+					"created": time.Now().Unix(),
+					"model":   line.Body.Model,
+					"choices": []any{map[string]any{
+						"index":         0,
+						"message":       map[string]any{"role": "assistant", "content": reply.Content},
+						"finish_reason": "stop",
+					}},
+					"usage": reply.Usage,
+				},
+			},
+		})
+	}
+
+	var batch = &mockBatch{
+		Object:           "batch",
+		Endpoint:         request.Endpoint,
+		InputFileID:      request.InputFileID,
+		CompletionWindow: request.CompletionWindow,
+		Status:           "in_progress",
+		CreatedAt:        time.Now().Unix(),
+		RequestCounts:    map[string]int{"total": total, "completed": total - failed, "failed": failed},
+		Metadata:         request.Metadata,
+	}
+
+	if output.Len() > 0 {
+		batch.OutputFileID = my.addFile("output.jsonl", "batch_output", output.Bytes()).ID
+	}
+
+	if failures.Len() > 0 {
+		batch.ErrorFileID = my.addFile("errors.jsonl", "batch_output", failures.Bytes()).ID
+	}
+
+	my.m.Lock()
+	my.nextId++
+	batch.ID = fmt.Sprintf("batch_%d", my.nextId)
+	my.batches[batch.ID] = batch
+	var snapshot = cloneBatch(batch)
+	my.m.Unlock()
+
+	my.writeBatch(w, snapshot)
+}
+
+// writeBatch 未完成的batch不暴露结果文件
+func (my *Server) writeBatch(w http.ResponseWriter, batch *mockBatch) {
+	if batch == nil {
+		writeError(w, ErrorReply(http.StatusNotFound, "batch not found"), 0)
+		return
+	}
+
+	if batch.Status != "completed" && batch.Status != "cancelled" {
+		batch.OutputFileID = ""
+		batch.ErrorFileID = ""
+	}
+
+	writeJson(w, batch)
+}
+
+// cloneBatch 需要在锁内调用
+func cloneBatch(batch *mockBatch) *mockBatch {
+	if batch == nil {
+		return nil
+	}
+
+	var cloned = *batch
+	return &cloned
+}
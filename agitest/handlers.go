@@ -0,0 +1,239 @@
+package agitest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/lixianmin/agi/chat"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+func (my *Server) handleChat(w http.ResponseWriter, r *http.Request, body []byte) {
+	var request ChatRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		writeError(w, ErrorReply(http.StatusBadRequest, err.Error()), 0)
+		return
+	}
+
+	if request.Model == "" || len(request.Messages) == 0 {
+		writeError(w, ErrorReply(http.StatusBadRequest, "model and messages are required"), 0)
+		return
+	}
+
+	var reply = my.nextReply(&request)
+	if reply.StatusCode != 0 && reply.StatusCode != http.StatusOK {
+		writeError(w, reply, 0)
+		return
+	}
+
+	if reply.FinishReason == "" {
+		reply.FinishReason = "stop"
+	}
+
+	if reply.Usage == nil {
+		reply.Usage = estimateUsage(&request, reply.Content)
+	}
+
+	var id = fmt.Sprintf("chatcmpl-%d", time.Now().UnixNano())
+	var created = time.Now().Unix()
+	if !request.Stream {
+		writeJson(w, map[string]any{
+			"id":      id,
+			"object":  "chat.completion",
+			"created": created,
+			"model":   request.Model,
+			"choices": []any{map[string]any{
+				"index":         0,
+				"message":       chat.Message{Role: "assistant", Content: reply.Content},
+				"finish_reason": reply.FinishReason,
+			}},
+			"usage": reply.Usage,
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	var writeChunk = func(choices []any, usage *chat.Usage) bool {
+		var chunk = map[string]any{
+			"id":      id,
+			"object":  "chat.completion.chunk",
+			"created": created,
+			"model":   request.Model,
+			"choices": choices,
+		}
+
+		if usage != nil {
+			chunk["usage"] = usage
+		}
+
+		var bts, _ = json.Marshal(chunk)
+		if _, err := fmt.Fprintf(w, "data: %s\n\n", bts); err != nil {
+			return false
+		}
+
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+
+		return true
+	}
+
+	var choice = func(delta map[string]any, finishReason any) []any {
+		return []any{map[string]any{"index": 0, "delta": delta, "finish_reason": finishReason}}
+	}
+
+	if !writeChunk(choice(map[string]any{"role": "assistant", "content": ""}, nil), nil) {
+		return
+	}
+
+	for _, text := range splitChunks(reply) {
+		if !sleep(r.Context(), reply.ChunkDelay) || !writeChunk(choice(map[string]any{"content": text}, nil), nil) {
+			return
+		}
+	}
+
+	if !writeChunk(choice(map[string]any{"content": ""}, reply.FinishReason), nil) {
+		return
+	}
+
+	// 与openai一致: 只有设置了stream_options.include_usage才发送usage, 并且是finish chunk之后一个choices为空的chunk
+	if request.StreamOptions != nil && request.StreamOptions.IncludeUsage && !writeChunk([]any{}, reply.Usage) {
+		return
+	}
+
+	_, _ = fmt.Fprint(w, "data: [DONE]\n\n")
+}
+
+func (my *Server) nextReply(request *ChatRequest) Reply {
+	my.m.Lock()
+	my.chatRequests = append(my.chatRequests, request)
+
+	if len(my.replies) > 0 {
+		var reply = my.replies[0]
+		my.replies = my.replies[1:]
+		my.m.Unlock()
+		return reply
+	}
+
+	var handler = my.chatHandler
+	var reply = my.defaultReply
+	my.m.Unlock()
+
+	if handler != nil {
+		return handler(request)
+	}
+
+	return reply
+}
+
+func (my *Server) handleTranscription(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		writeError(w, ErrorReply(http.StatusBadRequest, err.Error()), 0)
+		return
+	}
+
+	var file, _, err = r.FormFile("file")
+	if err != nil || r.FormValue("model") == "" {
+		writeError(w, ErrorReply(http.StatusBadRequest, "model and file are required"), 0)
+		return
+	}
+	_ = file.Close()
+
+	my.m.Lock()
+	var text = my.transcription
+	my.m.Unlock()
+
+	writeJson(w, map[string]any{"text": text})
+}
+
+func (my *Server) handleEmbeddings(w http.ResponseWriter, body []byte) {
+	var request struct {
+		Model string          `json:"model"`
+		Input json.RawMessage `json:"input"`
+	}
+
+	if err := json.Unmarshal(body, &request); err != nil {
+		writeError(w, ErrorReply(http.StatusBadRequest, err.Error()), 0)
+		return
+	}
+
+	// input既可以是字符串, 也可以是字符串数组
+	var inputs []string
+	var single string
+	if json.Unmarshal(request.Input, &single) == nil {
+		inputs = []string{single}
+	} else if err := json.Unmarshal(request.Input, &inputs); err != nil {
+		writeError(w, ErrorReply(http.StatusBadRequest, "invalid input"), 0)
+		return
+	}
+
+	my.m.Lock()
+	var embedder = my.embedder
+	my.m.Unlock()
+
+	var data = make([]any, 0, len(inputs))
+	var tokens = 0
+	for i, input := range inputs {
+		tokens += utf8.RuneCountInString(input)
+		data = append(data, map[string]any{"object": "embedding", "index": i, "embedding": embedder(input)})
+	}
+
+	writeJson(w, map[string]any{
+		"object": "list",
+		"model":  request.Model,
+		"data":   data,
+		"usage":  chat.Usage{PromptTokens: tokens, TotalTokens: tokens},
+	})
+}
+
+func (my *Server) handleModels(w http.ResponseWriter, r *http.Request) {
+	var query = r.URL.Query()
+	var modelType = query.Get("type")
+	var subType = query.Get("sub_type")
+
+	my.m.Lock()
+	var data = make([]Model, 0, len(my.models))
+	for _, model := range my.models {
+		if (modelType == "" || model.Type == modelType) && (subType == "" || model.SubType == subType) {
+			data = append(data, model)
+		}
+	}
+	my.m.Unlock()
+
+	writeJson(w, map[string]any{"object": "list", "data": data})
+}
+
+func splitChunks(reply Reply) []string {
+	if len(reply.Chunks) > 0 {
+		return reply.Chunks
+	}
+
+	var chunks = make([]string, 0, utf8.RuneCountInString(reply.Content))
+	for _, r := range reply.Content {
+		chunks = append(chunks, string(r))
+	}
+
+	return chunks
+}
+
+func estimateUsage(request *ChatRequest, content string) *chat.Usage {
+	var prompt = 0
+	for _, message := range request.Messages {
+		prompt += utf8.RuneCountInString(message.Content)
+	}
+
+	var completion = utf8.RuneCountInString(content)
+	return &chat.Usage{PromptTokens: prompt, CompletionTokens: completion, TotalTokens: prompt + completion}
+}
@@ -0,0 +1,85 @@
+package agitest
+
+import (
+	"time"
+
+	"github.com/lixianmin/agi/chat"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+type (
+	// Reply 描述mock server对一次chat请求的响应
+	Reply struct {
+		Content      string
+		FinishReason string      // 默认为stop
+		Usage        *chat.Usage // 为nil时按字符数估算
+
+		// Chunks 指定streaming时的分块, 为空时按rune拆分Content
+		Chunks     []string
+		ChunkDelay time.Duration
+
+		// StatusCode 非0且不为200时返回openai格式的错误
+		StatusCode   int
+		ErrorMessage string
+		RetryAfter   time.Duration
+	}
+
+	// ChatRequest 是mock server解析出来的chat请求, 兼容deepseek与siliconflow的字段
+	ChatRequest struct {
+		Model            string          `json:"model"`
+		Messages         []*chat.Message `json:"messages"`
+		Stream           bool            `json:"stream,omitempty"`
+		FrequencyPenalty float32         `json:"frequency_penalty,omitempty"`
+		MaxTokens        int32           `json:"max_tokens,omitempty"`
+		Stop             []string        `json:"stop,omitempty"`
+		Temperature      *float32        `json:"temperature,omitempty"`
+		TopK             int32           `json:"top_k,omitempty"`
+		TopP             float32         `json:"top_p,omitempty"`
+		StreamOptions    *struct {
+			IncludeUsage bool `json:"include_usage"`
+		} `json:"stream_options,omitempty"`
+	}
+
+	// RecordedRequest 记录server收到的每一个请求, 用于断言
+	RecordedRequest struct {
+		Method string
+		Path   string // 去掉了/v1前缀
+		Header map[string][]string
+		Body   []byte
+		Time   time.Time
+	}
+
+	Model struct {
+		ID      string `json:"id"`
+		Object  string `json:"object"`
+		Created int64  `json:"created"`
+		OwnedBy string `json:"owned_by"`
+		Type    string `json:"type,omitempty"`
+		SubType string `json:"sub_type,omitempty"`
+	}
+
+	ChatHandler func(request *ChatRequest) Reply
+	Embedder    func(text string) []float32
+)
+
+// ErrorReply 构造一个错误响应
+func ErrorReply(statusCode int, message string) Reply {
+	return Reply{StatusCode: statusCode, ErrorMessage: message}
+}
+
+// LastUserMessage 返回请求中最后一条user消息的内容
+func (my *ChatRequest) LastUserMessage() string {
+	for i := len(my.Messages) - 1; i >= 0; i-- {
+		if my.Messages[i].Role == "user" {
+			return my.Messages[i].Content
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,353 @@
+package agitest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"hash/fnv"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+const (
+	PathChat           = "/chat/completions"
+	PathTranscriptions = "/audio/transcriptions"
+	PathEmbeddings     = "/embeddings"
+	PathModels         = "/models"
+	PathFiles          = "/files"
+	PathBatches        = "/batches"
+)
+
+// Server 是一个兼容openai接口的httptest server, 同时可以作为deepseek与siliconflow的替身.
+// 所有的路径都可以带或不带/v1前缀
+type Server struct {
+	*httptest.Server
+
+	replies       []Reply
+	defaultReply  Reply
+	chatHandler   ChatHandler
+	embedder      Embedder
+	transcription string
+	models        []Model
+	latency       time.Duration
+	files         map[string]*mockFile
+	batches       map[string]*mockBatch
+	batchPolls    int
+	nextId        int
+
+	rateLimit    int
+	rateWindow   time.Duration
+	windowStart  time.Time
+	windowCount  int
+	requests     []*RecordedRequest
+	chatRequests []*ChatRequest
+	m            sync.Mutex
+}
+
+func NewServer() *Server {
+	var server = &Server{
+		defaultReply:  Reply{Content: "是的"},
+		embedder:      HashEmbedder(64),
+		transcription: "今天天气怎么样?",
+		files:         make(map[string]*mockFile),
+		batches:       make(map[string]*mockBatch),
+		batchPolls:    2,
+		models: []Model{
+			{ID: "deepseek-chat", Object: "model", OwnedBy: "deepseek", Type: "text", SubType: "chat"},
+			{ID: "Qwen/Qwen2-7B-Instruct", Object: "model", OwnedBy: "siliconflow", Type: "text", SubType: "chat"},
+			{ID: "BAAI/bge-m3", Object: "model", OwnedBy: "siliconflow", Type: "text", SubType: "embedding"},
+			{ID: "iic/SenseVoiceSmall", Object: "model", OwnedBy: "siliconflow", Type: "audio", SubType: "speech-to-text"},
+		},
+	}
+
+	server.Server = httptest.NewServer(http.HandlerFunc(server.serveHTTP))
+	return server
+}
+
+// NewTestServer 创建server并在测试结束时关闭
+func NewTestServer(t testing.TB) *Server {
+	var server = NewServer()
+	t.Cleanup(server.Close)
+	return server
+}
+
+// BaseUrl 用于deepseek.WithBaseUrl()或siliconflow.WithBaseUrl()
+func (my *Server) BaseUrl() string {
+	return my.URL
+}
+
+// EnqueueReply 追加若干个chat响应, 按顺序使用, 用完之后使用ChatHandler或默认响应
+func (my *Server) EnqueueReply(replies ...Reply) {
+	my.m.Lock()
+	my.replies = append(my.replies, replies...)
+	my.m.Unlock()
+}
+
+// EnqueueError 使下一次chat请求返回错误
+func (my *Server) EnqueueError(statusCode int, message string) {
+	my.EnqueueReply(ErrorReply(statusCode, message))
+}
+
+func (my *Server) SetDefaultReply(reply Reply) {
+	my.m.Lock()
+	my.defaultReply = reply
+	my.m.Unlock()
+}
+
+// SetChatHandler 根据请求内容动态生成响应, 优先级低于EnqueueReply
+func (my *Server) SetChatHandler(handler ChatHandler) {
+	my.m.Lock()
+	my.chatHandler = handler
+	my.m.Unlock()
+}
+
+func (my *Server) SetEmbedder(embedder Embedder) {
+	if embedder != nil {
+		my.m.Lock()
+		my.embedder = embedder
+		my.m.Unlock()
+	}
+}
+
+func (my *Server) SetTranscription(text string) {
+	my.m.Lock()
+	my.transcription = text
+	my.m.Unlock()
+}
+
+func (my *Server) SetModels(models ...Model) {
+	my.m.Lock()
+	my.models = models
+	my.m.Unlock()
+}
+
+// SetLatency 每个请求在响应之前等待的时间
+func (my *Server) SetLatency(latency time.Duration) {
+	my.m.Lock()
+	my.latency = latency
+	my.m.Unlock()
+}
+
+// SetRateLimit 每个window内最多接受limit个请求, 超出的返回429与Retry-After; limit<=0表示不限流
+func (my *Server) SetRateLimit(limit int, window time.Duration) {
+	my.m.Lock()
+	my.rateLimit = limit
+	my.rateWindow = window
+	my.windowStart = time.Time{}
+	my.windowCount = 0
+	my.m.Unlock()
+}
+
+// Requests 返回收到的所有请求
+func (my *Server) Requests() []*RecordedRequest {
+	my.m.Lock()
+	defer my.m.Unlock()
+
+	var cloned = make([]*RecordedRequest, len(my.requests))
+	copy(cloned, my.requests)
+	return cloned
+}
+
+// ChatRequests 返回收到的所有chat请求
+func (my *Server) ChatRequests() []*ChatRequest {
+	my.m.Lock()
+	defer my.m.Unlock()
+
+	var cloned = make([]*ChatRequest, len(my.chatRequests))
+	copy(cloned, my.chatRequests)
+	return cloned
+}
+
+// LastChatRequest 返回最后一个chat请求, 没有则返回nil
+func (my *Server) LastChatRequest() *ChatRequest {
+	my.m.Lock()
+	defer my.m.Unlock()
+
+	if count := len(my.chatRequests); count > 0 {
+		return my.chatRequests[count-1]
+	}
+
+	return nil
+}
+
+// RequestCount 返回path(不带/v1前缀)收到的请求数, path为空时返回总数
+func (my *Server) RequestCount(path string) int {
+	var count = 0
+	for _, request := range my.Requests() {
+		if path == "" || request.Path == path {
+			count++
+		}
+	}
+
+	return count
+}
+
+// AssertRequestCount 断言path收到的请求数
+func (my *Server) AssertRequestCount(t testing.TB, path string, expected int) {
+	t.Helper()
+	if count := my.RequestCount(path); count != expected {
+		t.Fatalf("path=%q, expected %d requests, got %d", path, expected, count)
+	}
+}
+
+func (my *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	var path = strings.TrimPrefix(r.URL.Path, "/v1")
+	var body, _ = io.ReadAll(r.Body)
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	my.m.Lock()
+	my.requests = append(my.requests, &RecordedRequest{
+		Method: r.Method,
+		Path:   path,
+		Header: r.Header.Clone(),
+		Body:   body,
+		Time:   time.Now(),
+	})
+	var latency = my.latency
+	var retryAfter, limited = my.checkRateLimit()
+	my.m.Unlock()
+
+	if !sleep(r.Context(), latency) {
+		return
+	}
+
+	if limited {
+		writeError(w, ErrorReply(http.StatusTooManyRequests, "rate limit exceeded"), retryAfter)
+		return
+	}
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+		writeError(w, ErrorReply(http.StatusUnauthorized, "missing api key"), 0)
+		return
+	}
+
+	switch {
+	case path == PathChat && r.Method == http.MethodPost:
+		my.handleChat(w, r, body)
+	case path == PathTranscriptions && r.Method == http.MethodPost:
+		my.handleTranscription(w, r)
+	case path == PathEmbeddings && r.Method == http.MethodPost:
+		my.handleEmbeddings(w, body)
+	case path == PathModels && r.Method == http.MethodGet:
+		my.handleModels(w, r)
+	case path == PathFiles || strings.HasPrefix(path, PathFiles+"/"):
+		my.handleFiles(w, r, path)
+	case path == PathBatches || strings.HasPrefix(path, PathBatches+"/"):
+		my.handleBatches(w, r, path, body)
+	default:
+		writeError(w, ErrorReply(http.StatusNotFound, "not found: "+r.URL.Path), 0)
+	}
+}
+
+// checkRateLimit 调用时需要持有锁
+func (my *Server) checkRateLimit() (time.Duration, bool) {
+	if my.rateLimit <= 0 {
+		return 0, false
+	}
+
+	var now = time.Now()
+	if now.Sub(my.windowStart) >= my.rateWindow {
+		my.windowStart = now
+		my.windowCount = 0
+	}
+
+	my.windowCount++
+	if my.windowCount > my.rateLimit {
+		return my.rateWindow - now.Sub(my.windowStart), true
+	}
+
+	return 0, false
+}
+
+func writeJson(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, reply Reply, retryAfter time.Duration) {
+	if reply.RetryAfter > 0 {
+		retryAfter = reply.RetryAfter
+	}
+
+	if retryAfter > 0 {
+		var seconds = int(math.Ceil(retryAfter.Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	}
+
+	var errorType = "invalid_request_error"
+	switch {
+	case reply.StatusCode == http.StatusUnauthorized:
+		errorType = "authentication_error"
+	case reply.StatusCode == http.StatusTooManyRequests:
+		errorType = "rate_limit_error"
+	case reply.StatusCode >= http.StatusInternalServerError:
+		errorType = "server_error"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(reply.StatusCode)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]any{
+			"message": reply.ErrorMessage,
+			"type":    errorType,
+			"code":    reply.StatusCode,
+		},
+	})
+}
+
+// sleep 返回false表示请求已经被取消
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+
+	var timer = time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// HashEmbedder 返回一个确定性的embedder: 相同的文本得到相同的单位向量, 共享字符越多的文本越相似
+func HashEmbedder(dimensions int) Embedder {
+	return func(text string) []float32 {
+		var vector = make([]float32, dimensions)
+		for _, r := range text {
+			var hash = fnv.New32a()
+			_, _ = hash.Write([]byte(string(r)))
+			vector[hash.Sum32()%uint32(dimensions)] += 1
+		}
+
+		var norm float64
+		for _, v := range vector {
+			norm += float64(v * v)
+		}
+
+		if norm > 0 {
+			var scale = float32(1 / math.Sqrt(norm))
+			for i := range vector {
+				vector[i] *= scale
+			}
+		}
+
+		return vector
+	}
+}
@@ -0,0 +1,161 @@
+package agitest
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lixianmin/agi/chat"
+	"github.com/lixianmin/agi/deepseek"
+	"github.com/lixianmin/agi/ifs"
+	"github.com/lixianmin/agi/siliconflow"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+func TestDeepSeekChat(t *testing.T) {
+	var server = NewTestServer(t)
+	server.EnqueueReply(Reply{Content: "你好"})
+
+	var client = deepseek.NewDeepSeekClient("sk-test", deepseek.WithBaseUrl(server.BaseUrl()))
+	var request = &deepseek.ChatRequest{
+		Request: chat.Request{
+			Model:    "deepseek-chat",
+			Messages: []*chat.Message{{Role: "user", Content: "hi"}},
+		},
+		Temperature: chat.Float32(0.5),
+	}
+
+	var response, err = client.Chat(context.Background(), request)
+	if err != nil || response.GetContent() != "你好" || response.GetUsage().CompletionTokens != 2 {
+		t.Fatalf("response=%v, err=%v", response, err)
+	}
+
+	server.AssertRequestCount(t, PathChat, 1)
+	if last := server.LastChatRequest(); *last.Temperature != 0.5 || last.LastUserMessage() != "hi" {
+		t.Fatalf("last=%v", last)
+	}
+}
+
+func TestSiliconStreamChat(t *testing.T) {
+	var server = NewTestServer(t)
+	server.SetChatHandler(func(request *ChatRequest) Reply {
+		return Reply{Content: "echo: " + request.LastUserMessage(), ChunkDelay: time.Millisecond}
+	})
+
+	var client = siliconflow.NewSiliconClient("sk-test", siliconflow.WithBaseUrl(server.BaseUrl()+"/v1"))
+	var request = &siliconflow.ChatRequest{
+		Request: chat.Request{
+			Model:    "Qwen/Qwen2-7B-Instruct",
+			Messages: []*chat.Message{{Role: "user", Content: "hello"}},
+		},
+	}
+
+	var text string
+	var usage *chat.Usage
+	var err = client.StreamChat(context.Background(), request, func(response siliconflow.ChatResponse) error {
+		text += response.Message.Content
+		if response.Usage != nil {
+			usage = response.Usage
+		}
+		return nil
+	})
+
+	if err != nil || text != "echo: hello" || usage == nil {
+		t.Fatalf("text=%q, usage=%v, err=%v", text, usage, err)
+	}
+}
+
+func TestInjectedErrors(t *testing.T) {
+	var server = NewTestServer(t)
+	server.EnqueueError(http.StatusInternalServerError, "boom")
+
+	var client = deepseek.NewDeepSeekClient("sk-test", deepseek.WithBaseUrl(server.BaseUrl()))
+	var request = &deepseek.ChatRequest{Request: chat.Request{Model: "deepseek-chat", Messages: []*chat.Message{{Role: "user", Content: "hi"}}}}
+
+	var statusError *ifs.StatusError
+	var _, err = client.Chat(context.Background(), request)
+	if !errors.As(err, &statusError) || statusError.StatusCode != http.StatusInternalServerError || statusError.Message != "boom" {
+		t.Fatalf("err=%v", err)
+	}
+
+	server.SetRateLimit(1, time.Minute)
+	if _, err := client.Chat(context.Background(), request); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.Chat(context.Background(), request)
+	if !errors.As(err, &statusError) || statusError.StatusCode != http.StatusTooManyRequests || statusError.RetryAfter <= 0 {
+		t.Fatalf("err=%v", err)
+	}
+
+	server.SetRateLimit(0, 0)
+	server.SetLatency(time.Second)
+	var ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.Chat(ctx, request); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err=%v", err)
+	}
+}
+
+func TestTranscribeAudio(t *testing.T) {
+	var server = NewTestServer(t)
+	server.SetTranscription("测试")
+
+	var client = siliconflow.NewSiliconClient("sk-test", siliconflow.WithBaseUrl(server.BaseUrl()))
+	var audio, _ = os.ReadFile(filepath.Join("..", "siliconflow", "testdata", "synthetic", "silence.wav"))
+	var text, err = client.TranscribeAudio(context.Background(), "iic/SenseVoiceSmall", audio)
+	if err != nil || text != "测试" {
+		t.Fatalf("text=%q, err=%v", text, err)
+	}
+}
+
+func TestListModels(t *testing.T) {
+	var server = NewTestServer(t)
+	var deepseekClient = deepseek.NewDeepSeekClient("sk-test", deepseek.WithBaseUrl(server.BaseUrl()))
+	var models, err = deepseekClient.ListModels(context.Background())
+	if err != nil || len(models) != 4 || models[0].ID != deepseek.ModelChat {
+		t.Fatalf("models=%v, err=%v", models, err)
+	}
+
+	var siliconClient = siliconflow.NewSiliconClient("sk-test", siliconflow.WithBaseUrl(server.BaseUrl()+"/v1"))
+	var embeddings, err2 = siliconClient.ListModels(context.Background(), siliconflow.ModelTypeText, siliconflow.SubTypeEmbedding)
+	if err2 != nil || len(embeddings) != 1 || embeddings[0].ID != siliconflow.ModelBgeM3 {
+		t.Fatalf("embeddings=%v, err=%v", embeddings, err2)
+	}
+
+	if last := server.Requests()[1]; last.Path != PathModels {
+		t.Fatalf("path=%s", last.Path)
+	}
+
+	// 模型列表也经过interceptor链, 由interceptor注入的鉴权同样生效
+	var endpoints []string
+	var auth = func(ctx context.Context, call *ifs.Call, next ifs.Invoker) (any, error) {
+		endpoints = append(endpoints, call.Endpoint)
+		call.Header = http.Header{"Authorization": []string{"Bearer sk-test"}}
+		return next(ctx, call)
+	}
+
+	deepseekClient = deepseek.NewDeepSeekClient("", deepseek.WithBaseUrl(server.BaseUrl()), deepseek.WithInterceptors(auth))
+	if _, err = deepseekClient.ListModels(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	siliconClient = siliconflow.NewSiliconClient("", siliconflow.WithBaseUrl(server.BaseUrl()+"/v1"), siliconflow.WithInterceptors(auth))
+	if _, err = siliconClient.ListModels(context.Background(), "", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(endpoints) != 2 || endpoints[0] != ifs.EndpointModels || endpoints[1] != ifs.EndpointModels {
+		t.Fatalf("endpoints=%v", endpoints)
+	}
+}
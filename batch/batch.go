@@ -0,0 +1,381 @@
+package batch
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lixianmin/agi/chat"
+	"github.com/lixianmin/agi/ifs"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+const maxLineSize = 16 * 1024 * 1024
+
+type (
+	// Request 是输入jsonl中的一行. Messages与Prompt二选一, Prompt是只有一个user消息时的简写
+	Request struct {
+		ID       string          `json:"id"` // 为空时使用行号
+		Model    string          `json:"model,omitempty"`
+		System   string          `json:"system,omitempty"`
+		Prompt   string          `json:"prompt,omitempty"`
+		Messages []*chat.Message `json:"messages,omitempty"`
+		Params   *chat.Params    `json:"params,omitempty"`
+	}
+
+	// Result 是输出jsonl中的一行, 按输入的顺序写出. 失败时Error不为空
+	Result struct {
+		ID           string        `json:"id"`
+		Content      string        `json:"content,omitempty"`
+		Model        string        `json:"model,omitempty"`
+		FinishReason string        `json:"finish_reason,omitempty"`
+		Usage        *chat.Usage   `json:"usage,omitempty"`
+		Latency      time.Duration `json:"latency,omitempty"`
+		Attempts     int           `json:"attempts,omitempty"`
+		Error        string        `json:"error,omitempty"`
+	}
+
+	Stats struct {
+		Total     int // 本次执行的请求数, 不包括跳过的
+		Skipped   int // 输出中已经成功的请求数
+		Succeeded int
+		Failed    int
+		Usage     chat.Usage
+	}
+
+	// Runner 以有限的并发数执行jsonl中的请求, 支持限速与重试
+	Runner struct {
+		service     chat.ChatService
+		model       string
+		concurrency int
+		maxRetries  int
+		baseDelay   time.Duration
+		limiter     *limiter
+	}
+
+	job struct {
+		index    int
+		request  *Request
+		result   *Result
+		canceled bool // 因为ctx结束而没有完成, 不写入输出, 以便下次继续
+	}
+
+	runnerOptions struct {
+		model             string
+		concurrency       int
+		requestsPerMinute int
+		maxRetries        int
+		baseDelay         time.Duration
+	}
+
+	RunnerOption func(*runnerOptions)
+)
+
+// WithModel 请求中没有指定model时使用的模型
+func WithModel(model string) RunnerOption {
+	return func(options *runnerOptions) {
+		options.model = model
+	}
+}
+
+// WithConcurrency 同时进行的请求数, 默认为4
+func WithConcurrency(concurrency int) RunnerOption {
+	return func(options *runnerOptions) {
+		if concurrency > 0 {
+			options.concurrency = concurrency
+		}
+	}
+}
+
+// WithRequestsPerMinute 每分钟最多发出的请求数(包括重试), 默认不限速
+func WithRequestsPerMinute(rpm int) RunnerOption {
+	return func(options *runnerOptions) {
+		if rpm >= 0 {
+			options.requestsPerMinute = rpm
+		}
+	}
+}
+
+// WithMaxRetries 限流, 服务端错误与网络错误的最大重试次数, 默认为3
+func WithMaxRetries(retries int) RunnerOption {
+	return func(options *runnerOptions) {
+		if retries >= 0 {
+			options.maxRetries = retries
+		}
+	}
+}
+
+// WithBaseDelay 第一次重试前的等待时间, 之后每次翻倍; 服务端给出Retry-After时以其为准. 默认为1秒
+func WithBaseDelay(delay time.Duration) RunnerOption {
+	return func(options *runnerOptions) {
+		if delay > 0 {
+			options.baseDelay = delay
+		}
+	}
+}
+
+func NewRunner(service chat.ChatService, opts ...RunnerOption) *Runner {
+	// 默认值
+	var options = runnerOptions{
+		concurrency: 4,
+		maxRetries:  3,
+		baseDelay:   time.Second,
+	}
+
+	// 初始化
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return &Runner{
+		service:     service,
+		model:       options.model,
+		concurrency: options.concurrency,
+		maxRetries:  options.maxRetries,
+		baseDelay:   options.baseDelay,
+		limiter:     newLimiter(options.requestsPerMinute),
+	}
+}
+
+// Run 读取reader中的请求, 按输入顺序把结果写入writer. skip中的ID会被跳过, 用于断点续跑.
+// ctx结束时已经按顺序完成的结果会被写出, 未完成的不写, 返回ctx.Err()
+func (my *Runner) Run(ctx context.Context, reader io.Reader, writer io.Writer, skip map[string]bool) (*Stats, error) {
+	var runCtx, cancel = context.WithCancel(ctx)
+	defer cancel()
+
+	var stats = &Stats{}
+	var jobs = make(chan *job)
+	var results = make(chan *job)
+	// window限制已经读入但还没有写出的请求数, 避免一个慢请求导致后面的结果无限堆积
+	var window = make(chan struct{}, my.concurrency*4)
+
+	var readErr error
+	go func() {
+		defer close(jobs)
+		readErr = my.feed(runCtx, reader, skip, stats, jobs, window)
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < my.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range jobs {
+				if item.result == nil {
+					item.result, item.canceled = my.execute(runCtx, item.request)
+				}
+				results <- item
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var encoder = json.NewEncoder(writer)
+	encoder.SetEscapeHTML(false)
+
+	var writeErr error
+	var pending = make(map[int]*job)
+	var next = 0
+	for item := range results {
+		pending[item.index] = item
+		for {
+			var ready, ok = pending[next]
+			if !ok {
+				break
+			}
+
+			delete(pending, next)
+			next++
+			<-window
+
+			if writeErr != nil {
+				continue
+			}
+
+			if ready.canceled {
+				writeErr = runCtx.Err()
+				cancel()
+				continue
+			}
+
+			if err := encoder.Encode(ready.result); err != nil {
+				writeErr = err
+				cancel()
+				continue
+			}
+
+			stats.add(ready.result)
+		}
+	}
+
+	if writeErr != nil {
+		return stats, writeErr
+	}
+
+	return stats, readErr
+}
+
+// feed 逐行解析请求并分配序号, 解析失败的行直接生成一个失败的结果
+func (my *Runner) feed(ctx context.Context, reader io.Reader, skip map[string]bool, stats *Stats, jobs chan<- *job, window chan struct{}) error {
+	var scanner = bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	var index = 0
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		var line = scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var item = &job{request: &Request{}}
+		if err := json.Unmarshal(line, item.request); err != nil {
+			item.result = &Result{Error: fmt.Sprintf("line %d: %v", lineNumber, err)}
+		}
+
+		if item.request.ID == "" {
+			item.request.ID = strconv.Itoa(lineNumber)
+		}
+
+		if item.result != nil {
+			item.result.ID = item.request.ID
+		}
+
+		if skip[item.request.ID] {
+			stats.Skipped++
+			continue
+		}
+
+		select {
+		case window <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		item.index = index
+		index++
+		jobs <- item
+	}
+
+	return scanner.Err()
+}
+
+// execute 执行一个请求, 可重试的错误按指数退避重试
+func (my *Runner) execute(ctx context.Context, request *Request) (*Result, bool) {
+	var result = &Result{ID: request.ID}
+	var messages = request.messages()
+	if len(messages) == 0 {
+		result.Error = "no prompt or messages"
+		return result, false
+	}
+
+	var chatRequest = &chat.Request{
+		Model:    request.Model,
+		Messages: messages,
+		Params:   request.Params,
+	}
+
+	if chatRequest.Model == "" {
+		chatRequest.Model = my.model
+	}
+
+	for {
+		if err := my.limiter.wait(ctx); err != nil {
+			return result, true
+		}
+
+		result.Attempts++
+		var response, err = my.service.Chat(ctx, chatRequest)
+		if err == nil {
+			result.Content = response.Message.Content
+			result.Model = response.Model
+			result.FinishReason = response.FinishReason
+			result.Usage = response.Usage
+			result.Latency = response.Latency
+			result.Error = ""
+			return result, false
+		}
+
+		if ctx.Err() != nil {
+			return result, true
+		}
+
+		result.Error = err.Error()
+		if result.Attempts > my.maxRetries || !isRetryable(err) {
+			return result, false
+		}
+
+		if sleep(ctx, my.retryDelay(result.Attempts, err)) != nil {
+			return result, true
+		}
+	}
+}
+
+func (my *Runner) retryDelay(attempts int, err error) time.Duration {
+	var statusErr *ifs.StatusError
+	if errors.As(err, &statusErr) && statusErr.RetryAfter > 0 {
+		return statusErr.RetryAfter
+	}
+
+	return my.baseDelay << min(attempts-1, 10)
+}
+
+// isRetryable 限流, 服务端错误与网络错误可以重试, 参数错误等重试也没有用
+func isRetryable(err error) bool {
+	var statusErr *ifs.StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Retryable()
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+func (my *Request) messages() []*chat.Message {
+	var messages = make([]*chat.Message, 0, len(my.Messages)+2)
+	if my.System != "" {
+		messages = append(messages, &chat.Message{Role: "system", Content: my.System})
+	}
+
+	messages = append(messages, my.Messages...)
+	if my.Prompt != "" {
+		messages = append(messages, &chat.Message{Role: "user", Content: my.Prompt})
+	}
+
+	if len(messages) == 0 || messages[len(messages)-1].Role == "system" {
+		return nil
+	}
+
+	return messages
+}
+
+func (my *Stats) add(result *Result) {
+	my.Total++
+	if result.Error != "" {
+		my.Failed++
+		return
+	}
+
+	my.Succeeded++
+	if result.Usage != nil {
+		my.Usage.PromptTokens += result.Usage.PromptTokens
+		my.Usage.CompletionTokens += result.Usage.CompletionTokens
+		my.Usage.TotalTokens += result.Usage.TotalTokens
+	}
+}
@@ -0,0 +1,181 @@
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lixianmin/agi/chat"
+	"github.com/lixianmin/agi/ifs"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+// fakeService 回答越短的问题越慢, 用于检验输出顺序; 问题为flaky时第一次返回429
+type fakeService struct {
+	calls map[string]int
+	m     sync.Mutex
+}
+
+func (my *fakeService) Chat(ctx context.Context, request *chat.Request) (*chat.Response, error) {
+	var question = request.Messages[len(request.Messages)-1].Content
+
+	my.m.Lock()
+	my.calls[question]++
+	var calls = my.calls[question]
+	my.m.Unlock()
+
+	if question == "flaky" && calls == 1 {
+		return nil, &ifs.StatusError{StatusCode: http.StatusTooManyRequests, Message: "slow down", RetryAfter: time.Millisecond}
+	}
+
+	if question == "bad" {
+		return nil, &ifs.StatusError{StatusCode: http.StatusBadRequest, Message: "bad request"}
+	}
+
+	time.Sleep(time.Duration(10-min(len(question), 10)) * time.Millisecond)
+	return &chat.Response{
+		Model:   request.Model,
+		Message: chat.Message{Role: "assistant", Content: "re: " + question},
+		Usage:   &chat.Usage{TotalTokens: len(question)},
+	}, nil
+}
+
+func (my *fakeService) StreamChat(ctx context.Context, request *chat.Request, fn chat.ResponseFunc) error {
+	var response, err = my.Chat(ctx, request)
+	if err != nil {
+		return err
+	}
+
+	return fn(response)
+}
+
+func readResults(t *testing.T, text string) []*Result {
+	var results []*Result
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		var result Result
+		if err := json.Unmarshal([]byte(line), &result); err != nil {
+			t.Fatal(err)
+		}
+		results = append(results, &result)
+	}
+
+	return results
+}
+
+func TestRun(t *testing.T) {
+	var input = strings.Join([]string{
+		`{"id":"a","prompt":"x"}`,
+		`{"id":"b","prompt":"flaky"}`,
+		``,
+		`{"id":"c","system":"be brief","messages":[{"role":"user","content":"longer question"}]}`,
+		`{"id":"d","prompt":"bad"}`,
+		`not json`,
+		`{"prompt":"no id","model":"other"}`,
+	}, "\n")
+
+	var service = &fakeService{calls: map[string]int{}}
+	var runner = NewRunner(service, WithModel("deepseek-chat"), WithConcurrency(3), WithBaseDelay(time.Millisecond))
+
+	var output strings.Builder
+	var stats, err = runner.Run(context.Background(), strings.NewReader(input), &output, map[string]bool{"c": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	println(output.String())
+	var results = readResults(t, output.String())
+	var ids []string
+	for _, result := range results {
+		ids = append(ids, result.ID)
+	}
+
+	if strings.Join(ids, ",") != "a,b,d,6,7" {
+		t.Fatalf("ids=%v", ids)
+	}
+
+	if results[1].Content != "re: flaky" || results[1].Attempts != 2 || results[1].Error != "" {
+		t.Fatalf("flaky=%+v", results[1])
+	}
+
+	if results[2].Error == "" || results[2].Attempts != 1 || results[3].Error == "" || results[4].Model != "other" || results[0].Model != "deepseek-chat" {
+		t.Fatalf("results=%+v %+v %+v", results[2], results[3], results[4])
+	}
+
+	if stats.Total != 5 || stats.Skipped != 1 || stats.Succeeded != 3 || stats.Failed != 2 || stats.Usage.TotalTokens != 1+5+5 {
+		t.Fatalf("stats=%+v", stats)
+	}
+}
+
+func TestRunFile(t *testing.T) {
+	var dir = t.TempDir()
+	var inputPath = filepath.Join(dir, "input.jsonl")
+	var outputPath = filepath.Join(dir, "output.jsonl")
+
+	_ = os.WriteFile(inputPath, []byte(`{"id":"1","prompt":"one"}
+{"id":"2","prompt":"two"}
+{"id":"3","prompt":"three"}
+`), 0o644)
+
+	// 模拟上次执行到一半: 1已经成功, 2失败, 3写了一半
+	_ = os.WriteFile(outputPath, []byte(`{"id":"1","content":"re: one"}
+{"id":"2","error":"boom"}
+{"id":"3","con`), 0o644)
+
+	var service = &fakeService{calls: map[string]int{}}
+	var stats, err = NewRunner(service).RunFile(context.Background(), inputPath, outputPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Skipped != 1 || stats.Succeeded != 2 || service.calls["one"] != 0 {
+		t.Fatalf("stats=%+v, calls=%v", stats, service.calls)
+	}
+
+	var file, _ = os.Open(outputPath)
+	defer file.Close()
+
+	var done, _ = ReadDone(file)
+	if len(done) != 3 {
+		t.Fatalf("done=%v", done)
+	}
+
+	var bts, _ = os.ReadFile(outputPath)
+	if results := readResults(t, string(bts)); len(results) != 4 || results[2].ID != "2" || results[3].ID != "3" {
+		t.Fatalf("output=%s", bts)
+	}
+}
+
+func TestRunCanceled(t *testing.T) {
+	var service = &fakeService{calls: map[string]int{}}
+	var runner = NewRunner(service, WithConcurrency(1), WithRequestsPerMinute(600))
+
+	var input strings.Builder
+	for i := 0; i < 100; i++ {
+		input.WriteString(`{"prompt":"q"}` + "\n")
+	}
+
+	var ctx, cancel = context.WithTimeout(context.Background(), 250*time.Millisecond)
+	defer cancel()
+
+	var output strings.Builder
+	var stats, err = runner.Run(ctx, strings.NewReader(input.String()), &output, nil)
+	if err == nil || stats.Total == 0 || stats.Total >= 10 || stats.Failed != 0 {
+		t.Fatalf("stats=%+v, err=%v", stats, err)
+	}
+
+	if lines := strings.Count(output.String(), "\n"); lines != stats.Total {
+		t.Fatalf("lines=%d, stats=%+v", lines, stats)
+	}
+}
@@ -0,0 +1,64 @@
+package batch
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+// limiter 把请求均匀地分布在时间轴上, 每interval最多放行一个请求. interval为0表示不限速
+type limiter struct {
+	interval time.Duration
+	next     time.Time
+	m        sync.Mutex
+}
+
+func newLimiter(requestsPerMinute int) *limiter {
+	var my = &limiter{}
+	if requestsPerMinute > 0 {
+		my.interval = time.Minute / time.Duration(requestsPerMinute)
+	}
+
+	return my
+}
+
+// wait 预约下一个时间片并等待到达, ctx结束时返回ctx.Err()
+func (my *limiter) wait(ctx context.Context) error {
+	if my.interval == 0 {
+		return ctx.Err()
+	}
+
+	my.m.Lock()
+	var now = time.Now()
+	if my.next.Before(now) {
+		my.next = now
+	}
+	var slot = my.next
+	my.next = slot.Add(my.interval)
+	my.m.Unlock()
+
+	return sleep(ctx, slot.Sub(now))
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	var timer = time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
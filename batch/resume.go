@@ -0,0 +1,93 @@
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+// RunFile 执行inputPath中的请求, 结果追加到outputPath. outputPath中已经成功的ID会被跳过, 因此中断之后重新执行即可继续;
+// 失败的请求会被重新执行, 其新结果追加在文件末尾, 同一个ID以最后一条为准
+func (my *Runner) RunFile(ctx context.Context, inputPath string, outputPath string) (*Stats, error) {
+	var input, err1 = os.Open(inputPath)
+	if err1 != nil {
+		return nil, err1
+	}
+	defer input.Close()
+
+	var output, err2 = os.OpenFile(outputPath, os.O_RDWR|os.O_CREATE, 0o644)
+	if err2 != nil {
+		return nil, err2
+	}
+	defer output.Close()
+
+	var done, err3 = readDone(output)
+	if err3 != nil {
+		return nil, err3
+	}
+
+	var writer = bufio.NewWriter(output)
+	var stats, err4 = my.Run(ctx, input, writer, done)
+	if err5 := writer.Flush(); err4 == nil {
+		err4 = err5
+	}
+
+	return stats, err4
+}
+
+// ReadDone 返回结果文件中已经成功的ID
+func ReadDone(reader io.Reader) (map[string]bool, error) {
+	var done = make(map[string]bool)
+	var scanner = bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	for scanner.Scan() {
+		var result Result
+		if json.Unmarshal(scanner.Bytes(), &result) != nil {
+			continue
+		}
+
+		// 后出现的结果覆盖之前的结果
+		done[result.ID] = result.Error == ""
+	}
+
+	for id, ok := range done {
+		if !ok {
+			delete(done, id)
+		}
+	}
+
+	return done, scanner.Err()
+}
+
+// readDone 读取已有的结果, 并截掉上次被中断时写了一半的最后一行, 把文件位置移到末尾以便追加
+func readDone(file *os.File) (map[string]bool, error) {
+	var bts, err1 = io.ReadAll(file)
+	if err1 != nil {
+		return nil, err1
+	}
+
+	var size = len(bts)
+	if size > 0 && bts[size-1] != '\n' {
+		size = bytes.LastIndexByte(bts, '\n') + 1
+		if err2 := file.Truncate(int64(size)); err2 != nil {
+			return nil, err2
+		}
+	}
+
+	if _, err3 := file.Seek(int64(size), io.SeekStart); err3 != nil {
+		return nil, err3
+	}
+
+	return ReadDone(bytes.NewReader(bts[:size]))
+}
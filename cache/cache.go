@@ -0,0 +1,229 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/lixianmin/agi/ifs"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+type (
+	// Cache 缓存完全相同的chat请求的结果, 通过Interceptor()挂到DeepSeekClient或SiliconClient上
+	Cache struct {
+		store Store
+		ttl   time.Duration
+		force bool
+
+		hits   atomic.Int64
+		misses atomic.Int64
+	}
+
+	cacheOptions struct {
+		ttl   time.Duration
+		force bool
+	}
+
+	CacheOption func(*cacheOptions)
+
+	bypassKey struct{}
+)
+
+// WithTTL 缓存的有效期, 默认为0表示永不过期
+func WithTTL(ttl time.Duration) CacheOption {
+	return func(options *cacheOptions) {
+		if ttl > 0 {
+			options.ttl = ttl
+		}
+	}
+}
+
+// WithForce 即使结果是随机的也缓存. 默认只缓存显式设置了temperature=0的请求: 没有设置temperature时provider使用自己的默认值(比如DeepSeek为1.0), 结果同样是随机的
+func WithForce(force bool) CacheOption {
+	return func(options *cacheOptions) {
+		options.force = force
+	}
+}
+
+// Bypass 返回的ctx会跳过缓存, 既不读也不写
+func Bypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassKey{}, true)
+}
+
+func NewCache(store Store, opts ...CacheOption) *Cache {
+	// 默认值
+	var options = cacheOptions{}
+
+	// 初始化
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if store == nil {
+		store = NewMemoryStore(0)
+	}
+
+	return &Cache{
+		store: store,
+		ttl:   options.ttl,
+		force: options.force,
+	}
+}
+
+// Stats 返回命中与未命中的次数
+func (my *Cache) Stats() (hits int64, misses int64) {
+	return my.hits.Load(), my.misses.Load()
+}
+
+func (my *Cache) Interceptor() ifs.Interceptor {
+	return func(ctx context.Context, call *ifs.Call, next ifs.Invoker) (any, error) {
+		if call.Endpoint != ifs.EndpointChat && call.Endpoint != ifs.EndpointStreamChat || call.Synthesizer == nil {
+			return next(ctx, call)
+		}
+
+		if bypass, _ := ctx.Value(bypassKey{}).(bool); bypass {
+			return next(ctx, call)
+		}
+
+		var key, deterministic, err = RequestKey(call)
+		if err != nil || !deterministic && !my.force {
+			return next(ctx, call)
+		}
+
+		if entry, ok := my.get(key); ok {
+			my.hits.Add(1)
+			return replay(call, entry)
+		}
+
+		my.misses.Add(1)
+		if call.Endpoint == ifs.EndpointStreamChat {
+			return my.recordStream(ctx, call, next, key)
+		}
+
+		var result, err2 = next(ctx, call)
+		if completion, ok := result.(ifs.Completion); ok && err2 == nil {
+			_ = my.store.Set(key, &Entry{
+				Model:        completion.GetModel(),
+				Content:      completion.GetContent(),
+				FinishReason: completion.GetFinishReason(),
+				Usage:        completion.GetUsage(),
+				CreatedAt:    time.Now(),
+			})
+		}
+
+		return result, err2
+	}
+}
+
+func (my *Cache) get(key string) (*Entry, bool) {
+	var entry, ok = my.store.Get(key)
+	if !ok {
+		return nil, false
+	}
+
+	if my.ttl > 0 && time.Since(entry.CreatedAt) > my.ttl {
+		_ = my.store.Delete(key)
+		return nil, false
+	}
+
+	return entry, true
+}
+
+func (my *Cache) recordStream(ctx context.Context, call *ifs.Call, next ifs.Invoker, key string) (any, error) {
+	var entry = &Entry{}
+	var builder strings.Builder
+	var onChunk = call.OnChunk
+	call.OnChunk = func(chunk any) error {
+		if completion, ok := chunk.(ifs.Completion); ok {
+			if content := completion.GetContent(); content != "" {
+				builder.WriteString(content)
+				entry.Chunks = append(entry.Chunks, content)
+			}
+
+			if model := completion.GetModel(); model != "" {
+				entry.Model = model
+			}
+
+			if reason := completion.GetFinishReason(); reason != "" {
+				entry.FinishReason = reason
+			}
+
+			if usage := completion.GetUsage(); usage != nil {
+				entry.Usage = usage
+			}
+		}
+
+		return onChunk(chunk)
+	}
+
+	var result, err = next(ctx, call)
+	// 被中断的stream没有finish reason, 不能缓存
+	if err == nil && entry.FinishReason != "" {
+		entry.Content = builder.String()
+		entry.CreatedAt = time.Now()
+		_ = my.store.Set(key, entry)
+	}
+
+	return result, err
+}
+
+// replay 把缓存的结果还原为provider的响应; streaming调用会按原来的分块依次回调
+func replay(call *ifs.Call, entry *Entry) (any, error) {
+	var synthesizer = call.Synthesizer
+	if call.Endpoint == ifs.EndpointChat {
+		return synthesizer.NewCompletion(entry.Model, entry.Content, entry.FinishReason, entry.Usage), nil
+	}
+
+	var chunks = entry.Chunks
+	if len(chunks) == 0 {
+		chunks = []string{entry.Content}
+	}
+
+	for _, content := range chunks {
+		if err := call.OnChunk(synthesizer.NewChunk(entry.Model, content, "", nil, false)); err != nil {
+			return nil, err
+		}
+	}
+
+	var done = synthesizer.NewChunk(entry.Model, "", entry.FinishReason, entry.Usage, entry.FinishReason == "stop")
+	return nil, call.OnChunk(done)
+}
+
+// RequestKey 返回请求的规范化hash, stream相关的字段不参与计算, 因此streaming与非streaming调用共享缓存.
+// deterministic表示请求中显式出现了temperature=0. provider请求的temperature是*float32, 没有设置时不会出现, 由provider按默认值采样, 视为随机
+func RequestKey(call *ifs.Call) (string, bool, error) {
+	var bts, err1 = json.Marshal(call.Request)
+	if err1 != nil {
+		return "", false, err1
+	}
+
+	var fields map[string]any
+	if err2 := json.Unmarshal(bts, &fields); err2 != nil {
+		return "", false, err2
+	}
+
+	delete(fields, "stream")
+	delete(fields, "stream_options")
+	var temperature, found = fields["temperature"].(float64)
+	var deterministic = found && temperature == 0
+
+	// map的key在json.Marshal时是有序的, 因此结果是确定的
+	var canonical, _ = json.Marshal(map[string]any{
+		"provider": call.Provider,
+		"request":  fields,
+	})
+
+	var sum = sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), deterministic, nil
+}
@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lixianmin/agi/agitest"
+	"github.com/lixianmin/agi/chat"
+	"github.com/lixianmin/agi/deepseek"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+func newRequest(temperature *float32) *deepseek.ChatRequest {
+	return &deepseek.ChatRequest{
+		Request: chat.Request{
+			Model:    "deepseek-chat",
+			Messages: []*chat.Message{{Role: "system", Content: "回答`是的`"}, {Role: "user", Content: "你觉得我帅嘛?"}},
+		},
+		Temperature: temperature,
+	}
+}
+
+func TestChatCache(t *testing.T) {
+	var server = agitest.NewTestServer(t)
+	var cache = NewCache(NewMemoryStore(8))
+	var client = deepseek.NewDeepSeekClient("sk-test", deepseek.WithBaseUrl(server.BaseUrl()), deepseek.WithInterceptors(cache.Interceptor()))
+
+	// 默认选项下, 显式设置了temperature=0的请求走缓存
+	for i := 0; i < 3; i++ {
+		var response, err = client.Chat(context.Background(), newRequest(chat.Float32(0)))
+		if err != nil || response.GetContent() != "是的" {
+			t.Fatalf("response=%v, err=%v", response, err)
+		}
+	}
+	server.AssertRequestCount(t, agitest.PathChat, 1)
+
+	if last := server.LastChatRequest(); last.Temperature == nil || *last.Temperature != 0 {
+		t.Fatal("temperature=0 should be sent")
+	}
+
+	// 从blocking调用的缓存回放到streaming回调
+	var text string
+	var done bool
+	var err = client.StreamChat(context.Background(), newRequest(chat.Float32(0)), func(response deepseek.ChatResponse) error {
+		text += response.Message.Content
+		done = done || response.Done
+		return nil
+	})
+
+	if err != nil || text != "是的" || !done {
+		t.Fatalf("text=%q, done=%v, err=%v", text, done, err)
+	}
+	server.AssertRequestCount(t, agitest.PathChat, 1)
+
+	// 没有设置temperature时provider按默认值采样, temperature>0同样是随机的, 都不走缓存
+	for _, temperature := range []*float32{nil, nil, chat.Float32(0.7), chat.Float32(0.7)} {
+		_, _ = client.Chat(context.Background(), newRequest(temperature))
+	}
+	server.AssertRequestCount(t, agitest.PathChat, 5)
+
+	if hits, misses := cache.Stats(); hits != 3 || misses != 1 {
+		t.Fatalf("hits=%d, misses=%d", hits, misses)
+	}
+
+	// WithForce时随机的请求也走缓存
+	var forceCache = NewCache(NewMemoryStore(8), WithForce(true))
+	var forceClient = deepseek.NewDeepSeekClient("sk-test", deepseek.WithBaseUrl(server.BaseUrl()), deepseek.WithInterceptors(forceCache.Interceptor()))
+	_, _ = forceClient.Chat(context.Background(), newRequest(nil))
+	_, _ = forceClient.Chat(context.Background(), newRequest(nil))
+	server.AssertRequestCount(t, agitest.PathChat, 6)
+
+	if hits, misses := forceCache.Stats(); hits != 1 || misses != 1 {
+		t.Fatalf("hits=%d, misses=%d", hits, misses)
+	}
+}
+
+func TestDiskStoreTTL(t *testing.T) {
+	var server = agitest.NewTestServer(t)
+	server.EnqueueReply(agitest.Reply{Content: "第一次", Chunks: []string{"第一", "次"}}, agitest.Reply{Content: "第二次"})
+
+	var store, _ = NewDiskStore(t.TempDir())
+	var cache = NewCache(store, WithTTL(50*time.Millisecond), WithForce(true))
+	var client = deepseek.NewDeepSeekClient("sk-test", deepseek.WithBaseUrl(server.BaseUrl()), deepseek.WithInterceptors(cache.Interceptor()))
+
+	var streamText = func() string {
+		var text string
+		_ = client.StreamChat(context.Background(), newRequest(chat.Float32(1)), func(response deepseek.ChatResponse) error {
+			text += response.Message.Content
+			return nil
+		})
+		return text
+	}
+
+	if text := streamText(); text != "第一次" {
+		t.Fatalf("text=%q", text)
+	}
+
+	if text := streamText(); text != "第一次" {
+		t.Fatalf("text=%q", text)
+	}
+	server.AssertRequestCount(t, agitest.PathChat, 1)
+
+	time.Sleep(60 * time.Millisecond)
+	if text := streamText(); text != "第二次" {
+		t.Fatalf("text=%q", text)
+	}
+}
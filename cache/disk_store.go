@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+// DiskStore 每个key保存为目录下的一个json文件, 进程重启之后缓存仍然有效
+type DiskStore struct {
+	dir string
+}
+
+func NewDiskStore(dir string) (*DiskStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	return &DiskStore{dir: dir}, nil
+}
+
+func (my *DiskStore) Get(key string) (*Entry, bool) {
+	var bts, err1 = os.ReadFile(my.path(key))
+	if err1 != nil {
+		return nil, false
+	}
+
+	var entry Entry
+	if err2 := json.Unmarshal(bts, &entry); err2 != nil {
+		return nil, false
+	}
+
+	return &entry, true
+}
+
+func (my *DiskStore) Set(key string, entry *Entry) error {
+	var bts, err1 = json.Marshal(entry)
+	if err1 != nil {
+		return err1
+	}
+
+	// 先写临时文件再rename, 避免并发读到写了一半的文件
+	var temp, err2 = os.CreateTemp(my.dir, key+".*.tmp")
+	if err2 != nil {
+		return err2
+	}
+
+	var _, err3 = temp.Write(bts)
+	var err4 = temp.Close()
+	if err := errors.Join(err3, err4); err != nil {
+		_ = os.Remove(temp.Name())
+		return err
+	}
+
+	return os.Rename(temp.Name(), my.path(key))
+}
+
+func (my *DiskStore) Delete(key string) error {
+	var err = os.Remove(my.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+func (my *DiskStore) path(key string) string {
+	return filepath.Join(my.dir, key+".json")
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+type (
+	// MemoryStore 是固定容量的LRU缓存
+	MemoryStore struct {
+		capacity int
+		items    map[string]*list.Element
+		order    *list.List // 最近使用的在前面
+		m        sync.Mutex
+	}
+
+	memoryItem struct {
+		key   string
+		entry *Entry
+	}
+)
+
+func NewMemoryStore(capacity int) *MemoryStore {
+	if capacity <= 0 {
+		capacity = 1024
+	}
+
+	return &MemoryStore{
+		capacity: capacity,
+		items:    make(map[string]*list.Element, capacity),
+		order:    list.New(),
+	}
+}
+
+func (my *MemoryStore) Get(key string) (*Entry, bool) {
+	my.m.Lock()
+	defer my.m.Unlock()
+
+	var element, ok = my.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	my.order.MoveToFront(element)
+	return element.Value.(*memoryItem).entry, true
+}
+
+func (my *MemoryStore) Set(key string, entry *Entry) error {
+	my.m.Lock()
+	defer my.m.Unlock()
+
+	if element, ok := my.items[key]; ok {
+		element.Value.(*memoryItem).entry = entry
+		my.order.MoveToFront(element)
+		return nil
+	}
+
+	my.items[key] = my.order.PushFront(&memoryItem{key: key, entry: entry})
+	for my.order.Len() > my.capacity {
+		var oldest = my.order.Back()
+		my.order.Remove(oldest)
+		delete(my.items, oldest.Value.(*memoryItem).key)
+	}
+
+	return nil
+}
+
+func (my *MemoryStore) Delete(key string) error {
+	my.m.Lock()
+	defer my.m.Unlock()
+
+	if element, ok := my.items[key]; ok {
+		my.order.Remove(element)
+		delete(my.items, key)
+	}
+
+	return nil
+}
+
+func (my *MemoryStore) Len() int {
+	my.m.Lock()
+	defer my.m.Unlock()
+	return my.order.Len()
+}
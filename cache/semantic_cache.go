@@ -0,0 +1,263 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/lixianmin/agi/chat"
+	"github.com/lixianmin/agi/ifs"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+type (
+	// SemanticCache 包裹在任意的chat.ChatService之外, 对最后一条user消息做embedding,
+	// 与相同model+system prompt下缓存的问题做相似度比较, 超过阈值则直接返回缓存的回答(Response.Cached=true)
+	SemanticCache struct {
+		service   chat.ChatService
+		embedder  ifs.Embedder
+		threshold float32
+		capacity  int
+
+		scopes map[string][]*semanticItem // 每个scope内按加入的先后排序
+		m      sync.RWMutex
+
+		hits   atomic.Int64
+		misses atomic.Int64
+	}
+
+	semanticItem struct {
+		question string
+		vector   []float32
+		norm     float32
+		response chat.Response
+	}
+
+	semanticOptions struct {
+		threshold float32
+		capacity  int
+	}
+
+	SemanticOption func(*semanticOptions)
+)
+
+var _ chat.ChatService = (*SemanticCache)(nil)
+
+// WithThreshold 余弦相似度的阈值, 取值(0, 1], 默认0.92
+func WithThreshold(threshold float32) SemanticOption {
+	return func(options *semanticOptions) {
+		if threshold > 0 && threshold <= 1 {
+			options.threshold = threshold
+		}
+	}
+}
+
+// WithCapacity 每个scope最多缓存的问题数, 超出时淘汰最早加入的, 默认1024
+func WithCapacity(capacity int) SemanticOption {
+	return func(options *semanticOptions) {
+		if capacity > 0 {
+			options.capacity = capacity
+		}
+	}
+}
+
+func NewSemanticCache(service chat.ChatService, embedder ifs.Embedder, opts ...SemanticOption) *SemanticCache {
+	// 默认值
+	var options = semanticOptions{
+		threshold: 0.92,
+		capacity:  1024,
+	}
+
+	// 初始化
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return &SemanticCache{
+		service:   service,
+		embedder:  embedder,
+		threshold: options.threshold,
+		capacity:  options.capacity,
+		scopes:    make(map[string][]*semanticItem),
+	}
+}
+
+// Stats 返回命中与未命中的次数
+func (my *SemanticCache) Stats() (hits int64, misses int64) {
+	return my.hits.Load(), my.misses.Load()
+}
+
+func (my *SemanticCache) Chat(ctx context.Context, request *chat.Request) (*chat.Response, error) {
+	if request == nil {
+		return nil, ifs.ErrRequestIsNil
+	}
+
+	var scope, question, vector = my.prepare(ctx, request)
+	if vector == nil {
+		return my.service.Chat(ctx, request)
+	}
+
+	if cached := my.lookup(scope, vector); cached != nil {
+		return cached, nil
+	}
+
+	var response, err = my.service.Chat(ctx, request)
+	if err == nil {
+		my.add(scope, question, vector, *response)
+	}
+
+	return response, err
+}
+
+func (my *SemanticCache) StreamChat(ctx context.Context, request *chat.Request, fn chat.ResponseFunc) error {
+	if request == nil {
+		return ifs.ErrRequestIsNil
+	}
+
+	if fn == nil {
+		return errors.New("fn is nil")
+	}
+
+	var scope, question, vector = my.prepare(ctx, request)
+	if vector == nil {
+		return my.service.StreamChat(ctx, request, fn)
+	}
+
+	if cached := my.lookup(scope, vector); cached != nil {
+		var content = *cached
+		content.FinishReason, content.Usage, content.Done = "", nil, false
+		if err := fn(&content); err != nil {
+			return err
+		}
+
+		return fn(&chat.Response{Model: cached.Model, FinishReason: cached.FinishReason, Usage: cached.Usage, Done: true, Cached: true})
+	}
+
+	var builder strings.Builder
+	var last chat.Response
+	var err = my.service.StreamChat(ctx, request, func(response *chat.Response) error {
+		builder.WriteString(response.Message.Content)
+		if response.Message.Role != "" {
+			last.Message.Role = response.Message.Role
+		}
+
+		if response.Model != "" {
+			last.Model = response.Model
+		}
+
+		if response.FinishReason != "" {
+			last.FinishReason = response.FinishReason
+		}
+
+		if response.Usage != nil {
+			last.Usage = response.Usage
+		}
+
+		return fn(response)
+	})
+
+	if err == nil && last.FinishReason != "" {
+		last.Message.Content = builder.String()
+		last.Done = true
+		my.add(scope, question, vector, last)
+	}
+
+	return err
+}
+
+// prepare 返回scope与问题的向量, 没有user消息或者embedding失败时返回nil, 此时直接透传给下层
+func (my *SemanticCache) prepare(ctx context.Context, request *chat.Request) (string, string, []float32) {
+	var question string
+	var hash = sha256.New()
+	hash.Write([]byte(request.Model))
+	for _, message := range request.Messages {
+		switch message.Role {
+		case "system":
+			hash.Write([]byte{0})
+			hash.Write([]byte(message.Content))
+		case "user":
+			question = message.Content
+		}
+	}
+
+	if question == "" {
+		return "", "", nil
+	}
+
+	var vectors, err = my.embedder.Embed(ctx, []string{question})
+	if err != nil || len(vectors) != 1 || len(vectors[0]) == 0 {
+		return "", "", nil
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), question, vectors[0]
+}
+
+func (my *SemanticCache) lookup(scope string, vector []float32) *chat.Response {
+	var norm = vectorNorm(vector)
+	var best *semanticItem
+	var bestScore float32
+
+	my.m.RLock()
+	for _, item := range my.scopes[scope] {
+		if len(item.vector) != len(vector) || item.norm == 0 || norm == 0 {
+			continue
+		}
+
+		var score = dot(item.vector, vector) / (item.norm * norm)
+		if score >= my.threshold && score > bestScore {
+			best, bestScore = item, score
+		}
+	}
+	my.m.RUnlock()
+
+	if best == nil {
+		my.misses.Add(1)
+		return nil
+	}
+
+	my.hits.Add(1)
+	var response = best.response
+	response.Cached = true
+	return &response
+}
+
+func (my *SemanticCache) add(scope string, question string, vector []float32, response chat.Response) {
+	var item = &semanticItem{
+		question: question,
+		vector:   vector,
+		norm:     vectorNorm(vector),
+		response: response,
+	}
+
+	my.m.Lock()
+	var items = append(my.scopes[scope], item)
+	if len(items) > my.capacity {
+		items = items[len(items)-my.capacity:]
+	}
+	my.scopes[scope] = items
+	my.m.Unlock()
+}
+
+func dot(a []float32, b []float32) float32 {
+	var sum float32
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+
+	return sum
+}
+
+func vectorNorm(v []float32) float32 {
+	return float32(math.Sqrt(float64(dot(v, v))))
+}
@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lixianmin/agi/agitest"
+	"github.com/lixianmin/agi/chat"
+	"github.com/lixianmin/agi/deepseek"
+	"github.com/lixianmin/agi/siliconflow"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+func TestSemanticCache(t *testing.T) {
+	var server = agitest.NewTestServer(t)
+	server.SetChatHandler(func(request *agitest.ChatRequest) agitest.Reply {
+		return agitest.Reply{Content: "answer to " + request.LastUserMessage()}
+	})
+
+	var embedder = siliconflow.NewEmbedder(siliconflow.NewSiliconClient("sk-test", siliconflow.WithBaseUrl(server.BaseUrl())), "BAAI/bge-m3")
+	var service = deepseek.NewChatService(deepseek.NewDeepSeekClient("sk-test", deepseek.WithBaseUrl(server.BaseUrl())))
+	var cache = NewSemanticCache(service, embedder, WithThreshold(0.9))
+
+	var ask = func(system string, question string) *chat.Response {
+		var request = &chat.Request{
+			Model:    "deepseek-chat",
+			Messages: []*chat.Message{{Role: "system", Content: system}, {Role: "user", Content: question}},
+		}
+
+		var response, err = cache.Chat(context.Background(), request)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return response
+	}
+
+	if response := ask("tutor", "今天北京的天气怎么样?"); response.Cached {
+		t.Fatal("first question should not be cached")
+	}
+
+	// 只差一个标点, 命中缓存并返回第一次的回答
+	var response = ask("tutor", "今天北京的天气怎么样")
+	if !response.Cached || response.Message.Content != "answer to 今天北京的天气怎么样?" {
+		t.Fatalf("response=%+v", response)
+	}
+
+	// 不同的system prompt是不同的scope
+	if response := ask("poet", "今天北京的天气怎么样"); response.Cached {
+		t.Fatal("different scope should not hit")
+	}
+
+	if response := ask("tutor", "给我讲一个笑话吧"); response.Cached {
+		t.Fatal("different question should not hit")
+	}
+
+	var text string
+	var cached bool
+	var request = &chat.Request{
+		Model:    "deepseek-chat",
+		Messages: []*chat.Message{{Role: "system", Content: "tutor"}, {Role: "user", Content: "今天北京天气怎么样?"}},
+	}
+
+	_ = cache.StreamChat(context.Background(), request, func(response *chat.Response) error {
+		text += response.Message.Content
+		cached = cached || response.Cached
+		return nil
+	})
+
+	if !cached || text != "answer to 今天北京的天气怎么样?" {
+		t.Fatalf("text=%q, cached=%v", text, cached)
+	}
+
+	server.AssertRequestCount(t, agitest.PathChat, 3)
+	if hits, _ := cache.Stats(); hits != 2 {
+		t.Fatalf("hits=%d", hits)
+	}
+}
@@ -0,0 +1,33 @@
+package cache
+
+import (
+	"time"
+
+	"github.com/lixianmin/agi/chat"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+type (
+	// Entry 是缓存的一次chat结果, 与provider无关, 命中时通过ifs.Synthesizer还原成provider的响应类型
+	Entry struct {
+		Model        string      `json:"model"`
+		Content      string      `json:"content"`
+		FinishReason string      `json:"finish_reason"`
+		Usage        *chat.Usage `json:"usage,omitempty"`
+		Chunks       []string    `json:"chunks,omitempty"` // 来自streaming调用时记录每个chunk的内容, 回放时保持相同的分块
+		CreatedAt    time.Time   `json:"created_at"`
+	}
+
+	// Store 是缓存的存储后端, 需要线程安全
+	Store interface {
+		Get(key string) (*Entry, bool)
+		Set(key string, entry *Entry) error
+		Delete(key string) error
+	}
+)
@@ -0,0 +1,104 @@
+package cassette
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+type (
+	// recordingBody 在client读取响应的同时记录下内容与时序, 读完或者关闭时把interaction加入cassette
+	recordingBody struct {
+		recorder    *Recorder
+		interaction *Interaction
+		body        io.ReadCloser
+		startTime   time.Time
+		stream      bool
+		buffer      bytes.Buffer
+		once        sync.Once
+	}
+
+	replayingBody struct {
+		chunks    []Chunk
+		index     int
+		pending   []byte
+		realtime  bool
+		startTime time.Time
+		ctxDone   <-chan struct{}
+	}
+)
+
+func (my *recordingBody) Read(p []byte) (int, error) {
+	var n, err = my.body.Read(p)
+	if n > 0 {
+		if my.stream {
+			var offset = time.Since(my.startTime).Milliseconds()
+			var data = my.recorder.scrub(string(p[:n]))
+			my.interaction.Response.Chunks = append(my.interaction.Response.Chunks, Chunk{OffsetMs: offset, Data: data})
+		} else {
+			my.buffer.Write(p[:n])
+		}
+	}
+
+	if err == io.EOF {
+		my.finish()
+	}
+
+	return n, err
+}
+
+func (my *recordingBody) Close() error {
+	my.finish()
+	return my.body.Close()
+}
+
+func (my *recordingBody) finish() {
+	my.once.Do(func() {
+		if !my.stream {
+			my.interaction.Response.Body = my.recorder.scrub(my.buffer.String())
+		}
+
+		my.recorder.add(my.interaction)
+	})
+}
+
+func (my *replayingBody) Read(p []byte) (int, error) {
+	if len(my.pending) == 0 {
+		if my.index == len(my.chunks) {
+			return 0, io.EOF
+		}
+
+		var chunk = my.chunks[my.index]
+		my.index++
+
+		if my.realtime {
+			var wait = time.Duration(chunk.OffsetMs)*time.Millisecond - time.Since(my.startTime)
+			if wait > 0 {
+				select {
+				case <-time.After(wait):
+				case <-my.ctxDone:
+					return 0, context.Canceled
+				}
+			}
+		}
+
+		my.pending = []byte(chunk.Data)
+	}
+
+	var n = copy(p, my.pending)
+	my.pending = my.pending[n:]
+	return n, nil
+}
+
+func (my *replayingBody) Close() error {
+	return nil
+}
@@ -0,0 +1,103 @@
+package cassette
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+type (
+	// Cassette 是一组录制下来的http请求与响应, 以json格式保存在磁盘上
+	Cassette struct {
+		Version      int            `json:"version"`
+		Interactions []*Interaction `json:"interactions"`
+	}
+
+	Interaction struct {
+		Request  Request  `json:"request"`
+		Response Response `json:"response"`
+	}
+
+	Request struct {
+		Method     string      `json:"method"`
+		Url        string      `json:"url"`
+		Header     http.Header `json:"header,omitempty"`
+		Body       string      `json:"body,omitempty"`
+		BodyBase64 string      `json:"body_base64,omitempty"` // 非utf8的body, 比如上传的音频
+	}
+
+	Response struct {
+		StatusCode int         `json:"status_code"`
+		Header     http.Header `json:"header,omitempty"`
+		Body       string      `json:"body,omitempty"`
+		Chunks     []Chunk     `json:"chunks,omitempty"` // SSE响应按读取的顺序分块保存, 以便回放时保留时序
+	}
+
+	Chunk struct {
+		OffsetMs int64  `json:"offset_ms"` // 相对于响应开始的时间
+		Data     string `json:"data"`
+	}
+)
+
+const cassetteVersion = 1
+
+func Load(path string) (*Cassette, error) {
+	var bts, err1 = os.ReadFile(path)
+	if err1 != nil {
+		return nil, err1
+	}
+
+	var cassette Cassette
+	if err2 := json.Unmarshal(bts, &cassette); err2 != nil {
+		return nil, err2
+	}
+
+	return &cassette, nil
+}
+
+func (my *Cassette) Save(path string) error {
+	my.Version = cassetteVersion
+	var bts, err1 = json.MarshalIndent(my, "", "  ")
+	if err1 != nil {
+		return err1
+	}
+
+	if err2 := os.MkdirAll(filepath.Dir(path), 0755); err2 != nil {
+		return err2
+	}
+
+	return os.WriteFile(path, append(bts, '\n'), 0644)
+}
+
+// GetBody 返回请求的原始body
+func (my *Request) GetBody() []byte {
+	if my.BodyBase64 != "" {
+		var bts, _ = base64.StdEncoding.DecodeString(my.BodyBase64)
+		return bts
+	}
+
+	return []byte(my.Body)
+}
+
+func (my *Request) setBody(body []byte) {
+	if utf8.Valid(body) {
+		my.Body = string(body)
+	} else {
+		my.BodyBase64 = base64.StdEncoding.EncodeToString(body)
+	}
+}
+
+func isEventStream(header http.Header) bool {
+	return strings.HasPrefix(header.Get("Content-Type"), "text/event-stream")
+}
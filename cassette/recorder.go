@@ -0,0 +1,353 @@
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+type Mode int
+
+const (
+	ModeReplay   Mode = iota // 只从cassette回放, 找不到匹配的录制时返回ErrInteractionNotFound
+	ModeRecord               // 发送真实请求, 并在Stop()时保存cassette
+	ModeDisabled             // 直接透传, 既不录制也不回放
+)
+
+// EnvMode 用于在测试中切换模式: AGI_CASSETTE=record|disabled, 默认为replay
+const EnvMode = "AGI_CASSETTE"
+
+var ErrInteractionNotFound = errors.New("cassette: interaction not found")
+
+var (
+	sensitiveHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "X-Api-Key", "Api-Key"}
+	secretPattern    = regexp.MustCompile(`sk-[A-Za-z0-9_\-]{8,}`)
+)
+
+const redacted = "[REDACTED]"
+
+type (
+	// Matcher 判断一个真实请求是否与录制的请求匹配, body是真实请求的body
+	Matcher func(request *http.Request, body []byte, recorded *Request) bool
+
+	// Scrubber 在保存之前处理body与url中的敏感信息
+	Scrubber func(text string) string
+
+	Recorder struct {
+		path      string
+		mode      Mode
+		transport http.RoundTripper
+		matcher   Matcher
+		scrubbers []Scrubber
+		realtime  bool
+
+		cassette *Cassette
+		used     []bool
+		m        sync.Mutex
+	}
+
+	recorderOptions struct {
+		mode      Mode
+		transport http.RoundTripper
+		matcher   Matcher
+		scrubbers []Scrubber
+		realtime  bool
+	}
+
+	RecorderOption func(*recorderOptions)
+)
+
+func WithMode(mode Mode) RecorderOption {
+	return func(options *recorderOptions) {
+		options.mode = mode
+	}
+}
+
+// WithTransport 设置录制时真正发送请求的transport, 默认为http.DefaultTransport
+func WithTransport(transport http.RoundTripper) RecorderOption {
+	return func(options *recorderOptions) {
+		if transport != nil {
+			options.transport = transport
+		}
+	}
+}
+
+func WithMatcher(matcher Matcher) RecorderOption {
+	return func(options *recorderOptions) {
+		if matcher != nil {
+			options.matcher = matcher
+		}
+	}
+}
+
+// WithScrubber 追加自定义的脱敏函数, 默认已经会去掉认证相关的header以及形如sk-xxx的key
+func WithScrubber(scrubber Scrubber) RecorderOption {
+	return func(options *recorderOptions) {
+		if scrubber != nil {
+			options.scrubbers = append(options.scrubbers, scrubber)
+		}
+	}
+}
+
+// WithRealtime 回放SSE响应时按录制的时间间隔输出chunk, 默认不等待以保证测试快速且确定
+func WithRealtime(realtime bool) RecorderOption {
+	return func(options *recorderOptions) {
+		options.realtime = realtime
+	}
+}
+
+// ModeFromEnv 从环境变量AGI_CASSETTE中读取模式
+func ModeFromEnv() Mode {
+	switch strings.ToLower(os.Getenv(EnvMode)) {
+	case "record":
+		return ModeRecord
+	case "disabled", "live":
+		return ModeDisabled
+	default:
+		return ModeReplay
+	}
+}
+
+// NewRecorder 在replay模式下会立即加载path指向的cassette
+func NewRecorder(path string, opts ...RecorderOption) (*Recorder, error) {
+	// 默认值
+	var options = recorderOptions{
+		mode:      ModeReplay,
+		transport: http.DefaultTransport,
+		matcher:   DefaultMatcher,
+	}
+
+	// 初始化
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	var recorder = &Recorder{
+		path:      path,
+		mode:      options.mode,
+		transport: options.transport,
+		matcher:   options.matcher,
+		scrubbers: append([]Scrubber{scrubSecretKey}, options.scrubbers...),
+		realtime:  options.realtime,
+		cassette:  &Cassette{},
+	}
+
+	if recorder.mode == ModeReplay {
+		var cassette, err = Load(path)
+		if err != nil {
+			return nil, err
+		}
+
+		recorder.cassette = cassette
+		recorder.used = make([]bool, len(cassette.Interactions))
+	}
+
+	return recorder, nil
+}
+
+func (my *Recorder) Mode() Mode {
+	return my.mode
+}
+
+// Client 返回使用当前Recorder作为transport的http.Client
+func (my *Recorder) Client() *http.Client {
+	return &http.Client{Transport: my}
+}
+
+// Stop 在record模式下把录制结果保存到磁盘
+func (my *Recorder) Stop() error {
+	if my.mode != ModeRecord {
+		return nil
+	}
+
+	my.m.Lock()
+	defer my.m.Unlock()
+	return my.cassette.Save(my.path)
+}
+
+func (my *Recorder) RoundTrip(request *http.Request) (*http.Response, error) {
+	switch my.mode {
+	case ModeRecord:
+		return my.record(request)
+	case ModeDisabled:
+		return my.transport.RoundTrip(request)
+	default:
+		return my.replay(request)
+	}
+}
+
+func (my *Recorder) record(request *http.Request) (*http.Response, error) {
+	var body, err1 = readRequestBody(request)
+	if err1 != nil {
+		return nil, err1
+	}
+
+	var response, err2 = my.transport.RoundTrip(request)
+	if err2 != nil {
+		return nil, err2
+	}
+
+	var interaction = &Interaction{
+		Request: Request{
+			Method: request.Method,
+			Url:    my.scrub(request.URL.String()),
+			Header: my.scrubHeader(request.Header),
+		},
+		Response: Response{
+			StatusCode: response.StatusCode,
+			Header:     my.scrubHeader(response.Header),
+		},
+	}
+	interaction.Request.setBody(body)
+	interaction.Request.Body = my.scrub(interaction.Request.Body)
+
+	response.Body = &recordingBody{
+		recorder:    my,
+		interaction: interaction,
+		body:        response.Body,
+		startTime:   time.Now(),
+		stream:      isEventStream(response.Header),
+	}
+
+	return response, nil
+}
+
+func (my *Recorder) replay(request *http.Request) (*http.Response, error) {
+	var body, err1 = readRequestBody(request)
+	if err1 != nil {
+		return nil, err1
+	}
+
+	var interaction = my.take(request, body)
+	if interaction == nil {
+		return nil, fmt.Errorf("%w: %s %s", ErrInteractionNotFound, request.Method, request.URL)
+	}
+
+	var recorded = interaction.Response
+	var response = &http.Response{
+		Status:     fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+		StatusCode: recorded.StatusCode,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     recorded.Header.Clone(),
+		Request:    request,
+	}
+
+	if response.Header == nil {
+		response.Header = http.Header{}
+	}
+
+	if len(recorded.Chunks) > 0 {
+		response.Body = &replayingBody{chunks: recorded.Chunks, realtime: my.realtime, startTime: time.Now(), ctxDone: request.Context().Done()}
+	} else {
+		response.Body = io.NopCloser(strings.NewReader(recorded.Body))
+		response.ContentLength = int64(len(recorded.Body))
+	}
+
+	return response, nil
+}
+
+// take 按录制顺序返回第一个尚未使用的匹配项
+func (my *Recorder) take(request *http.Request, body []byte) *Interaction {
+	my.m.Lock()
+	defer my.m.Unlock()
+
+	for i, interaction := range my.cassette.Interactions {
+		if !my.used[i] && my.matcher(request, body, &interaction.Request) {
+			my.used[i] = true
+			return interaction
+		}
+	}
+
+	return nil
+}
+
+func (my *Recorder) add(interaction *Interaction) {
+	my.m.Lock()
+	my.cassette.Interactions = append(my.cassette.Interactions, interaction)
+	my.m.Unlock()
+}
+
+func (my *Recorder) scrub(text string) string {
+	for _, scrubber := range my.scrubbers {
+		text = scrubber(text)
+	}
+
+	return text
+}
+
+func (my *Recorder) scrubHeader(header http.Header) http.Header {
+	var cloned = header.Clone()
+	for _, key := range sensitiveHeaders {
+		if cloned.Get(key) != "" {
+			cloned.Set(key, redacted)
+		}
+	}
+
+	for key, values := range cloned {
+		for i := range values {
+			values[i] = my.scrub(values[i])
+		}
+		cloned[key] = values
+	}
+
+	return cloned
+}
+
+// DefaultMatcher 比较method与url; 如果是json请求, 则还比较规范化之后的body
+func DefaultMatcher(request *http.Request, body []byte, recorded *Request) bool {
+	if request.Method != recorded.Method || request.URL.String() != recorded.Url {
+		return false
+	}
+
+	if !strings.HasPrefix(request.Header.Get("Content-Type"), "application/json") {
+		return true
+	}
+
+	return canonicalJson(body) == canonicalJson(recorded.GetBody())
+}
+
+func canonicalJson(body []byte) string {
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return string(body)
+	}
+
+	var bts, _ = json.Marshal(v)
+	return string(bts)
+}
+
+func scrubSecretKey(text string) string {
+	return secretPattern.ReplaceAllString(text, redacted)
+}
+
+func readRequestBody(request *http.Request) ([]byte, error) {
+	if request.Body == nil {
+		return nil, nil
+	}
+
+	var body, err = io.ReadAll(request.Body)
+	_ = request.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	request.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
@@ -0,0 +1,87 @@
+package cassette
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+func TestRecordAndReplay(t *testing.T) {
+	var server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, word := range []string{"hello", "world"} {
+			_, _ = io.WriteString(w, "data: "+word+"\n\n")
+			w.(http.Flusher).Flush()
+			time.Sleep(20 * time.Millisecond)
+		}
+	}))
+
+	var path = filepath.Join(t.TempDir(), "stream.json")
+	var recorder, _ = NewRecorder(path, WithMode(ModeRecord))
+	var text1 = send(t, recorder.Client(), server.URL)
+	if err := recorder.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	server.Close()
+
+	var saved, _ = os.ReadFile(path)
+	if strings.Contains(string(saved), "sk-0123456789abcdef") {
+		t.Fatal("secret key is not scrubbed")
+	}
+
+	var player, err = NewRecorder(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var text2 = send(t, player.Client(), server.URL)
+	if text1 != text2 || !strings.Contains(text2, "data: world") {
+		t.Fatalf("text1=%q, text2=%q", text1, text2)
+	}
+
+	var cassette, _ = Load(path)
+	var chunks = cassette.Interactions[0].Response.Chunks
+	if len(chunks) < 2 || chunks[len(chunks)-1].OffsetMs < 20 {
+		t.Fatalf("chunks=%v", chunks)
+	}
+
+	// 录制的内容已经用完
+	var request, _ = http.NewRequest(http.MethodPost, server.URL+"/chat", strings.NewReader(`{"model":"m"}`))
+	request.Header.Set("Content-Type", "application/json")
+	if _, err := player.Client().Do(request); err == nil {
+		t.Fatal("error expected")
+	}
+}
+
+func send(t *testing.T, client *http.Client, url string) string {
+	// 字段顺序与录制时不同, 但规范化之后是相同的json
+	var body = `{"stream":true, "model":"m"}`
+	if client.Transport.(*Recorder).Mode() == ModeRecord {
+		body = `{"model":"m","stream":true}`
+	}
+
+	var request, _ = http.NewRequest(http.MethodPost, url+"/chat", strings.NewReader(body))
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Authorization", "Bearer sk-0123456789abcdef")
+
+	var response, err = client.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	var bts, _ = io.ReadAll(response.Body)
+	return string(bts)
+}
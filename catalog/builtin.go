@@ -0,0 +1,81 @@
+package catalog
+
+import (
+	"sync"
+
+	"github.com/lixianmin/agi/deepseek"
+	"github.com/lixianmin/agi/ifs"
+	"github.com/lixianmin/agi/siliconflow"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+var (
+	builtinOnce    sync.Once
+	builtinCatalog *Catalog
+)
+
+// Builtin 返回内置的模型能力表, 数据整理自各provider的文档, 价格单位为人民币每百万token.
+// provider调整之后可以通过Register覆盖, 返回的是共享的实例
+func Builtin() *Catalog {
+	builtinOnce.Do(func() {
+		builtinCatalog = NewCatalog(builtinCapabilities()...)
+	})
+
+	return builtinCatalog
+}
+
+func builtinCapabilities() []*Capability {
+	return []*Capability{
+		{
+			Provider:        ifs.ProviderDeepSeek,
+			Model:           deepseek.ModelChat,
+			Type:            TypeChat,
+			ContextLength:   64 * 1024,
+			MaxOutputTokens: 8192,
+			Tools:           true,
+			JsonMode:        true,
+			InputPrice:      2,
+			OutputPrice:     8,
+			Currency:        "CNY",
+		},
+		{
+			Provider:        ifs.ProviderDeepSeek,
+			Model:           deepseek.ModelReasoner,
+			Type:            TypeChat,
+			ContextLength:   64 * 1024,
+			MaxOutputTokens: 8192,
+			InputPrice:      4,
+			OutputPrice:     16,
+			Currency:        "CNY",
+		},
+		{
+			Provider:        ifs.ProviderSiliconFlow,
+			Model:           siliconflow.ModelQwen2Instruct,
+			Type:            TypeChat,
+			ContextLength:   32 * 1024,
+			MaxOutputTokens: 4096,
+			Tools:           true,
+			JsonMode:        true,
+			Currency:        "CNY",
+		},
+		{
+			Provider:      ifs.ProviderSiliconFlow,
+			Model:         siliconflow.ModelBgeM3,
+			Type:          TypeEmbedding,
+			ContextLength: 8192,
+			Currency:      "CNY",
+		},
+		{
+			Provider: ifs.ProviderSiliconFlow,
+			Model:    siliconflow.ModelSenseVoiceSmall,
+			Type:     TypeTranscription,
+			Currency: "CNY",
+		},
+	}
+}
@@ -0,0 +1,280 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/lixianmin/agi/chat"
+	"github.com/lixianmin/agi/ifs"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+const (
+	TypeChat          = "chat"
+	TypeEmbedding     = "embedding"
+	TypeTranscription = "transcription"
+)
+
+const (
+	FeatureVision Feature = iota + 1
+	FeatureTools
+	FeatureJson
+)
+
+var (
+	ErrUnknownModel       = errors.New("unknown model")
+	ErrWrongModelType     = errors.New("wrong model type")
+	ErrContextTooLong     = errors.New("context too long")
+	ErrMaxTokensTooLarge  = errors.New("max tokens too large")
+	ErrUnsupportedFeature = errors.New("unsupported feature")
+)
+
+type (
+	// Feature 是模型可选支持的能力
+	Feature int
+
+	// Capability 描述一个模型的能力与价格, 价格的单位是每百万token, 为0表示免费或者未知
+	Capability struct {
+		Provider        string  `json:"provider"`
+		Model           string  `json:"model"`
+		Type            string  `json:"type"` // TypeChat, TypeEmbedding, TypeTranscription
+		ContextLength   int     `json:"context_length,omitempty"`
+		MaxOutputTokens int     `json:"max_output_tokens,omitempty"`
+		Vision          bool    `json:"vision,omitempty"`
+		Tools           bool    `json:"tools,omitempty"`
+		JsonMode        bool    `json:"json_mode,omitempty"`
+		InputPrice      float64 `json:"input_price,omitempty"`
+		OutputPrice     float64 `json:"output_price,omitempty"`
+		Currency        string  `json:"currency,omitempty"`
+	}
+
+	// Catalog 按provider与model索引Capability, 线程安全
+	Catalog struct {
+		capabilities map[string]*Capability
+		m            sync.RWMutex
+	}
+
+	// chatFields 是各provider的chat请求中与校验相关的公共字段
+	chatFields struct {
+		Model     string          `json:"model"`
+		Messages  []*chat.Message `json:"messages"`
+		MaxTokens int             `json:"max_tokens"`
+	}
+)
+
+func NewCatalog(capabilities ...*Capability) *Catalog {
+	var catalog = &Catalog{capabilities: make(map[string]*Capability, len(capabilities))}
+	for _, capability := range capabilities {
+		catalog.Register(capability)
+	}
+
+	return catalog
+}
+
+// Register 添加或覆盖一个模型的能力, 可以用来修正内置的数据
+func (my *Catalog) Register(capability *Capability) {
+	if capability == nil || capability.Provider == "" || capability.Model == "" {
+		return
+	}
+
+	var cloned = *capability
+	if cloned.Type == "" {
+		cloned.Type = TypeChat
+	}
+
+	my.m.Lock()
+	my.capabilities[key(cloned.Provider, cloned.Model)] = &cloned
+	my.m.Unlock()
+}
+
+// Lookup 返回模型能力的副本
+func (my *Catalog) Lookup(provider string, model string) (Capability, bool) {
+	my.m.RLock()
+	var capability, ok = my.capabilities[key(provider, model)]
+	my.m.RUnlock()
+
+	if !ok {
+		return Capability{}, false
+	}
+
+	return *capability, true
+}
+
+// Models 返回provider下所有已知的模型, 按名字排序; provider为空时返回全部
+func (my *Catalog) Models(provider string) []Capability {
+	my.m.RLock()
+	var list = make([]Capability, 0, len(my.capabilities))
+	for _, capability := range my.capabilities {
+		if provider == "" || capability.Provider == provider {
+			list = append(list, *capability)
+		}
+	}
+	my.m.RUnlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Provider != list[j].Provider {
+			return list[i].Provider < list[j].Provider
+		}
+		return list[i].Model < list[j].Model
+	})
+
+	return list
+}
+
+// Validate 在发送之前检查chat请求: 模型类型, max_tokens是否超出上限, 以及估算的prompt与max_tokens之和是否超出上下文长度
+func (my *Catalog) Validate(provider string, request *chat.Request) error {
+	if request == nil {
+		return ifs.ErrRequestIsNil
+	}
+
+	var maxTokens = 0
+	if request.Params != nil {
+		maxTokens = int(request.Params.MaxTokens)
+	}
+
+	return my.validate(provider, request.Model, request.Messages, maxTokens)
+}
+
+// Require 检查模型是否支持所有features, 比如调用方准备发送图片或者使用function calling
+func (my *Catalog) Require(provider string, model string, features ...Feature) error {
+	var capability, ok = my.Lookup(provider, model)
+	if !ok {
+		return fmt.Errorf("%w: %s/%s", ErrUnknownModel, provider, model)
+	}
+
+	for _, feature := range features {
+		if !capability.Supports(feature) {
+			return fmt.Errorf("%w: %s does not support %s", ErrUnsupportedFeature, model, feature)
+		}
+	}
+
+	return nil
+}
+
+// Cost 按价格计算usage的费用, 模型未知时返回false
+func (my *Catalog) Cost(provider string, model string, usage *chat.Usage) (float64, bool) {
+	var capability, ok = my.Lookup(provider, model)
+	if !ok || usage == nil {
+		return 0, ok
+	}
+
+	var cost = float64(usage.PromptTokens)*capability.InputPrice + float64(usage.CompletionTokens)*capability.OutputPrice
+	return cost / 1e6, true
+}
+
+// Interceptor 在发送chat请求之前做校验, 不通过时直接返回错误而不访问provider. 未知的模型直接放行
+func (my *Catalog) Interceptor() ifs.Interceptor {
+	return func(ctx context.Context, call *ifs.Call, next ifs.Invoker) (any, error) {
+		if call.Endpoint != ifs.EndpointChat && call.Endpoint != ifs.EndpointStreamChat {
+			return next(ctx, call)
+		}
+
+		// 与cache一样通过json读取公共字段, 不依赖具体provider的请求类型. 此时的请求已经合并了thread级的参数
+		var bts, err1 = json.Marshal(call.Request)
+		if err1 != nil {
+			return next(ctx, call)
+		}
+
+		var fields chatFields
+		if err2 := json.Unmarshal(bts, &fields); err2 != nil {
+			return next(ctx, call)
+		}
+
+		var err3 = my.validate(call.Provider, fields.Model, fields.Messages, fields.MaxTokens)
+		if err3 != nil && !errors.Is(err3, ErrUnknownModel) {
+			return nil, err3
+		}
+
+		return next(ctx, call)
+	}
+}
+
+func (my *Catalog) validate(provider string, model string, messages []*chat.Message, maxTokens int) error {
+	var capability, ok = my.Lookup(provider, model)
+	if !ok {
+		return fmt.Errorf("%w: %s/%s", ErrUnknownModel, provider, model)
+	}
+
+	if capability.Type != TypeChat {
+		return fmt.Errorf("%w: %s is a %s model", ErrWrongModelType, model, capability.Type)
+	}
+
+	if capability.MaxOutputTokens > 0 && maxTokens > capability.MaxOutputTokens {
+		return fmt.Errorf("%w: %d > %d for %s", ErrMaxTokensTooLarge, maxTokens, capability.MaxOutputTokens, model)
+	}
+
+	if capability.ContextLength > 0 {
+		var promptTokens = EstimateTokens(messages)
+		if promptTokens+maxTokens > capability.ContextLength {
+			return fmt.Errorf("%w: about %d prompt tokens + %d max tokens > %d for %s", ErrContextTooLong, promptTokens, maxTokens, capability.ContextLength, model)
+		}
+	}
+
+	return nil
+}
+
+// Supports 判断模型是否支持feature
+func (my *Capability) Supports(feature Feature) bool {
+	switch feature {
+	case FeatureVision:
+		return my.Vision
+	case FeatureTools:
+		return my.Tools
+	case FeatureJson:
+		return my.JsonMode
+	default:
+		return false
+	}
+}
+
+func (my Feature) String() string {
+	switch my {
+	case FeatureVision:
+		return "vision"
+	case FeatureTools:
+		return "tools"
+	case FeatureJson:
+		return "json mode"
+	default:
+		return fmt.Sprintf("feature(%d)", int(my))
+	}
+}
+
+// EstimateTokens 粗略估算消息的token数: 英文约4个字符一个token, 中日韩等非ASCII字符约一个字符一个token, 每条消息另加4个token的格式开销.
+// 只用于发送前的校验, 宁可略微高估
+func EstimateTokens(messages []*chat.Message) int {
+	var tokens = 0
+	for _, message := range messages {
+		if message == nil {
+			continue
+		}
+
+		var ascii = 0
+		for _, r := range message.Content {
+			if r < utf8.RuneSelf {
+				ascii++
+			} else {
+				tokens++
+			}
+		}
+
+		tokens += (ascii+3)/4 + 4
+	}
+
+	return tokens
+}
+
+func key(provider string, model string) string {
+	return provider + "/" + model
+}
@@ -0,0 +1,94 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lixianmin/agi/agitest"
+	"github.com/lixianmin/agi/chat"
+	"github.com/lixianmin/agi/deepseek"
+	"github.com/lixianmin/agi/ifs"
+	"github.com/lixianmin/agi/siliconflow"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+func TestValidate(t *testing.T) {
+	var catalog = Builtin()
+	var messages = []*chat.Message{{Role: "user", Content: "hello"}}
+
+	var request = &chat.Request{Model: deepseek.ModelChat, Messages: messages, Params: &chat.Params{MaxTokens: 1024}}
+	if err := catalog.Validate(ifs.ProviderDeepSeek, request); err != nil {
+		t.Fatal(err)
+	}
+
+	request.Params.MaxTokens = 10000
+	if err := catalog.Validate(ifs.ProviderDeepSeek, request); !errors.Is(err, ErrMaxTokensTooLarge) {
+		t.Fatalf("err=%v", err)
+	}
+
+	var long = &chat.Request{Model: siliconflow.ModelQwen2Instruct, Messages: []*chat.Message{{Role: "user", Content: strings.Repeat("长", 40000)}}}
+	if err := catalog.Validate(ifs.ProviderSiliconFlow, long); !errors.Is(err, ErrContextTooLong) {
+		t.Fatalf("err=%v", err)
+	}
+
+	var embedding = &chat.Request{Model: siliconflow.ModelBgeM3, Messages: messages}
+	if err := catalog.Validate(ifs.ProviderSiliconFlow, embedding); !errors.Is(err, ErrWrongModelType) {
+		t.Fatalf("err=%v", err)
+	}
+
+	if err := catalog.Validate(ifs.ProviderDeepSeek, &chat.Request{Model: "unknown", Messages: messages}); !errors.Is(err, ErrUnknownModel) {
+		t.Fatalf("err=%v", err)
+	}
+
+	if err := catalog.Require(ifs.ProviderDeepSeek, deepseek.ModelChat, FeatureTools, FeatureJson); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := catalog.Require(ifs.ProviderDeepSeek, deepseek.ModelChat, FeatureVision); !errors.Is(err, ErrUnsupportedFeature) {
+		t.Fatalf("err=%v", err)
+	}
+
+	var cost, ok = catalog.Cost(ifs.ProviderDeepSeek, deepseek.ModelChat, &chat.Usage{PromptTokens: 1000000, CompletionTokens: 500000})
+	if !ok || cost != 6 {
+		t.Fatalf("cost=%v", cost)
+	}
+
+	if models := catalog.Models(ifs.ProviderSiliconFlow); len(models) != 3 || models[0].Model != siliconflow.ModelBgeM3 {
+		t.Fatalf("models=%v", models)
+	}
+}
+
+func TestInterceptor(t *testing.T) {
+	var server = agitest.NewTestServer(t)
+	var catalog = NewCatalog(&Capability{Provider: ifs.ProviderDeepSeek, Model: deepseek.ModelChat, ContextLength: 100, MaxOutputTokens: 50})
+	var client = deepseek.NewDeepSeekClient("sk-test", deepseek.WithBaseUrl(server.BaseUrl()), deepseek.WithInterceptors(catalog.Interceptor()))
+	var service = deepseek.NewChatService(client)
+
+	var thread, _ = chat.NewThread(chat.WithoutSystemPrompt(), chat.WithMaxTokens(80))
+	thread.AddUserMessage("hi")
+
+	// thread级的max_tokens在发送前被合并, 因此同样会被校验
+	if _, err := service.Chat(context.Background(), thread.NewRequest(deepseek.ModelChat)); !errors.Is(err, ErrMaxTokensTooLarge) {
+		t.Fatalf("err=%v", err)
+	}
+	server.AssertRequestCount(t, agitest.PathChat, 0)
+
+	thread.SetParams(chat.Params{MaxTokens: 20})
+	if _, err := service.Chat(context.Background(), thread.NewRequest(deepseek.ModelChat)); err != nil {
+		t.Fatal(err)
+	}
+
+	// 未知的模型直接放行
+	if _, err := service.Chat(context.Background(), thread.NewRequest("deepseek-unknown")); err != nil {
+		t.Fatal(err)
+	}
+	server.AssertRequestCount(t, agitest.PathChat, 2)
+}
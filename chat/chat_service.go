@@ -1,5 +1,10 @@
 package chat
 
+import (
+	"context"
+	"time"
+)
+
 /********************************************************************
 created:    2024-06-30
 author:     lixianmin
@@ -7,6 +12,29 @@ author:     lixianmin
 Copyright (C) - All Rights Reserved
 *********************************************************************/
 
-// type ChatService interface {
-// 	StreamChat(ctx context.Context, thread *Thread, fn func(line string)) (string, error)
-// }
+type (
+	// ChatService 是与provider无关的chat接口, deepseek与siliconflow都提供了实现(NewChatService),
+	// 缓存, RAG等功能可以包裹在任意的ChatService之外
+	ChatService interface {
+		Chat(ctx context.Context, request *Request) (*Response, error)
+		StreamChat(ctx context.Context, request *Request, fn ResponseFunc) error
+	}
+
+	// Response 在非streaming调用中是完整的回答, 在streaming调用中是一个chunk
+	Response struct {
+		ID           string  `json:"id,omitempty"`
+		Model        string  `json:"model"`
+		Message      Message `json:"message"`
+		FinishReason string  `json:"finish_reason,omitempty"`
+		Usage        *Usage  `json:"usage,omitempty"`
+		Done         bool    `json:"done"`
+
+		// Latency 是从发送请求到收到这个响应(streaming时为这个chunk)的耗时
+		Latency time.Duration `json:"latency,omitempty"`
+
+		// Cached 表示回答来自缓存而不是provider
+		Cached bool `json:"cached,omitempty"`
+	}
+
+	ResponseFunc func(response *Response) error
+)
@@ -0,0 +1,26 @@
+package chat
+
+import "errors"
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+// EvictionPolicy 决定滚动窗口满了之后如何淘汰消息
+type EvictionPolicy int
+
+const (
+	EvictPair    EvictionPolicy = iota // 默认, 淘汰最早的消息之后, 如果窗口以bot的回答开头则一并淘汰, 保证窗口总是以完整的一问一答开始
+	EvictMessage                       // 每次只淘汰最早的一条消息
+)
+
+var (
+	ErrInvalidThreadOption = errors.New("invalid thread option")
+	ErrMessageNotFound     = errors.New("message not found")
+	ErrNotBotMessage       = errors.New("not a bot message")
+	ErrBranchNotFound      = errors.New("branch not found")
+	ErrRequestIsNil        = errors.New("request is nil")
+)
@@ -11,11 +11,21 @@ type (
 	Message struct {
 		Role    string `json:"role"`
 		Content string `json:"content"`
+		Name    string `json:"name,omitempty"` // 多人对话时区分同一个role下的不同参与者
+	}
+
+	Usage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
 	}
 
 	Request struct {
 		Model    string     `json:"model"`
 		Messages []*Message `json:"messages"`
 		Stream   bool       `json:"stream,omitempty"`
+
+		// Params 通常来自Thread.NewRequest(), 是thread级的默认采样参数, 不会直接发送. client在发送前用请求中显式设置的字段覆盖它, 并按provider裁剪
+		Params *Params `json:"-"`
 	}
 )
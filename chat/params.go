@@ -0,0 +1,87 @@
+package chat
+
+import "fmt"
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+// Params 是采样参数, 零值表示不设置, 由服务端使用默认值. 各provider的取值范围不同, 由client在发送前裁剪.
+// Temperature为指针, 因为0是一个有意义的取值(贪心解码), 需要与不设置区分开
+type Params struct {
+	Temperature *float32 `json:"temperature,omitempty"`
+	TopP        float32  `json:"top_p,omitempty"`
+	TopK        int32    `json:"top_k,omitempty"`
+	MaxTokens   int32    `json:"max_tokens,omitempty"`
+	Stop        []string `json:"stop,omitempty"`
+}
+
+// Float32 返回v的指针, 用于设置Temperature等可选字段
+func Float32(v float32) *float32 {
+	return &v
+}
+
+// Merge 用override中的非零字段覆盖my, 常用于请求级参数覆盖thread级默认值
+func (my Params) Merge(override Params) Params {
+	if override.Temperature != nil {
+		my.Temperature = Float32(*override.Temperature)
+	}
+
+	if override.TopP != 0 {
+		my.TopP = override.TopP
+	}
+
+	if override.TopK != 0 {
+		my.TopK = override.TopK
+	}
+
+	if override.MaxTokens != 0 {
+		my.MaxTokens = override.MaxTokens
+	}
+
+	if len(override.Stop) > 0 {
+		my.Stop = override.Stop
+	}
+
+	return my
+}
+
+// Validate 只检查与provider无关的约束, 比如负数
+func (my Params) Validate() error {
+	if my.Temperature != nil && *my.Temperature < 0 {
+		return fmt.Errorf("invalid temperature %v", *my.Temperature)
+	}
+
+	if my.TopP < 0 || my.TopP > 1 {
+		return fmt.Errorf("invalid top_p %v", my.TopP)
+	}
+
+	if my.TopK < 0 {
+		return fmt.Errorf("invalid top_k %v", my.TopK)
+	}
+
+	if my.MaxTokens < 0 {
+		return fmt.Errorf("invalid max_tokens %v", my.MaxTokens)
+	}
+
+	for _, stop := range my.Stop {
+		if stop == "" {
+			return fmt.Errorf("empty stop sequence")
+		}
+	}
+
+	return nil
+}
+
+// clone 复制Stop与Temperature, 避免与thread共享
+func (my Params) clone() Params {
+	if my.Temperature != nil {
+		my.Temperature = Float32(*my.Temperature)
+	}
+
+	my.Stop = append([]string(nil), my.Stop...)
+	return my
+}
@@ -0,0 +1,50 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+// AdaptChat 是各provider实现ChatService.Chat的公共部分: 检查参数, 计时并补全Done与Latency.
+// invoke只负责发送provider自己的请求, 并把响应映射为Response
+func AdaptChat(ctx context.Context, request *Request, invoke func(ctx context.Context, request *Request) (*Response, error)) (*Response, error) {
+	if request == nil {
+		return nil, ErrRequestIsNil
+	}
+
+	var startTime = time.Now()
+	var response, err = invoke(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	response.Done = true
+	response.Latency = time.Since(startTime)
+	return response, nil
+}
+
+// AdaptStreamChat 是各provider实现ChatService.StreamChat的公共部分: 检查参数, 并为每个chunk补全Latency.
+// invoke负责发送provider自己的请求, 把每个chunk映射为Response之后交给emit
+func AdaptStreamChat(ctx context.Context, request *Request, fn ResponseFunc, invoke func(ctx context.Context, request *Request, emit ResponseFunc) error) error {
+	if request == nil {
+		return ErrRequestIsNil
+	}
+
+	if fn == nil {
+		return errors.New("fn is nil")
+	}
+
+	var startTime = time.Now()
+	return invoke(ctx, request, func(response *Response) error {
+		response.Latency = time.Since(startTime)
+		return fn(response)
+	})
+}
@@ -0,0 +1,179 @@
+package chat
+
+import (
+	"context"
+	"io"
+	"strings"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+type (
+	// Stream 把回调式的ChatService.StreamChat转换为拉取式的Recv()/Close(), 也可以作为channel或者iterator使用
+	Stream struct {
+		responses chan *Response
+		cancel    context.CancelFunc
+		err       error // 在responses关闭之前写入, 因此读到关闭之后可以安全访问
+	}
+
+	// Seq 与go1.23的iter.Seq2[*Response, error]形状相同: go1.23+可以直接for range, go1.22中可以直接调用并传入yield
+	Seq func(yield func(response *Response, err error) bool)
+
+	// Accumulator 把streaming的chunk拼接为完整的回答, 同时可以把文本实时写入writer(比如os.Stdout或http.ResponseWriter)
+	Accumulator struct {
+		writer   io.Writer
+		builder  strings.Builder
+		response Response
+	}
+)
+
+// OpenStream 在后台goroutine中调用service.StreamChat, 调用方需要读到io.EOF或者调用Close, 否则goroutine不会退出
+func OpenStream(ctx context.Context, service ChatService, request *Request) *Stream {
+	var streamCtx, cancel = context.WithCancel(ctx)
+	var stream = &Stream{
+		responses: make(chan *Response),
+		cancel:    cancel,
+	}
+
+	go func() {
+		var err = service.StreamChat(streamCtx, request, func(response *Response) error {
+			select {
+			case stream.responses <- response:
+				return nil
+			case <-streamCtx.Done():
+				return streamCtx.Err()
+			}
+		})
+
+		stream.err = err
+		close(stream.responses)
+	}()
+
+	return stream
+}
+
+// Recv 返回下一个chunk, 正常结束时返回io.EOF
+func (my *Stream) Recv() (*Response, error) {
+	var response, ok = <-my.responses
+	if ok {
+		return response, nil
+	}
+
+	if my.err != nil {
+		return nil, my.err
+	}
+
+	return nil, io.EOF
+}
+
+// Chan 返回chunk的channel, 读完之后通过Err()获取错误
+func (my *Stream) Chan() <-chan *Response {
+	return my.responses
+}
+
+// Err 只能在Chan()被关闭之后调用
+func (my *Stream) Err() error {
+	return my.err
+}
+
+// All 返回一个iterator, 出错时yield一次(nil, err)之后结束; 提前退出时自动Close
+func (my *Stream) All() Seq {
+	return func(yield func(response *Response, err error) bool) {
+		for {
+			var response, err = my.Recv()
+			if err == io.EOF {
+				return
+			}
+
+			if !yield(response, err) {
+				my.Close()
+				return
+			}
+
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Close 取消上游的请求, 并等待后台goroutine退出. 可以重复调用
+func (my *Stream) Close() {
+	my.cancel()
+	for range my.responses {
+	}
+}
+
+// NewAccumulator writer可以为nil
+func NewAccumulator(writer io.Writer) *Accumulator {
+	return &Accumulator{writer: writer}
+}
+
+// Add 可以直接作为ResponseFunc使用
+func (my *Accumulator) Add(response *Response) error {
+	if response == nil {
+		return nil
+	}
+
+	var content = response.Message.Content
+	if content != "" {
+		my.builder.WriteString(content)
+		if my.writer != nil {
+			if _, err := io.WriteString(my.writer, content); err != nil {
+				return err
+			}
+		}
+	}
+
+	var total = &my.response
+	if response.ID != "" {
+		total.ID = response.ID
+	}
+
+	if response.Model != "" {
+		total.Model = response.Model
+	}
+
+	if response.FinishReason != "" {
+		total.FinishReason = response.FinishReason
+	}
+
+	if response.Usage != nil {
+		total.Usage = response.Usage
+	}
+
+	if response.Latency > 0 {
+		total.Latency = response.Latency
+	}
+
+	total.Cached = total.Cached || response.Cached
+	total.Done = total.Done || response.Done
+	return nil
+}
+
+// Response 返回目前为止拼接好的完整回答
+func (my *Accumulator) Response() *Response {
+	var response = my.response
+	response.Message = Message{Role: "assistant", Content: my.builder.String()}
+	return &response
+}
+
+// StreamTo 流式调用service, 把文本实时写入writer, 成功结束后把完整的回答加入thread. writer与thread都可以为nil
+func StreamTo(ctx context.Context, service ChatService, request *Request, writer io.Writer, thread *Thread) (*Response, error) {
+	var accumulator = NewAccumulator(writer)
+	if err := service.StreamChat(ctx, request, accumulator.Add); err != nil {
+		return nil, err
+	}
+
+	var response = accumulator.Response()
+	if thread != nil {
+		thread.AddResponse(response)
+	}
+
+	return response, nil
+}
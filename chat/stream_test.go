@@ -0,0 +1,128 @@
+package chat
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+// fakeService 依次返回chunks, 最后返回err
+type fakeService struct {
+	chunks []string
+	err    error
+}
+
+func (my *fakeService) Chat(ctx context.Context, request *Request) (*Response, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (my *fakeService) StreamChat(ctx context.Context, request *Request, fn ResponseFunc) error {
+	for _, chunk := range my.chunks {
+		if err := fn(&Response{Model: "fake", Message: Message{Role: "assistant", Content: chunk}}); err != nil {
+			return err
+		}
+	}
+
+	if my.err != nil {
+		return my.err
+	}
+
+	return fn(&Response{FinishReason: "stop", Usage: &Usage{TotalTokens: 3}, Done: true})
+}
+
+func TestStream(t *testing.T) {
+	var service = &fakeService{chunks: []string{"你", "好", "!"}}
+	var stream = OpenStream(context.Background(), service, &Request{})
+
+	var text string
+	for {
+		var response, err = stream.Recv()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+		text += response.Message.Content
+	}
+
+	if text != "你好!" {
+		t.Fatalf("text=%q", text)
+	}
+
+	// channel
+	var failed = OpenStream(context.Background(), &fakeService{chunks: []string{"a"}, err: io.ErrUnexpectedEOF}, &Request{})
+	var count = 0
+	for range failed.Chan() {
+		count++
+	}
+
+	if count != 1 || failed.Err() != io.ErrUnexpectedEOF {
+		t.Fatalf("count=%d, err=%v", count, failed.Err())
+	}
+
+	// iterator, 提前退出时会取消上游
+	var first string
+	OpenStream(context.Background(), service, &Request{}).All()(func(response *Response, err error) bool {
+		first = response.Message.Content
+		return false
+	})
+
+	if first != "你" {
+		t.Fatalf("first=%q", first)
+	}
+}
+
+func TestStreamTo(t *testing.T) {
+	var thread, _ = NewThread()
+	thread.AddUserMessage("hi")
+
+	var output bytes.Buffer
+	var response, err = StreamTo(context.Background(), &fakeService{chunks: []string{"hello", " world"}}, thread.NewRequest("fake"), &output, thread)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var entries = thread.Entries()
+	var last = entries[len(entries)-1]
+	if output.String() != "hello world" || response.Usage.TotalTokens != 3 || last.Message.Content != "hello world" || last.FinishReason != "stop" {
+		t.Fatalf("output=%q, last=%+v", output.String(), last)
+	}
+}
+
+func TestAdaptService(t *testing.T) {
+	var ctx = context.Background()
+	if _, err := AdaptChat(ctx, nil, nil); err != ErrRequestIsNil {
+		t.Fatalf("err=%v", err)
+	}
+
+	var response, err1 = AdaptChat(ctx, &Request{}, func(ctx context.Context, request *Request) (*Response, error) {
+		return &Response{Model: "fake"}, nil
+	})
+	if err1 != nil || !response.Done || response.Latency <= 0 {
+		t.Fatalf("response=%+v, err=%v", response, err1)
+	}
+
+	var service = &fakeService{chunks: []string{"a", "b"}}
+	var count = 0
+	var err2 = AdaptStreamChat(ctx, &Request{}, func(response *Response) error {
+		if response.Latency <= 0 {
+			t.Fatalf("latency is not set: %+v", response)
+		}
+		count++
+		return nil
+	}, service.StreamChat)
+	if err2 != nil || count != 3 {
+		t.Fatalf("count=%d, err=%v", count, err2)
+	}
+}
@@ -1,6 +1,10 @@
 package chat
 
-import "sync"
+import (
+	"strconv"
+	"sync"
+	"time"
+)
 
 /********************************************************************
 created:    2024-06-01
@@ -13,23 +17,54 @@ type (
 	Thread struct {
 		userRole string
 		botRole  string
+		params   Params
+
+		prompt      *Message   // system prompt
+		pinned      []*Message // 固定在system prompt之后的消息(few-shot示例, 额外的system消息), 永远不会被淘汰
+		entries     []*entry   // 滚动窗口, 只包含实时对话, 超过historySize后淘汰最早的消息
+		historySize int
+		eviction    EvictionPolicy
+		nextId      uint64
+		m           sync.Mutex
+	}
+
+	// Entry 是thread中存储的一条消息及其元数据, ID在thread内唯一. 发送给API的只有Message
+	Entry struct {
+		ID        string    `json:"id"`
+		Message   *Message  `json:"message"`
+		CreatedAt time.Time `json:"created_at"`
 
-		messages []*Message
-		m        sync.Mutex
+		// 以下字段只有通过AddResponse()加入的回答才有
+		Model        string        `json:"model,omitempty"`
+		Usage        *Usage        `json:"usage,omitempty"`
+		Latency      time.Duration `json:"latency,omitempty"`
+		FinishReason string        `json:"finish_reason,omitempty"`
+	}
+
+	entry struct {
+		Entry
+		siblings *siblings // 同一位置上的其它回答, 没有分支时为nil
+	}
+
+	// siblings 记录同一位置上的多个分支, 每个分支是从该位置开始直到末尾的一段对话.
+	// 当前激活的分支就在thread.entries中, 因此branches[active]为nil
+	siblings struct {
+		branches [][]*entry
+		active   int
 	}
 )
 
-func NewThread(opts ...ThreadOption) *Thread {
+const defaultPrompt = "You are an English expert, you can help me to improve my English skills. The following are chats between you and me."
+
+// NewThread 参数非法或者相互冲突时返回ErrInvalidThreadOption
+func NewThread(opts ...ThreadOption) (*Thread, error) {
 	// 默认值
 	var options = threadOptions{
-		prompt:   "You are an English expert, you can help me to improve my English skills. The following are chats between you and me.",
-		userRole: "user",
-		botRole:  "assistant",
-		// temperature: 0.7,
-		// topK:        50,
-		// topP:        0.7,
-
+		prompt:      defaultPrompt,
+		userRole:    "user",
+		botRole:     "assistant",
 		historySize: 20,
+		eviction:    EvictPair,
 	}
 
 	// 初始化
@@ -37,66 +72,266 @@ func NewThread(opts ...ThreadOption) *Thread {
 		opt(&options)
 	}
 
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+
+	if options.noPrompt {
+		options.prompt = ""
+	}
+
 	var thread = &Thread{
 		userRole: options.userRole,
 		botRole:  options.botRole,
-		messages: make([]*Message, 1, options.historySize+1), // index=0 is system prompt
-	}
-
-	thread.messages[0] = &Message{
-		Role:    "system",
-		Content: options.prompt,
+		params:   options.params,
+		prompt: &Message{
+			Role:    "system",
+			Content: options.prompt,
+		},
+		entries:     make([]*entry, 0, options.historySize),
+		historySize: options.historySize,
+		eviction:    options.eviction,
 	}
 
-	return thread
+	return thread, nil
 }
 
 func (my *Thread) SetPrompt(prompt string) {
 	if prompt != "" {
 		my.m.Lock()
-		my.messages[0].Content = prompt
+		my.prompt = &Message{Role: "system", Content: prompt}
 		my.m.Unlock()
 	}
 }
 
-func (my *Thread) AddUserMessage(content string) {
+func (my *Thread) GetPrompt() string {
+	my.m.Lock()
+	var prompt = my.prompt.Content
+	my.m.Unlock()
+
+	return prompt
+}
+
+// NewRequest 用thread中的消息与采样参数创建请求
+func (my *Thread) NewRequest(model string) *Request {
+	var params = my.GetParams()
+	return &Request{
+		Model:    model,
+		Messages: my.CloneMessages(),
+		Params:   &params,
+	}
+}
+
+func (my *Thread) GetParams() Params {
+	my.m.Lock()
+	var params = my.params.clone()
+	my.m.Unlock()
+
+	return params
+}
+
+func (my *Thread) SetParams(params Params) {
+	my.m.Lock()
+	my.params = params.clone()
+	my.m.Unlock()
+}
+
+// GetTemperature 返回nil表示没有设置, 由provider使用默认值
+func (my *Thread) GetTemperature() *float32 {
+	return my.GetParams().Temperature
+}
+
+func (my *Thread) GetTopP() float32 {
+	return my.GetParams().TopP
+}
+
+func (my *Thread) GetTopK() int32 {
+	return my.GetParams().TopK
+}
+
+func (my *Thread) GetMaxTokens() int32 {
+	return my.GetParams().MaxTokens
+}
+
+func (my *Thread) GetStop() []string {
+	return my.GetParams().Stop
+}
+
+// AddUserMessage 返回新消息的ID, content为空时不添加并返回空串
+func (my *Thread) AddUserMessage(content string) string {
 	if content != "" {
 		var message = &Message{Role: my.userRole, Content: content}
-		my.addMessage(message)
+		return my.addMessage(message)
 	}
+
+	return ""
 }
 
-func (my *Thread) AddBotMessage(content string) {
+// AddBotMessage 返回新消息的ID, content为空时不添加并返回空串
+func (my *Thread) AddBotMessage(content string) string {
 	if content != "" {
 		var message = &Message{Role: my.botRole, Content: content}
-		my.addMessage(message)
+		return my.addMessage(message)
+	}
+
+	return ""
+}
+
+// AddMessage 按原样加入一条消息(比如导入的对话中间的system消息, 或者带Name的多人对话消息), 返回新消息的ID
+func (my *Thread) AddMessage(message *Message) string {
+	if message != nil && message.Content != "" {
+		return my.addMessage(&Message{Role: message.Role, Content: message.Content, Name: message.Name})
 	}
+
+	return ""
 }
 
-func (my *Thread) addMessage(message *Message) {
+// AddResponse 把模型的回答加入thread, 同时记录model, usage, latency与finish reason. streaming时需要传入拼接完整的回答
+func (my *Thread) AddResponse(response *Response) string {
+	if response == nil || response.Message.Content == "" {
+		return ""
+	}
+
+	my.m.Lock()
+	defer my.m.Unlock()
+
+	var item = my.newEntry(&Message{Role: my.botRole, Content: response.Message.Content})
+	item.Model = response.Model
+	item.Usage = response.Usage
+	item.Latency = response.Latency
+	item.FinishReason = response.FinishReason
+
+	my.entries = append(my.entries, item)
+	my.trimHistory()
+	return item.ID
+}
+
+// TotalUsage 累加滚动窗口中所有回答的token用量
+func (my *Thread) TotalUsage() Usage {
+	var total Usage
+	my.m.Lock()
+	{
+		for _, item := range my.entries {
+			if item.Usage != nil {
+				total.PromptTokens += item.Usage.PromptTokens
+				total.CompletionTokens += item.Usage.CompletionTokens
+				total.TotalTokens += item.Usage.TotalTokens
+			}
+		}
+	}
+	my.m.Unlock()
+
+	return total
+}
+
+// PinExample 固定一组few-shot示例, 它们紧跟在system prompt之后, 不受historySize影响
+func (my *Thread) PinExample(user string, bot string) {
+	my.PinMessages(&Message{Role: my.userRole, Content: user}, &Message{Role: my.botRole, Content: bot})
+}
+
+// PinMessages 在固定区的末尾追加消息, 可以是额外的system消息
+func (my *Thread) PinMessages(messages ...*Message) {
+	my.m.Lock()
+	my.pinned = appendMessages(my.pinned, messages)
+	my.m.Unlock()
+}
+
+// SetPinnedMessages 替换整个固定区, 传nil可以清空
+func (my *Thread) SetPinnedMessages(messages []*Message) {
+	my.m.Lock()
+	my.pinned = appendMessages(nil, messages)
+	my.m.Unlock()
+}
+
+// PinnedMessages 返回固定区的拷贝
+func (my *Thread) PinnedMessages() []*Message {
+	my.m.Lock()
+	var pinned = appendMessages(nil, my.pinned)
+	my.m.Unlock()
+
+	return pinned
+}
+
+// HistoryMessages 返回滚动窗口中的实时对话, 不包含system prompt与固定区
+func (my *Thread) HistoryMessages() []*Message {
+	var history []*Message
 	my.m.Lock()
 	{
-		var count = len(my.messages)
-		if count == cap(my.messages) {
-			copy(my.messages[1:], my.messages[2:])
-			my.messages[count-1] = message
-		} else {
-			my.messages = append(my.messages, message)
+		history = make([]*Message, 0, len(my.entries))
+		for _, item := range my.entries {
+			history = append(history, item.Message)
 		}
 	}
 	my.m.Unlock()
+
+	return history
 }
 
+func (my *Thread) addMessage(message *Message) string {
+	my.m.Lock()
+	defer my.m.Unlock()
+
+	var item = my.newEntry(message)
+	my.entries = append(my.entries, item)
+	my.trimHistory()
+	return item.ID
+}
+
+// newEntry 需要在锁内调用
+func (my *Thread) newEntry(message *Message) *entry {
+	my.nextId++
+	return &entry{Entry: Entry{ID: "msg_" + strconv.FormatUint(my.nextId, 10), Message: message, CreatedAt: time.Now()}}
+}
+
+// trimHistory 需要在锁内调用, 淘汰超出historySize的最早的消息
+func (my *Thread) trimHistory() {
+	var overflow = len(my.entries) - my.historySize
+	if overflow > 0 && my.eviction == EvictPair {
+		// 只有后面还有非bot的消息时才跳过开头的bot回答, 否则窗口中全是连续的bot消息, 按条淘汰即可, 不能清空历史
+		var next = overflow
+		for next < len(my.entries) && my.entries[next].Message.Role == my.botRole {
+			next++
+		}
+
+		if next < len(my.entries) {
+			overflow = next
+		}
+	}
+
+	if overflow > 0 {
+		var count = copy(my.entries, my.entries[overflow:])
+		clear(my.entries[count:])
+		my.entries = my.entries[:count]
+	}
+}
+
+// CloneMessages 按system prompt, 固定区, 实时对话的顺序返回发送给模型的全部消息
 func (my *Thread) CloneMessages() []*Message {
 
 	var cloned []*Message
 	my.m.Lock()
 	{
-		var size = len(my.messages)
-		cloned = make([]*Message, size)
-		copy(cloned, my.messages)
+		var size = 1 + len(my.pinned) + len(my.entries)
+		cloned = make([]*Message, 0, size)
+		if my.prompt.Content != "" {
+			cloned = append(cloned, my.prompt)
+		}
+		cloned = append(cloned, my.pinned...)
+		for _, item := range my.entries {
+			cloned = append(cloned, item.Message)
+		}
 	}
 	my.m.Unlock()
 
 	return cloned
 }
+
+func appendMessages(dst []*Message, messages []*Message) []*Message {
+	for _, message := range messages {
+		if message != nil && message.Content != "" {
+			dst = append(dst, message)
+		}
+	}
+
+	return dst
+}
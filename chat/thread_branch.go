@@ -0,0 +1,230 @@
+package chat
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+// Entries 返回滚动窗口中的实时对话及其ID与元数据, 不包含system prompt与固定区, 可用于统计与导出
+func (my *Thread) Entries() []Entry {
+	my.m.Lock()
+	defer my.m.Unlock()
+
+	var entries = make([]Entry, 0, len(my.entries))
+	for _, item := range my.entries {
+		entries = append(entries, item.Entry)
+	}
+
+	return entries
+}
+
+// Truncate 删除id及其之后的所有消息, 常用于撤回一个问题
+func (my *Thread) Truncate(id string) error {
+	my.m.Lock()
+	defer my.m.Unlock()
+
+	var index = my.indexOf(id)
+	if index < 0 {
+		return ErrMessageNotFound
+	}
+
+	my.truncate(index)
+	return nil
+}
+
+// TruncateAfter 保留id, 删除它之后的所有消息
+func (my *Thread) TruncateAfter(id string) error {
+	my.m.Lock()
+	defer my.m.Unlock()
+
+	var index = my.indexOf(id)
+	if index < 0 {
+		return ErrMessageNotFound
+	}
+
+	my.truncate(index + 1)
+	return nil
+}
+
+// ReplaceMessage 原地替换id的内容, ID与角色保持不变. 编辑之前的问题时, 通常再调用TruncateAfter(id)丢弃旧的回答
+func (my *Thread) ReplaceMessage(id string, content string) error {
+	my.m.Lock()
+	defer my.m.Unlock()
+
+	var index = my.indexOf(id)
+	if index < 0 {
+		return ErrMessageNotFound
+	}
+
+	// 不修改旧的Message, 因为它可能已经被CloneMessages()返回给了其它goroutine
+	var item = my.entries[index]
+	item.Message = &Message{Role: item.Message.Role, Content: content, Name: item.Message.Name}
+	return nil
+}
+
+// Fork 复制出一个独立的thread, 包含id及其之前的所有消息; id为空时复制全部消息. 分支信息也一并复制
+func (my *Thread) Fork(id string) (*Thread, error) {
+	my.m.Lock()
+	defer my.m.Unlock()
+
+	var end = len(my.entries)
+	if id != "" {
+		var index = my.indexOf(id)
+		if index < 0 {
+			return nil, ErrMessageNotFound
+		}
+		end = index + 1
+	}
+
+	var forked = &Thread{
+		userRole:    my.userRole,
+		botRole:     my.botRole,
+		params:      my.params.clone(),
+		prompt:      my.prompt,
+		pinned:      append([]*Message(nil), my.pinned...),
+		entries:     make([]*entry, 0, my.historySize),
+		historySize: my.historySize,
+		eviction:    my.eviction,
+		nextId:      my.nextId,
+	}
+
+	forked.entries = append(forked.entries, cloneEntries(my.entries[:end], make(map[*siblings]*siblings))...)
+	return forked, nil
+}
+
+// AddBotBranch 为id所在的位置生成一个新的回答(regenerate). 原来的回答连同其后的对话被保存为一个兄弟分支,
+// 新的回答成为当前分支, 返回新回答的ID
+func (my *Thread) AddBotBranch(id string, content string) (string, error) {
+	my.m.Lock()
+	defer my.m.Unlock()
+
+	var index = my.indexOf(id)
+	if index < 0 {
+		return "", ErrMessageNotFound
+	}
+
+	var current = my.entries[index]
+	if current.Message.Role != my.botRole {
+		return "", ErrNotBotMessage
+	}
+
+	var group = current.siblings
+	if group == nil {
+		group = &siblings{branches: make([][]*entry, 1)}
+		current.siblings = group
+	}
+
+	group.branches[group.active] = append([]*entry(nil), my.entries[index:]...)
+	my.truncate(index)
+
+	var item = my.newEntry(&Message{Role: my.botRole, Content: content})
+	item.siblings = group
+	group.branches = append(group.branches, nil)
+	group.active = len(group.branches) - 1
+
+	my.entries = append(my.entries, item)
+	return item.ID, nil
+}
+
+// Branches 返回id所在位置上所有兄弟分支的首条消息ID, 以及当前激活的下标. 没有分支时只返回id自己
+func (my *Thread) Branches(id string) ([]string, int, error) {
+	my.m.Lock()
+	defer my.m.Unlock()
+
+	var index = my.indexOf(id)
+	if index < 0 {
+		return nil, 0, ErrMessageNotFound
+	}
+
+	var group = my.entries[index].siblings
+	if group == nil {
+		return []string{id}, 0, nil
+	}
+
+	var ids = make([]string, len(group.branches))
+	for i, branch := range group.branches {
+		if i == group.active {
+			ids[i] = my.entries[index].ID
+		} else {
+			ids[i] = branch[0].ID
+		}
+	}
+
+	return ids, group.active, nil
+}
+
+// SwitchBranch 切换到id所在的兄弟分支, id是Branches()返回的某个ID. 当前分支连同其后的对话会被保存下来
+func (my *Thread) SwitchBranch(id string) error {
+	my.m.Lock()
+	defer my.m.Unlock()
+
+	if my.indexOf(id) >= 0 {
+		return nil
+	}
+
+	for index, item := range my.entries {
+		var group = item.siblings
+		if group == nil {
+			continue
+		}
+
+		for target, branch := range group.branches {
+			if target != group.active && branch[0].ID == id {
+				group.branches[group.active] = append([]*entry(nil), my.entries[index:]...)
+				my.truncate(index)
+
+				my.entries = append(my.entries, branch...)
+				group.branches[target] = nil
+				group.active = target
+				my.trimHistory()
+				return nil
+			}
+		}
+	}
+
+	return ErrBranchNotFound
+}
+
+// indexOf 需要在锁内调用
+func (my *Thread) indexOf(id string) int {
+	for i, item := range my.entries {
+		if item.ID == id {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// truncate 需要在锁内调用
+func (my *Thread) truncate(index int) {
+	clear(my.entries[index:])
+	my.entries = my.entries[:index]
+}
+
+// cloneEntries 深拷贝entries及其分支, mapping保证同一组兄弟在拷贝之后仍然共享同一个siblings. Message是只读的, 可以直接共享
+func cloneEntries(entries []*entry, mapping map[*siblings]*siblings) []*entry {
+	var cloned = make([]*entry, 0, len(entries))
+	for _, item := range entries {
+		var copied = &entry{Entry: item.Entry}
+		if group := item.siblings; group != nil {
+			var copiedGroup, ok = mapping[group]
+			if !ok {
+				copiedGroup = &siblings{branches: make([][]*entry, len(group.branches)), active: group.active}
+				mapping[group] = copiedGroup
+				for i, branch := range group.branches {
+					if branch != nil {
+						copiedGroup.branches[i] = cloneEntries(branch, mapping)
+					}
+				}
+			}
+			copied.siblings = copiedGroup
+		}
+
+		cloned = append(cloned, copied)
+	}
+
+	return cloned
+}
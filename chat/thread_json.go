@@ -0,0 +1,83 @@
+package chat
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+// threadJson 是Thread持久化的格式. 只保存当前分支, 非激活的兄弟分支不会被保存
+type threadJson struct {
+	UserRole    string         `json:"user_role"`
+	BotRole     string         `json:"bot_role"`
+	Params      Params         `json:"params"`
+	Prompt      string         `json:"prompt"`
+	Pinned      []*Message     `json:"pinned,omitempty"`
+	Entries     []Entry        `json:"entries"`
+	HistorySize int            `json:"history_size"`
+	Eviction    EvictionPolicy `json:"eviction,omitempty"`
+	NextId      uint64         `json:"next_id"`
+}
+
+func (my *Thread) MarshalJSON() ([]byte, error) {
+	my.m.Lock()
+	var data = threadJson{
+		UserRole:    my.userRole,
+		BotRole:     my.botRole,
+		Params:      my.params,
+		Prompt:      my.prompt.Content,
+		Pinned:      my.pinned,
+		Entries:     make([]Entry, 0, len(my.entries)),
+		HistorySize: my.historySize,
+		Eviction:    my.eviction,
+		NextId:      my.nextId,
+	}
+
+	for _, item := range my.entries {
+		data.Entries = append(data.Entries, item.Entry)
+	}
+	my.m.Unlock()
+
+	return json.Marshal(data)
+}
+
+// UnmarshalJSON 用于恢复MarshalJSON保存的thread, 通常是对一个零值的Thread调用
+func (my *Thread) UnmarshalJSON(bts []byte) error {
+	var data threadJson
+	if err := json.Unmarshal(bts, &data); err != nil {
+		return err
+	}
+
+	if data.HistorySize <= 0 {
+		return errors.New("invalid history size")
+	}
+
+	var entries = make([]*entry, 0, data.HistorySize)
+	for _, item := range data.Entries {
+		if item.Message == nil {
+			return errors.New("entry without message")
+		}
+		entries = append(entries, &entry{Entry: item})
+	}
+
+	my.m.Lock()
+	defer my.m.Unlock()
+
+	my.userRole = data.UserRole
+	my.botRole = data.BotRole
+	my.params = data.Params
+	my.prompt = &Message{Role: "system", Content: data.Prompt}
+	my.pinned = data.Pinned
+	my.entries = entries
+	my.historySize = data.HistorySize
+	my.eviction = data.Eviction
+	my.nextId = data.NextId
+	my.trimHistory()
+	return nil
+}
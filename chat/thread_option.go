@@ -1,5 +1,10 @@
 package chat
 
+import (
+	"errors"
+	"fmt"
+)
+
 /********************************************************************
 created:    2024-07-07
 author:     lixianmin
@@ -8,71 +13,131 @@ Copyright (C) - All Rights Reserved
 *********************************************************************/
 
 type threadOptions struct {
-	prompt   string
-	userRole string
-	botRole  string
-	// temperature float32
-	// topK        int32
-	// topP        float32
+	prompt    string
+	promptSet bool
+	noPrompt  bool
+	userRole  string
+	botRole   string
+	params    Params
 
 	historySize int
+	eviction    EvictionPolicy
+	errs        []error // 无法在option内部判断的非法参数, 由NewThread统一返回
 }
 
 type ThreadOption func(*threadOptions)
 
+// WithPrompt 设置system prompt, 不能为空; 不需要system prompt时请使用WithoutSystemPrompt
 func WithPrompt(prompt string) ThreadOption {
 	return func(options *threadOptions) {
-		if options.prompt != "" {
-			options.prompt = prompt
+		if prompt == "" {
+			options.errs = append(options.errs, errors.New("prompt is empty, use WithoutSystemPrompt instead"))
+			return
 		}
+
+		options.prompt = prompt
+		options.promptSet = true
 	}
 }
 
+// WithoutSystemPrompt 发送给模型的消息中不包含system prompt, 不能与WithPrompt同时使用
+func WithoutSystemPrompt() ThreadOption {
+	return func(options *threadOptions) {
+		options.noPrompt = true
+	}
+}
+
+// WithHistorySize 滚动窗口中最多保留的消息数, 可以是奇数, 淘汰方式见WithEvictionPolicy
 func WithHistorySize(size int) ThreadOption {
 	return func(options *threadOptions) {
-		if size > 0 {
-			options.historySize = size / 2 * 2
-		}
+		options.historySize = size
+	}
+}
+
+// WithEvictionPolicy 默认为EvictPair
+func WithEvictionPolicy(policy EvictionPolicy) ThreadOption {
+	return func(options *threadOptions) {
+		options.eviction = policy
+	}
+}
+
+// WithUserRole AddUserMessage使用的role, 默认为user. 多人对话时配合Message.Name使用
+func WithUserRole(userRole string) ThreadOption {
+	return func(options *threadOptions) {
+		options.userRole = userRole
+	}
+}
+
+// WithBotRole AddBotMessage与AddResponse使用的role, 默认为assistant
+func WithBotRole(botRole string) ThreadOption {
+	return func(options *threadOptions) {
+		options.botRole = botRole
+	}
+}
+
+// WithTemperature 各provider的上限不同, 超出的部分在发送时裁剪
+func WithTemperature(temperature float32) ThreadOption {
+	return func(options *threadOptions) {
+		options.params.Temperature = Float32(temperature)
+	}
+}
+
+func WithTopK(v int32) ThreadOption {
+	return func(options *threadOptions) {
+		options.params.TopK = v
+	}
+}
+
+func WithTopP(v float32) ThreadOption {
+	return func(options *threadOptions) {
+		options.params.TopP = v
+	}
+}
+
+func WithMaxTokens(v int32) ThreadOption {
+	return func(options *threadOptions) {
+		options.params.MaxTokens = v
+	}
+}
+
+func WithStop(stop ...string) ThreadOption {
+	return func(options *threadOptions) {
+		options.params.Stop = append([]string(nil), stop...)
 	}
 }
 
-// 这个设置好像没有意义, 因此第一次请求的时候server也不会知道bot的role是什么, 然后就返回了assistant. 使用siliconflow的测试是这样的结果
-// func WithUserRole(userRole string) ThreadOption {
-// 	return func(options *threadOptions) {
-// 		if options.userRole != "" {
-// 			options.userRole = userRole
-// 		}
-// 	}
-// }
-
-// func WithBotRole(botRole string) ThreadOption {
-// 	return func(options *threadOptions) {
-// 		if options.botRole != "" {
-// 			options.botRole = botRole
-// 		}
-// 	}
-// }
-// func WithTemperature(temperature float32) ThreadOption {
-// 	return func(options *threadOptions) {
-// 		if temperature > 0 {
-// 			options.temperature = min(temperature, 1)
-// 		}
-// 	}
-// }
-
-// func WithTopK(v int32) ThreadOption {
-// 	return func(options *threadOptions) {
-// 		if v > 0 {
-// 			options.topK = v
-// 		}
-// 	}
-// }
-
-// func WithTopP(v float32) ThreadOption {
-// 	return func(options *threadOptions) {
-// 		if v > 0 {
-// 			options.topP = min(v, 1)
-// 		}
-// 		//options.topP = mathx.Clamp(v, 0, 1)
-// 	}
-// }
+// validate 检查参数本身与参数之间的组合是否合法
+func (my *threadOptions) validate() error {
+	var errs = my.errs
+	if my.noPrompt && my.promptSet {
+		errs = append(errs, errors.New("WithPrompt and WithoutSystemPrompt are mutually exclusive"))
+	}
+
+	if my.historySize <= 0 {
+		errs = append(errs, fmt.Errorf("history size must be positive, got %d", my.historySize))
+	}
+
+	if my.eviction != EvictPair && my.eviction != EvictMessage {
+		errs = append(errs, fmt.Errorf("unknown eviction policy %d", my.eviction))
+	} else if my.eviction == EvictPair && my.historySize == 1 {
+		errs = append(errs, errors.New("EvictPair needs a history size of at least 2"))
+	}
+
+	if my.userRole == "" || my.botRole == "" {
+		errs = append(errs, errors.New("user role and bot role must not be empty"))
+	} else if my.userRole == my.botRole {
+		errs = append(errs, fmt.Errorf("user role and bot role are both %q", my.userRole))
+	} else if my.userRole == "system" || my.botRole == "system" {
+		errs = append(errs, errors.New("system is reserved for the prompt"))
+	}
+
+	if err := my.params.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%w: %w", ErrInvalidThreadOption, errors.Join(errs...))
+	}
+
+	return nil
+}
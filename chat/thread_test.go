@@ -2,8 +2,11 @@ package chat
 
 import (
 	"encoding/json"
+	"errors"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 /********************************************************************
@@ -14,7 +17,7 @@ Copyright (C) - All Rights Reserved
 *********************************************************************/
 
 func TestThread(t *testing.T) {
-	var thread = NewThread()
+	var thread, _ = NewThread()
 	for i := 0; i < 3; i++ {
 		thread.AddUserMessage("user " + strconv.Itoa(i))
 		thread.AddBotMessage("bot " + strconv.Itoa(i))
@@ -24,3 +27,200 @@ func TestThread(t *testing.T) {
 	var text, _ = json.Marshal(messages)
 	println(string(text))
 }
+
+func TestPinnedMessages(t *testing.T) {
+	var thread, _ = NewThread(WithHistorySize(4))
+	thread.PinExample("I goed home.", "I went home.")
+	thread.PinMessages(&Message{Role: "system", Content: "Answer briefly."})
+
+	for i := 0; i < 5; i++ {
+		thread.AddUserMessage("user " + strconv.Itoa(i))
+		thread.AddBotMessage("bot " + strconv.Itoa(i))
+	}
+
+	var messages = thread.CloneMessages()
+	var text, _ = json.Marshal(messages)
+	println(string(text))
+
+	if len(messages) != 1+3+4 {
+		t.Fatalf("len(messages)=%d", len(messages))
+	}
+
+	if messages[1].Content != "I goed home." || messages[3].Role != "system" || messages[4].Content != "user 3" {
+		t.Fatalf("pinned messages should survive eviction")
+	}
+
+	thread.SetPinnedMessages(nil)
+	if len(thread.PinnedMessages()) != 0 || len(thread.HistoryMessages()) != 4 {
+		t.Fatalf("pinned=%d, history=%d", len(thread.PinnedMessages()), len(thread.HistoryMessages()))
+	}
+}
+
+func TestThreadBranch(t *testing.T) {
+	var thread, _ = NewThread()
+	var question = thread.AddUserMessage("1+1=?")
+	var first = thread.AddBotMessage("3")
+	thread.AddUserMessage("are you sure?")
+
+	var second, err = thread.AddBotBranch(first, "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var history = thread.HistoryMessages()
+	if len(history) != 2 || history[1].Content != "2" {
+		t.Fatalf("regenerate should drop the old tail: %d", len(history))
+	}
+
+	var ids, active, _ = thread.Branches(second)
+	if len(ids) != 2 || ids[0] != first || active != 1 {
+		t.Fatalf("ids=%v, active=%d", ids, active)
+	}
+
+	var forked, _ = thread.Fork(second)
+	forked.AddUserMessage("thanks")
+
+	if err = thread.SwitchBranch(first); err != nil {
+		t.Fatal(err)
+	}
+
+	history = thread.HistoryMessages()
+	if len(history) != 3 || history[2].Content != "are you sure?" {
+		t.Fatalf("switch should restore the old tail: %d", len(history))
+	}
+
+	if len(forked.HistoryMessages()) != 3 {
+		t.Fatal("forked thread should be independent")
+	}
+
+	_ = thread.ReplaceMessage(question, "2+2=?")
+	_ = thread.TruncateAfter(question)
+	var entries = thread.Entries()
+	if len(entries) != 1 || entries[0].ID != question || entries[0].Message.Content != "2+2=?" {
+		t.Fatalf("unexpected entries after edit: %d", len(entries))
+	}
+
+	if thread.Truncate("missing") != ErrMessageNotFound {
+		t.Fatal("missing id should be reported")
+	}
+
+	// fork保留淘汰策略: EvictMessage按条淘汰, 不会连同开头的bot回答一起淘汰
+	var origin, _ = NewThread(WithHistorySize(3), WithEvictionPolicy(EvictMessage))
+	origin.AddUserMessage("q0")
+	origin.AddBotMessage("a0")
+	var copied, _ = origin.Fork("")
+	for _, item := range []*Thread{origin, copied} {
+		item.AddUserMessage("q1")
+		item.AddBotMessage("a1")
+	}
+
+	if len(origin.Entries()) != 3 || len(copied.Entries()) != 3 {
+		t.Fatalf("fork should keep the eviction policy: %d vs %d", len(origin.Entries()), len(copied.Entries()))
+	}
+}
+
+func TestEntryMetadata(t *testing.T) {
+	var thread, _ = NewThread()
+	thread.AddUserMessage("你好")
+	var id = thread.AddResponse(&Response{
+		Model:        "deepseek-chat",
+		Message:      Message{Role: "assistant", Content: "你好!"},
+		FinishReason: "stop",
+		Usage:        &Usage{PromptTokens: 10, CompletionTokens: 3, TotalTokens: 13},
+		Latency:      120 * time.Millisecond,
+	})
+
+	var entries = thread.Entries()
+	var text, _ = json.Marshal(entries)
+	println(string(text))
+
+	var last = entries[len(entries)-1]
+	if last.ID != id || last.Model != "deepseek-chat" || last.Latency != 120*time.Millisecond || last.CreatedAt.IsZero() {
+		t.Fatalf("unexpected entry: %s", text)
+	}
+
+	// 元数据不会出现在发送给API的消息中
+	var messages, _ = json.Marshal(thread.CloneMessages())
+	if strings.Contains(string(messages), "deepseek-chat") {
+		t.Fatalf("metadata leaked: %s", messages)
+	}
+
+	if thread.TotalUsage().TotalTokens != 13 {
+		t.Fatalf("total usage=%d", thread.TotalUsage().TotalTokens)
+	}
+}
+
+func TestThreadJson(t *testing.T) {
+	var thread, _ = NewThread(WithTemperature(0.3))
+	thread.PinExample("hi", "hello")
+	thread.AddUserMessage("1+1=?")
+	thread.AddResponse(&Response{Model: "deepseek-chat", Message: Message{Content: "2"}})
+
+	var bts, _ = json.Marshal(thread)
+	println(string(bts))
+
+	var restored Thread
+	if err := json.Unmarshal(bts, &restored); err != nil {
+		t.Fatal(err)
+	}
+
+	var next = restored.AddUserMessage("2+2=?")
+	var entries = restored.Entries()
+	if len(restored.CloneMessages()) != 6 || entries[1].Model != "deepseek-chat" || next != "msg_3" || *restored.GetTemperature() != 0.3 {
+		t.Fatalf("unexpected restored thread: %s", bts)
+	}
+}
+
+func TestThreadOptions(t *testing.T) {
+	var invalids = [][]ThreadOption{
+		{WithPrompt("")},
+		{WithPrompt("hi"), WithoutSystemPrompt()},
+		{WithHistorySize(0)},
+		{WithUserRole("bot"), WithBotRole("bot")},
+		{WithTemperature(-1)},
+	}
+
+	for i, opts := range invalids {
+		var _, err = NewThread(opts...)
+		if !errors.Is(err, ErrInvalidThreadOption) {
+			t.Fatalf("case %d should be invalid, err=%v", i, err)
+		}
+		println(err.Error())
+	}
+
+	var thread, err = NewThread(WithoutSystemPrompt(), WithHistorySize(5), WithUserRole("student"), WithBotRole("teacher"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		thread.AddUserMessage("q" + strconv.Itoa(i))
+		thread.AddBotMessage("a" + strconv.Itoa(i))
+	}
+
+	// 窗口为5, 淘汰q0时连同a0一起淘汰, 保证以student开头
+	var messages = thread.CloneMessages()
+	if len(messages) != 4 || messages[0].Role != "student" || messages[0].Content != "q1" {
+		t.Fatalf("unexpected messages: %d %+v", len(messages), messages[0])
+	}
+}
+
+func TestEvictConsecutiveBots(t *testing.T) {
+	var thread, _ = NewThread(WithHistorySize(3))
+	var last string
+	for i := 0; i < 4; i++ {
+		last = thread.AddBotMessage("a" + strconv.Itoa(i))
+	}
+
+	// 窗口中全是bot消息时按条淘汰, 刚加入的消息必须保留
+	var entries = thread.Entries()
+	if len(entries) != 3 || entries[2].ID != last || entries[0].Message.Content != "a1" {
+		t.Fatalf("unexpected entries: %d", len(entries))
+	}
+
+	thread.AddUserMessage("q")
+	entries = thread.Entries()
+	if len(entries) != 1 || entries[0].Message.Content != "q" {
+		t.Fatalf("leading bot messages should be dropped before a user turn: %d", len(entries))
+	}
+}
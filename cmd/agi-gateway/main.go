@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"github.com/joho/godotenv"
+	"github.com/lixianmin/agi/gateway"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+agi-gateway 以OpenAI兼容的接口对内暴露deepseek与siliconflow:
+
+	agi-gateway -config gateway.json -listen :8080
+
+真实的provider key建议通过api_key_env从环境变量(或者.env文件)读取
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+func main() {
+	var configPath = flag.String("config", "gateway.json", "path of the gateway config")
+	var listen = flag.String("listen", ":8080", "listen address")
+	var envPath = flag.String("env", ".env", "optional .env file with provider keys")
+	flag.Parse()
+
+	// .env不存在时直接使用进程的环境变量
+	_ = godotenv.Load(*envPath)
+
+	var config, err1 = gateway.LoadConfig(*configPath)
+	if err1 != nil {
+		log.Fatalf("load config: %v", err1)
+	}
+
+	var handler, err2 = gateway.New(config)
+	if err2 != nil {
+		log.Fatalf("create gateway: %v", err2)
+	}
+
+	log.Printf("agi-gateway is listening on %s", *listen)
+	if err3 := http.ListenAndServe(*listen, handler); err3 != nil {
+		log.Fatal(err3)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/lixianmin/agi/batch"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+// runBatch 实现agi batch子命令, Ctrl+C之后已经完成的结果会被保留, 再次执行同样的命令即可继续
+func runBatch(args []string) error {
+	var flags = flag.NewFlagSet("agi batch", flag.ExitOnError)
+	var provider = addProviderFlags(flags)
+	var inputPath = flags.String("in", "", "input jsonl, one request per line")
+	var outputPath = flags.String("out", "", "output jsonl, results are appended in input order")
+	var concurrency = flags.Int("concurrency", 4, "number of requests in flight")
+	var rpm = flags.Int("rpm", 0, "max requests per minute, 0 means unlimited")
+	var retries = flags.Int("retries", 3, "max retries for rate limits, server and network errors")
+	_ = flags.Parse(args)
+
+	if *inputPath == "" || *outputPath == "" {
+		flags.Usage()
+		return errors.New("-in and -out are required")
+	}
+
+	var service, model, err1 = provider.newChatService()
+	if err1 != nil {
+		return err1
+	}
+
+	var ctx, stop = signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var runner = batch.NewRunner(service, batch.WithModel(model), batch.WithConcurrency(*concurrency),
+		batch.WithRequestsPerMinute(*rpm), batch.WithMaxRetries(*retries))
+
+	var stats, err2 = runner.RunFile(ctx, *inputPath, *outputPath)
+	if stats != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "done: %d succeeded, %d failed, %d skipped, %d tokens\n",
+			stats.Succeeded, stats.Failed, stats.Skipped, stats.Usage.TotalTokens)
+	}
+
+	return err2
+}
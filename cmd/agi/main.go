@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/joho/godotenv"
+	"github.com/lixianmin/agi/chat"
+	"github.com/lixianmin/agi/deepseek"
+	"github.com/lixianmin/agi/ifs"
+	"github.com/lixianmin/agi/siliconflow"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+agi 是一个命令行的聊天工具:
+
+	agi                                  进入交互模式
+	agi -provider siliconflow 你好        一次性提问
+	cat main.go | agi 解释一下这段代码     把stdin作为问题的一部分, 适合在脚本中使用
+	agi batch -in prompts.jsonl -out results.jsonl  批量执行, 中断后重新执行即可继续
+
+provider与model也可以通过环境变量AGI_PROVIDER与AGI_MODEL设置, api key从.env或者环境变量中读取
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+const (
+	envProvider = "AGI_PROVIDER"
+	envModel    = "AGI_MODEL"
+	envBaseUrl  = "AGI_BASE_URL"
+
+	defaultSystemPrompt = "You are a helpful assistant."
+)
+
+type providerFlags struct {
+	envPath  *string
+	provider *string
+	model    *string
+	baseUrl  *string
+}
+
+// 与测试中使用的.env保持一致
+var secretKeyEnvs = map[string]string{
+	ifs.ProviderDeepSeek:    "DEEPSEEK_SECRET_KEY",
+	ifs.ProviderSiliconFlow: "SLICONFLOW_SECRET_KEY",
+}
+
+var defaultModels = map[string]string{
+	ifs.ProviderDeepSeek:    deepseek.ModelChat,
+	ifs.ProviderSiliconFlow: siliconflow.ModelQwen2Instruct,
+}
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, "agi:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	if len(args) > 0 && args[0] == "batch" {
+		return runBatch(args[1:])
+	}
+
+	var flags = flag.NewFlagSet("agi", flag.ExitOnError)
+	var provider = addProviderFlags(flags)
+	var system = flags.String("system", defaultSystemPrompt, "system prompt")
+	_ = flags.Parse(args)
+
+	var service, resolvedModel, err1 = provider.newChatService()
+	if err1 != nil {
+		return err1
+	}
+
+	var ctx, stop = signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var question = strings.Join(flags.Args(), " ")
+	var piped = isPiped(os.Stdin)
+	if !piped && question == "" {
+		var repl, err2 = newRepl(service, resolvedModel, *system, os.Stdin, os.Stdout)
+		if err2 != nil {
+			return err2
+		}
+
+		// 交互模式下由repl自己处理Ctrl+C: 中断当前回答而不是退出
+		stop()
+		return repl.run(context.Background())
+	}
+
+	if piped {
+		var input, err3 = io.ReadAll(os.Stdin)
+		if err3 != nil {
+			return err3
+		}
+
+		question = joinQuestion(question, string(input))
+	}
+
+	return oneShot(ctx, service, resolvedModel, *system, question, os.Stdout)
+}
+
+// oneShot 流式输出一个问题的回答, 用于脚本
+func oneShot(ctx context.Context, service chat.ChatService, model string, system string, question string, writer io.Writer) error {
+	if strings.TrimSpace(question) == "" {
+		return fmt.Errorf("empty question")
+	}
+
+	var thread, err1 = newThread(system)
+	if err1 != nil {
+		return err1
+	}
+
+	thread.AddUserMessage(question)
+	if _, err2 := chat.StreamTo(ctx, service, thread.NewRequest(model), writer, nil); err2 != nil {
+		return err2
+	}
+
+	_, _ = io.WriteString(writer, "\n")
+	return nil
+}
+
+// addProviderFlags 注册交互模式与batch共用的provider参数
+func addProviderFlags(flags *flag.FlagSet) *providerFlags {
+	return &providerFlags{
+		envPath:  flags.String("env", ".env", "optional .env file with provider keys"),
+		provider: flags.String("provider", "", "deepseek or siliconflow, defaults to $"+envProvider+" or deepseek"),
+		model:    flags.String("model", "", "model name, defaults to $"+envModel+" or the provider's default model"),
+		baseUrl:  flags.String("base-url", "", "override the provider's base url, defaults to $"+envBaseUrl),
+	}
+}
+
+// newChatService 需要在flags.Parse()之后调用
+func (my *providerFlags) newChatService() (chat.ChatService, string, error) {
+	// .env不存在时直接使用进程的环境变量
+	_ = godotenv.Load(*my.envPath)
+
+	return newChatService(firstNonEmpty(*my.provider, os.Getenv(envProvider), ifs.ProviderDeepSeek),
+		firstNonEmpty(*my.model, os.Getenv(envModel)), firstNonEmpty(*my.baseUrl, os.Getenv(envBaseUrl)))
+}
+
+func newChatService(provider string, model string, baseUrl string) (chat.ChatService, string, error) {
+	var envName, ok = secretKeyEnvs[provider]
+	if !ok {
+		return nil, "", fmt.Errorf("unknown provider %q", provider)
+	}
+
+	var secretKey = os.Getenv(envName)
+	if secretKey == "" && baseUrl == "" {
+		return nil, "", fmt.Errorf("%s is not set, put it into .env or the environment", envName)
+	}
+
+	model = firstNonEmpty(model, defaultModels[provider])
+	switch provider {
+	case ifs.ProviderSiliconFlow:
+		var client = siliconflow.NewSiliconClient(secretKey, siliconflow.WithBaseUrl(baseUrl))
+		return siliconflow.NewChatService(client), model, nil
+	default:
+		var client = deepseek.NewDeepSeekClient(secretKey, deepseek.WithBaseUrl(baseUrl))
+		return deepseek.NewChatService(client), model, nil
+	}
+}
+
+func newThread(system string) (*chat.Thread, error) {
+	if system == "" {
+		return chat.NewThread(chat.WithoutSystemPrompt())
+	}
+
+	return chat.NewThread(chat.WithPrompt(system))
+}
+
+// joinQuestion 命令行参数是指令, stdin是材料
+func joinQuestion(instruction string, input string) string {
+	input = strings.TrimRight(input, "\n")
+	if instruction == "" {
+		return input
+	}
+
+	if input == "" {
+		return instruction
+	}
+
+	return instruction + "\n\n" + input
+}
+
+func isPiped(file *os.File) bool {
+	var info, err = file.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice == 0
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
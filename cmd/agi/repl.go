@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/lixianmin/agi/chat"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+const (
+	multilineMark = `"""`
+	replHelp      = `commands:
+  /system [prompt]  show or replace the system prompt
+  /reset            clear the conversation, keep the system prompt
+  /save <file>      save the conversation as json
+  /load <file>      load a conversation saved by /save
+  /model [name]     show or switch the model
+  /help             show this help
+  /exit             quit (or Ctrl+D)
+multiline input: end a line with \ to continue, or wrap the text between two """ lines
+Ctrl+C interrupts the current answer`
+)
+
+var errQuit = errors.New("quit")
+
+// repl 是交互式的对话循环, 回答以streaming的方式实时输出
+type repl struct {
+	service chat.ChatService
+	model   string
+	thread  *chat.Thread
+	reader  *bufio.Reader
+	writer  io.Writer
+}
+
+func newRepl(service chat.ChatService, model string, system string, reader io.Reader, writer io.Writer) (*repl, error) {
+	var thread, err = newThread(system)
+	if err != nil {
+		return nil, err
+	}
+
+	return &repl{
+		service: service,
+		model:   model,
+		thread:  thread,
+		reader:  bufio.NewReader(reader),
+		writer:  writer,
+	}, nil
+}
+
+func (my *repl) run(ctx context.Context) error {
+	my.printf("model: %s, type /help for commands\n", my.model)
+	for {
+		var input, err1 = my.readInput()
+		if err1 == io.EOF {
+			my.printf("\n")
+			return nil
+		}
+
+		if err1 != nil {
+			return err1
+		}
+
+		input = strings.TrimSpace(input)
+		if input == "" {
+			continue
+		}
+
+		if strings.HasPrefix(input, "/") {
+			var err2 = my.command(input)
+			if err2 == errQuit {
+				return nil
+			}
+
+			if err2 != nil {
+				my.printf("error: %v\n", err2)
+			}
+			continue
+		}
+
+		if err3 := my.ask(ctx, input); err3 != nil {
+			my.printf("\nerror: %v\n", err3)
+		}
+	}
+}
+
+// readInput 读取一个完整的输入, 支持以\结尾的续行与"""包围的多行文本
+func (my *repl) readInput() (string, error) {
+	my.printf("> ")
+	var line, err = my.readLine()
+	if err != nil {
+		return "", err
+	}
+
+	if strings.TrimSpace(line) == multilineMark {
+		var lines []string
+		for {
+			my.printf(". ")
+			var next, err2 = my.readLine()
+			if err2 != nil {
+				return "", err2
+			}
+
+			if strings.TrimSpace(next) == multilineMark {
+				return strings.Join(lines, "\n"), nil
+			}
+			lines = append(lines, next)
+		}
+	}
+
+	var builder strings.Builder
+	for strings.HasSuffix(line, `\`) {
+		builder.WriteString(strings.TrimSuffix(line, `\`))
+		builder.WriteString("\n")
+
+		my.printf(". ")
+		var next, err3 = my.readLine()
+		if err3 != nil {
+			return "", err3
+		}
+		line = next
+	}
+
+	builder.WriteString(line)
+	return builder.String(), nil
+}
+
+// readLine 最后一行没有换行符时也能返回, 之后再返回io.EOF
+func (my *repl) readLine() (string, error) {
+	var line, err = my.reader.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+
+	return strings.TrimRight(line, "\r\n"), err
+}
+
+func (my *repl) command(input string) error {
+	var name, arg, _ = strings.Cut(input, " ")
+	arg = strings.TrimSpace(arg)
+
+	switch name {
+	case "/system":
+		if arg == "" {
+			my.printf("%s\n", my.thread.GetPrompt())
+			return nil
+		}
+
+		my.thread.SetPrompt(arg)
+	case "/reset":
+		var thread, err = newThread(my.thread.GetPrompt())
+		if err != nil {
+			return err
+		}
+
+		my.thread = thread
+		my.printf("conversation cleared\n")
+	case "/save":
+		if arg == "" {
+			return errors.New("usage: /save <file>")
+		}
+
+		var bts, err1 = json.MarshalIndent(my.thread, "", "  ")
+		if err1 != nil {
+			return err1
+		}
+
+		if err2 := os.WriteFile(arg, bts, 0o644); err2 != nil {
+			return err2
+		}
+		my.printf("saved to %s\n", arg)
+	case "/load":
+		if arg == "" {
+			return errors.New("usage: /load <file>")
+		}
+
+		var bts, err1 = os.ReadFile(arg)
+		if err1 != nil {
+			return err1
+		}
+
+		var thread = &chat.Thread{}
+		if err2 := json.Unmarshal(bts, thread); err2 != nil {
+			return fmt.Errorf("%s: %w", arg, err2)
+		}
+
+		my.thread = thread
+		my.printf("loaded %d messages from %s\n", len(thread.HistoryMessages()), arg)
+	case "/model":
+		if arg != "" {
+			my.model = arg
+		}
+		my.printf("model: %s\n", my.model)
+	case "/help":
+		my.printf("%s\n", replHelp)
+	case "/exit", "/quit":
+		return errQuit
+	default:
+		return fmt.Errorf("unknown command %s, type /help for commands", name)
+	}
+
+	return nil
+}
+
+// ask 流式输出回答; Ctrl+C或者出错时撤回问题, 保证thread中总是完整的一问一答
+func (my *repl) ask(ctx context.Context, question string) error {
+	var askCtx, stop = signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	var questionId = my.thread.AddUserMessage(question)
+	var _, err = chat.StreamTo(askCtx, my.service, my.thread.NewRequest(my.model), my.writer, my.thread)
+	my.printf("\n")
+
+	if err != nil {
+		_ = my.thread.Truncate(questionId)
+		if askCtx.Err() != nil && ctx.Err() == nil {
+			my.printf("interrupted\n")
+			return nil
+		}
+	}
+
+	return err
+}
+
+func (my *repl) printf(format string, args ...any) {
+	_, _ = fmt.Fprintf(my.writer, format, args...)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lixianmin/agi/agitest"
+	"github.com/lixianmin/agi/deepseek"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+func newTestService(t *testing.T) (*deepseek.ChatService, *agitest.Server) {
+	var server = agitest.NewTestServer(t)
+	server.SetChatHandler(func(request *agitest.ChatRequest) agitest.Reply {
+		return agitest.Reply{Content: "echo: " + request.LastUserMessage()}
+	})
+
+	var client = deepseek.NewDeepSeekClient("sk-test", deepseek.WithBaseUrl(server.BaseUrl()))
+	return deepseek.NewChatService(client), server
+}
+
+func TestRepl(t *testing.T) {
+	var service, server = newTestService(t)
+	var path = filepath.Join(t.TempDir(), "thread.json")
+
+	var input = strings.Join([]string{
+		"hello",
+		`first \`,
+		"second",
+		`"""`,
+		"line 1",
+		"line 2",
+		`"""`,
+		"/system be brief",
+		"/model deepseek-reasoner",
+		"/save " + path,
+		"/reset",
+		"/load " + path,
+		"/unknown",
+		"last",
+	}, "\n")
+
+	var output strings.Builder
+	var repl, _ = newRepl(service, "deepseek-chat", defaultSystemPrompt, strings.NewReader(input), &output)
+	if err := repl.run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var text = output.String()
+	println(text)
+	for _, expected := range []string{"echo: hello", "echo: first \nsecond", "echo: line 1\nline 2", "model: deepseek-reasoner", "loaded 6 messages", "unknown command", "echo: last"} {
+		if !strings.Contains(text, expected) {
+			t.Fatalf("missing %q in output", expected)
+		}
+	}
+
+	var last = server.LastChatRequest()
+	if last.Model != "deepseek-reasoner" || last.Messages[0].Content != "be brief" || len(last.Messages) != 8 {
+		t.Fatalf("last=%+v", last)
+	}
+}
+
+func TestOneShot(t *testing.T) {
+	var service, server = newTestService(t)
+	server.AssertRequestCount(t, agitest.PathChat, 0)
+
+	var output strings.Builder
+	var question = joinQuestion("summarize", "some text\n")
+	if err := oneShot(context.Background(), service, "deepseek-chat", "", question, &output); err != nil {
+		t.Fatal(err)
+	}
+
+	if output.String() != "echo: summarize\n\nsome text\n" {
+		t.Fatalf("output=%q", output.String())
+	}
+
+	if last := server.LastChatRequest(); len(last.Messages) != 1 {
+		t.Fatalf("messages=%d", len(last.Messages))
+	}
+
+	if err := oneShot(context.Background(), service, "deepseek-chat", "", " ", &output); err == nil {
+		t.Fatal("empty question should fail")
+	}
+}
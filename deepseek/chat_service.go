@@ -0,0 +1,61 @@
+package deepseek
+
+import (
+	"context"
+
+	"github.com/lixianmin/agi/chat"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+// ChatService 把DeepSeekClient适配为chat.ChatService
+type ChatService struct {
+	client *DeepSeekClient
+}
+
+var _ chat.ChatService = (*ChatService)(nil)
+
+func NewChatService(client *DeepSeekClient) *ChatService {
+	return &ChatService{client: client}
+}
+
+func (my *ChatService) Chat(ctx context.Context, request *chat.Request) (*chat.Response, error) {
+	return chat.AdaptChat(ctx, request, func(ctx context.Context, request *chat.Request) (*chat.Response, error) {
+		var response, err = my.client.Chat(ctx, &ChatRequest{Request: *request})
+		if err != nil {
+			return nil, err
+		}
+
+		var result = &chat.Response{
+			ID:           response.ID,
+			Model:        response.Model,
+			FinishReason: response.GetFinishReason(),
+			Usage:        response.Usage,
+		}
+
+		if len(response.Choices) > 0 {
+			result.Message = response.Choices[0].Message
+		}
+
+		return result, nil
+	})
+}
+
+func (my *ChatService) StreamChat(ctx context.Context, request *chat.Request, fn chat.ResponseFunc) error {
+	return chat.AdaptStreamChat(ctx, request, fn, func(ctx context.Context, request *chat.Request, emit chat.ResponseFunc) error {
+		return my.client.StreamChat(ctx, &ChatRequest{Request: *request}, func(response ChatResponse) error {
+			return emit(&chat.Response{
+				Model:        response.Model,
+				Message:      response.Message,
+				FinishReason: response.DoneReason,
+				Usage:        response.Usage,
+				Done:         response.Done,
+			})
+		})
+	})
+}
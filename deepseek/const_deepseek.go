@@ -0,0 +1,17 @@
+package deepseek
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+const (
+	maxBufferSize = 512 * 1024
+
+	// 采样参数的取值范围, 参考https://api-docs.deepseek.com/api/create-chat-completion
+	maxTemperature = 2
+	maxTokens      = 8192
+	maxStopCount   = 16
+)
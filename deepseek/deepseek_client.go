@@ -1,10 +1,13 @@
 package deepseek
 
 import (
+	"bufio"
 	"bytes"
 	"context"
+	"errors"
 	"io"
 	"net/http"
+	"regexp"
 	"time"
 
 	"github.com/lixianmin/agi/chat"
@@ -24,7 +27,9 @@ Copyright (C) - All Rights Reserved
 type (
 	DeepSeekClient struct {
 		client        *http.Client
+		baseUrl       string
 		authorization string
+		interceptor   ifs.Interceptor
 	}
 
 	ChatRequest struct {
@@ -33,10 +38,18 @@ type (
 		FrequencyPenalty float32  `json:"frequency_penalty,omitempty"`
 		MaxTokens        int32    `json:"max_tokens,omitempty"`
 		Stop             []string `json:"stop,omitempty"`
-		Temperature      float32  `json:"temperature,omitempty"`
+		Temperature      *float32 `json:"temperature,omitempty"` // nil表示不设置, 0会被发送
 		TopP             float32  `json:"top_p,omitempty"`
 
 		ResponseFormat string `json:"response_format,omitempty"`
+
+		// StreamOptions 由StreamChat设置, 调用方不需要关心
+		StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+	}
+
+	// StreamOptions 中include_usage为true时, 服务端在data: [DONE]之前单独发送一个choices为空的usage chunk
+	StreamOptions struct {
+		IncludeUsage bool `json:"include_usage"`
 	}
 
 	ChatResponse struct {
@@ -44,6 +57,7 @@ type (
 		CreatedAt  time.Time    `json:"created_at"`
 		Message    chat.Message `json:"message"`
 		DoneReason string       `json:"done_reason,omitempty"`
+		Usage      *chat.Usage  `json:"usage,omitempty"`
 
 		Done bool `json:"done"`
 	}
@@ -55,11 +69,13 @@ type (
 		Model             string          `json:"model"`
 		SystemFingerprint string          `json:"system_fingerprint"`
 		Choices           []ChunkedChoice `json:"choices"`
+		Usage             *chat.Usage     `json:"usage,omitempty"`
 	}
 
 	ChunkedChoice struct {
 		Index        int          `json:"index"`
 		Message      chat.Message `json:"message"`
+		Delta        chat.Message `json:"delta"`
 		FinishReason string       `json:"finish_reason"`
 	}
 
@@ -67,11 +83,27 @@ type (
 )
 
 // NewDeepSeekClient 线程安全+无状态
-func NewDeepSeekClient(secretKey string) *DeepSeekClient {
-	var client = &http.Client{}
+func NewDeepSeekClient(secretKey string, opts ...ClientOption) *DeepSeekClient {
+	// 默认值
+	var options = clientOptions{
+		baseUrl: defaultBaseUrl,
+	}
+
+	// 初始化
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	var client = options.httpClient
+	if client == nil {
+		client = &http.Client{}
+	}
+
 	return &DeepSeekClient{
 		client:        client,
+		baseUrl:       options.baseUrl,
 		authorization: "Bearer " + secretKey,
+		interceptor:   ifs.ChainInterceptors(options.interceptors...),
 	}
 }
 
@@ -80,13 +112,99 @@ func (my *DeepSeekClient) Chat(ctx context.Context, request *ChatRequest) (*Chat
 		return nil, ifs.ErrRequestIsNil
 	}
 
+	var resolved, err1 = request.resolveParams()
+	if err1 != nil {
+		return nil, err1
+	}
+
+	var call = my.newCall(ifs.EndpointChat, resolved.Model, resolved)
+	var result, err = ifs.Invoke(ctx, my.interceptor, call, my.invoke)
+	if err != nil {
+		return nil, err
+	}
+
+	var response, ok = result.(*ChatCompletionChunk)
+	if !ok {
+		return nil, ifs.ErrUnexpectedResult
+	}
+
+	return response, nil
+}
+
+func (my *DeepSeekClient) StreamChat(ctx context.Context, request *ChatRequest, fn ChatResponseFunc) error {
+	if request == nil {
+		return ifs.ErrRequestIsNil
+	}
+
+	if fn == nil {
+		return errors.New("fn is nil")
+	}
+
+	var resolved, err1 = request.resolveParams()
+	if err1 != nil {
+		return err1
+	}
+
+	var call = my.newCall(ifs.EndpointStreamChat, resolved.Model, resolved)
+	call.OnChunk = func(chunk any) error {
+		var chatResponse, ok = chunk.(ChatResponse)
+		if !ok {
+			return ifs.ErrUnexpectedResult
+		}
+
+		return fn(chatResponse)
+	}
+
+	var _, err = ifs.Invoke(ctx, my.interceptor, call, my.invoke)
+	return err
+}
+
+func (my *DeepSeekClient) newCall(endpoint string, model string, request any) *ifs.Call {
+	return &ifs.Call{
+		Provider:    ifs.ProviderDeepSeek,
+		Endpoint:    endpoint,
+		Model:       model,
+		Request:     request,
+		Header:      http.Header{},
+		Synthesizer: synthesizer{},
+	}
+}
+
+// invoke 是interceptor链的最内层, 真正发送http请求
+func (my *DeepSeekClient) invoke(ctx context.Context, call *ifs.Call) (any, error) {
+	switch call.Endpoint {
+	case ifs.EndpointChat:
+		var request, ok = call.Request.(*ChatRequest)
+		if !ok {
+			return nil, ifs.ErrUnexpectedRequest
+		}
+		return my.chat(ctx, request, call.Header)
+	case ifs.EndpointStreamChat:
+		var request, ok = call.Request.(*ChatRequest)
+		if !ok {
+			return nil, ifs.ErrUnexpectedRequest
+		}
+		return nil, my.streamChat(ctx, request, call.Header, call.OnChunk)
+	case ifs.EndpointModels:
+		return my.listModels(ctx, call.Header)
+	default:
+		return nil, ifs.ErrUnknownEndpoint
+	}
+}
+
+func (my *DeepSeekClient) chat(ctx context.Context, request *ChatRequest, extra http.Header) (*ChatCompletionChunk, error) {
 	request.Stream = false
-	var response1, err1 = my.sendChatRequest(ctx, request)
+	request.StreamOptions = nil
+	var response1, err1 = my.sendChatRequest(ctx, request, extra)
 	if err1 != nil {
 		return nil, err1
 	}
 	defer response1.Body.Close()
 
+	if response1.StatusCode != http.StatusOK {
+		return nil, ifs.NewStatusError(response1)
+	}
+
 	var bts, err2 = io.ReadAll(response1.Body)
 	if err2 != nil {
 		return nil, err2
@@ -100,8 +218,91 @@ func (my *DeepSeekClient) Chat(ctx context.Context, request *ChatRequest) (*Chat
 	return &response, nil
 }
 
-func (my *DeepSeekClient) sendChatRequest(ctx context.Context, request *ChatRequest) (*http.Response, error) {
-	const requestUrl = "https://api.deepseek.com/chat/completions"
+func (my *DeepSeekClient) streamChat(ctx context.Context, request *ChatRequest, extra http.Header, fn func(chunk any) error) error {
+	if fn == nil {
+		return errors.New("fn is nil")
+	}
+
+	request.Stream = true
+	request.StreamOptions = &StreamOptions{IncludeUsage: true}
+	var response1, err1 = my.sendChatRequest(ctx, request, extra)
+	if err1 != nil {
+		return err1
+	}
+	defer response1.Body.Close()
+
+	if response1.StatusCode != http.StatusOK {
+		return ifs.NewStatusError(response1)
+	}
+
+	var scanner = bufio.NewScanner(response1.Body)
+	// increase the buffer size to avoid running out of space
+	var scanBuf = make([]byte, 0, maxBufferSize)
+	scanner.Buffer(scanBuf, maxBufferSize)
+
+	var regex = regexp.MustCompile(`^data: `)
+	for scanner.Scan() {
+		var tokens = scanner.Bytes()
+		var line = convert.String(tokens)
+		// deepseek在排队时会发送": keep-alive"注释行
+		if line == "" || line[0] == ':' {
+			continue
+		}
+
+		if line == "data: [DONE]" {
+			var chatResponse = ChatResponse{
+				Done: true,
+			}
+
+			if err3 := fn(chatResponse); err3 != nil {
+				return err3
+			}
+			return nil
+		}
+
+		var cleanLine = regex.ReplaceAllString(line, "")
+
+		var chunk ChatCompletionChunk
+		convert.FromJsonS(cleanLine, &chunk)
+
+		if len(chunk.Choices) == 0 && chunk.Usage != nil {
+			// include_usage时finish chunk之后还有一个带usage的chunk, choices为空
+			var chatResponse = ChatResponse{
+				Model:     chunk.Model,
+				CreatedAt: time.Unix(chunk.Created, 0),
+				Usage:     chunk.Usage,
+			}
+
+			if err4 := fn(chatResponse); err4 != nil {
+				return err4
+			}
+		}
+
+		if len(chunk.Choices) > 0 {
+			var choice = chunk.Choices[0]
+			var chatResponse = ChatResponse{
+				Model:     chunk.Model,
+				CreatedAt: time.Unix(chunk.Created, 0),
+				Message: chat.Message{
+					Role:    choice.Delta.Role,
+					Content: choice.Delta.Content,
+				},
+				DoneReason: choice.FinishReason,
+				Usage:      chunk.Usage,
+			}
+
+			// 收到finish reason之后不能返回, usage chunk在它后面, 以data: [DONE]作为结束
+			if err5 := fn(chatResponse); err5 != nil {
+				return err5
+			}
+		}
+	}
+
+	return scanner.Err()
+}
+
+func (my *DeepSeekClient) sendChatRequest(ctx context.Context, request *ChatRequest, extra http.Header) (*http.Response, error) {
+	var requestUrl = my.baseUrl + "/chat/completions"
 	var bts1, err1 = convert.ToJsonE(request)
 	if err1 != nil {
 		return nil, err1
@@ -117,7 +318,17 @@ func (my *DeepSeekClient) sendChatRequest(ctx context.Context, request *ChatRequ
 	header.Set("accept", "application/json")
 	header.Set("Content-Type", "application/json")
 	header.Set("authorization", my.authorization)
+	mergeHeader(header, extra)
 
 	var response3, err3 = my.client.Do(request2)
 	return response3, err3
 }
+
+func mergeHeader(header http.Header, extra http.Header) {
+	for key, values := range extra {
+		header.Del(key)
+		for _, value := range values {
+			header.Add(key, value)
+		}
+	}
+}
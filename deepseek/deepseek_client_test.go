@@ -5,11 +5,14 @@ import (
 	"encoding/json"
 	"log"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/lixianmin/agi/cassette"
 	"github.com/lixianmin/agi/chat"
+	"github.com/lixianmin/agi/ifs"
 )
 
 /********************************************************************
@@ -29,12 +32,48 @@ func getSecretKey() string {
 	return sk
 }
 
+// newTestClient 默认回放testdata中的cassette; 设置AGI_CASSETTE=record并提供.env后可以录制真实的cassette
+func newTestClient(t *testing.T) *DeepSeekClient {
+	var mode = cassette.ModeFromEnv()
+	var recorder, err = cassette.NewRecorder(cassettePath(t, mode), cassette.WithMode(mode))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if err := recorder.Stop(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	var sk = "sk-replay"
+	if recorder.Mode() != cassette.ModeReplay {
+		sk = getSecretKey()
+	}
+
+	return NewDeepSeekClient(sk, WithHttpClient(recorder.Client()))
+}
+
+// cassettePath 录制的cassette保存在testdata中, 回放时优先使用. testdata/synthetic中是按照api文档手写的合成数据,
+// 不是真实的录制, 只能验证client的解析逻辑, 不能证明与真实服务端的格式一致
+func cassettePath(t *testing.T, mode cassette.Mode) string {
+	var recorded = filepath.Join("testdata", t.Name()+".json")
+	if mode != cassette.ModeReplay {
+		return recorded
+	}
+
+	if _, err := os.Stat(recorded); err == nil {
+		return recorded
+	}
+
+	return filepath.Join("testdata", "synthetic", t.Name()+".json")
+}
+
 func TestChat(t *testing.T) {
-	var sk = getSecretKey()
-	var client = NewDeepSeekClient(sk)
+	var client = newTestClient(t)
 
 	const modelName = "deepseek-chat"
-	var chatThread = chat.NewThread()
+	var chatThread, _ = chat.NewThread()
 	chatThread.SetPrompt("你是一个脑残人士, 无论我说什么, 你都回答`是的`. 现在开始: ")
 	chatThread.AddUserMessage("今天天气怎么样?")
 	chatThread.AddBotMessage("是的")
@@ -45,8 +84,8 @@ func TestChat(t *testing.T) {
 			Model:    modelName,
 			Messages: chatThread.CloneMessages(),
 		},
-		// Temperature: chatThread.GetTemperature(),
-		// TopP:        chatThread.GetTopP(),
+		Temperature: chatThread.GetTemperature(),
+		TopP:        chatThread.GetTopP(),
 	}
 
 	var req, _ = json.Marshal(request)
@@ -57,10 +96,115 @@ func TestChat(t *testing.T) {
 
 	var response, err = client.Chat(ctx, request)
 	if err != nil {
-		log.Fatalf("chat error: %v", err)
-		return
+		t.Fatalf("chat error: %v", err)
 	}
 
 	var result, _ = json.Marshal(response)
 	println(string(result))
+
+	if response.GetContent() == "" {
+		t.Fatal("content is empty")
+	}
+}
+
+func TestStreamChat(t *testing.T) {
+	var client = newTestClient(t)
+
+	var chatThread, _ = chat.NewThread()
+	chatThread.SetPrompt("你是一个脑残人士, 无论我说什么, 你都回答`是的`. 现在开始: ")
+	chatThread.AddUserMessage("你觉得我帅嘛?")
+
+	var request = &ChatRequest{
+		Request: chat.Request{
+			Model:    "deepseek-chat",
+			Messages: chatThread.CloneMessages(),
+		},
+	}
+
+	var ctx, cancel = context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	var text string
+	var err = client.StreamChat(ctx, request, func(response ChatResponse) error {
+		text += response.Message.Content
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("stream chat error: %v", err)
+	}
+
+	println(text)
+	if text == "" {
+		t.Fatal("text is empty")
+	}
+}
+
+func TestInterceptor(t *testing.T) {
+	var logger = func(ctx context.Context, call *ifs.Call, next ifs.Invoker) (any, error) {
+		var onChunk = call.OnChunk
+		call.OnChunk = func(chunk any) error {
+			println("chunk:", chunk.(ChatResponse).Message.Content)
+			return onChunk(chunk)
+		}
+
+		return next(ctx, call)
+	}
+
+	// 不调用next, 直接返回合成的streaming响应
+	var mock = func(ctx context.Context, call *ifs.Call, next ifs.Invoker) (any, error) {
+		for _, content := range []string{"是", "的"} {
+			if err := call.OnChunk(ChatResponse{Message: chat.Message{Role: "assistant", Content: content}}); err != nil {
+				return nil, err
+			}
+		}
+
+		return nil, call.OnChunk(ChatResponse{DoneReason: "stop", Done: true})
+	}
+
+	var client = NewDeepSeekClient("", WithInterceptors(logger, mock))
+	var request = &ChatRequest{Request: chat.Request{Model: "deepseek-chat"}}
+
+	var text string
+	var err = client.StreamChat(context.Background(), request, func(response ChatResponse) error {
+		text += response.Message.Content
+		return nil
+	})
+
+	if err != nil || text != "是的" {
+		t.Fatalf("text=%q, err=%v", text, err)
+	}
+}
+
+func TestThreadParams(t *testing.T) {
+	var sent *ChatRequest
+	var capture = func(ctx context.Context, call *ifs.Call) (any, error) {
+		sent = call.Request.(*ChatRequest)
+		return &ChatCompletionChunk{}, nil
+	}
+
+	var client = NewDeepSeekClient("", WithInterceptors(func(ctx context.Context, call *ifs.Call, next ifs.Invoker) (any, error) {
+		return capture(ctx, call)
+	}))
+
+	var chatThread, _ = chat.NewThread(chat.WithTemperature(3), chat.WithTopK(40), chat.WithMaxTokens(100000), chat.WithStop("\n\n"))
+	chatThread.AddUserMessage("你好")
+
+	// 请求中显式设置的字段覆盖thread的默认值
+	var request = &ChatRequest{Request: *chatThread.NewRequest("deepseek-chat"), MaxTokens: 256}
+	if _, err := client.Chat(context.Background(), request); err != nil {
+		t.Fatal(err)
+	}
+
+	var body, _ = json.Marshal(sent)
+	println(string(body))
+
+	if *sent.Temperature != maxTemperature || sent.MaxTokens != 256 || len(sent.Stop) != 1 || sent.Params != nil {
+		t.Fatalf("unexpected params: %s", body)
+	}
+
+	request.Stop = make([]string, maxStopCount+1)
+	if _, err := client.Chat(context.Background(), request); err == nil {
+		t.Fatal("invalid stop sequences should be rejected")
+	}
 }
@@ -0,0 +1,84 @@
+package deepseek
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/lixianmin/agi/ifs"
+	"github.com/lixianmin/got/convert"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+const (
+	ModelChat     = "deepseek-chat"
+	ModelReasoner = "deepseek-reasoner"
+)
+
+type (
+	Model struct {
+		ID      string `json:"id"`
+		Object  string `json:"object"`
+		OwnedBy string `json:"owned_by"`
+	}
+
+	modelList struct {
+		Object string   `json:"object"`
+		Data   []*Model `json:"data"`
+	}
+)
+
+// ListModels 返回当前可用的模型, 模型的能力参考catalog包. 与chat一样经过interceptor链
+func (my *DeepSeekClient) ListModels(ctx context.Context) ([]*Model, error) {
+	var call = my.newCall(ifs.EndpointModels, "", nil)
+	var result, err = ifs.Invoke(ctx, my.interceptor, call, my.invoke)
+	if err != nil {
+		return nil, err
+	}
+
+	var models, ok = result.([]*Model)
+	if !ok {
+		return nil, ifs.ErrUnexpectedResult
+	}
+
+	return models, nil
+}
+
+func (my *DeepSeekClient) listModels(ctx context.Context, extra http.Header) ([]*Model, error) {
+	var request, err1 = http.NewRequestWithContext(ctx, http.MethodGet, my.baseUrl+"/models", nil)
+	if err1 != nil {
+		return nil, err1
+	}
+
+	request.Header.Set("accept", "application/json")
+	request.Header.Set("authorization", my.authorization)
+	mergeHeader(request.Header, extra)
+
+	var response, err2 = my.client.Do(request)
+	if err2 != nil {
+		return nil, err2
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, ifs.NewStatusError(response)
+	}
+
+	var bts, err3 = io.ReadAll(response.Body)
+	if err3 != nil {
+		return nil, err3
+	}
+
+	var list modelList
+	if err4 := convert.FromJsonE(bts, &list); err4 != nil {
+		return nil, err4
+	}
+
+	return list.Data, nil
+}
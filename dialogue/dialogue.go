@@ -0,0 +1,218 @@
+package dialogue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/lixianmin/agi/chat"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+type (
+	// Dialogue 驱动多个persona在一个共享的chat.Thread上轮流发言, 人类参与者可以随时通过Say插话
+	Dialogue struct {
+		personas  []*Persona
+		moderator Moderator
+		maxTurns  int
+		thread    *chat.Thread
+		turns     int
+		m         sync.Mutex
+	}
+
+	// Turn 是persona的一次发言
+	Turn struct {
+		Speaker  string
+		Content  string
+		Response *chat.Response
+	}
+
+	dialogueOptions struct {
+		moderator   Moderator
+		maxTurns    int
+		historySize int
+	}
+
+	DialogueOption func(*dialogueOptions)
+)
+
+// WithModerator 默认为RoundRobin
+func WithModerator(moderator Moderator) DialogueOption {
+	return func(options *dialogueOptions) {
+		if moderator != nil {
+			options.moderator = moderator
+		}
+	}
+}
+
+// WithMaxTurns Run最多进行的persona发言数, 默认为10
+func WithMaxTurns(turns int) DialogueOption {
+	return func(options *dialogueOptions) {
+		if turns > 0 {
+			options.maxTurns = turns
+		}
+	}
+}
+
+// WithHistorySize 共享历史保留的消息数, 默认为50
+func WithHistorySize(size int) DialogueOption {
+	return func(options *dialogueOptions) {
+		if size > 0 {
+			options.historySize = size
+		}
+	}
+}
+
+func NewDialogue(personas []*Persona, opts ...DialogueOption) (*Dialogue, error) {
+	// 默认值
+	var options = dialogueOptions{
+		moderator:   RoundRobin(),
+		maxTurns:    10,
+		historySize: 50,
+	}
+
+	// 初始化
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if len(personas) == 0 {
+		return nil, errors.New("no personas")
+	}
+
+	var names = make(map[string]bool, len(personas))
+	for _, persona := range personas {
+		if persona == nil || persona.Name == "" || persona.Service == nil {
+			return nil, errors.New("persona needs a name and a service")
+		}
+
+		if names[persona.Name] {
+			return nil, fmt.Errorf("duplicate persona %s", persona.Name)
+		}
+		names[persona.Name] = true
+	}
+
+	// 共享历史中每个人的发言都是带Name的消息, 多方对话中不存在严格的一问一答, 因此逐条淘汰
+	var thread, err = chat.NewThread(chat.WithoutSystemPrompt(), chat.WithHistorySize(options.historySize), chat.WithEvictionPolicy(chat.EvictMessage))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Dialogue{
+		personas:  personas,
+		moderator: options.moderator,
+		maxTurns:  options.maxTurns,
+		thread:    thread,
+	}, nil
+}
+
+// Say 以人类参与者name的身份发言
+func (my *Dialogue) Say(name string, content string) {
+	my.thread.AddMessage(&chat.Message{Role: "user", Name: name, Content: content})
+}
+
+// Step 由moderator选出下一个persona并让它发言, 对话结束时返回nil, nil
+func (my *Dialogue) Step(ctx context.Context) (*Turn, error) {
+	my.m.Lock()
+	defer my.m.Unlock()
+
+	var history = my.thread.HistoryMessages()
+	var name, err1 = my.moderator(ctx, history, my.personas, my.turns)
+	if err1 != nil || name == "" {
+		return nil, err1
+	}
+
+	var persona = my.find(name)
+	if persona == nil {
+		return nil, fmt.Errorf("moderator picked unknown persona %s", name)
+	}
+
+	var request = &chat.Request{
+		Model:    persona.Model,
+		Messages: persona.render(history, my.participants(history)),
+		Params:   persona.Params,
+	}
+
+	var response, err2 = persona.Service.Chat(ctx, request)
+	if err2 != nil {
+		return nil, err2
+	}
+
+	// 模型有时会模仿历史消息的格式, 带上自己的名字前缀
+	var content = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(response.Message.Content), persona.Name+":"))
+	my.thread.AddMessage(&chat.Message{Role: "assistant", Name: persona.Name, Content: content})
+	my.turns++
+
+	return &Turn{Speaker: persona.Name, Content: content, Response: response}, nil
+}
+
+// Run 连续调用Step, 直到moderator结束对话或者达到maxTurns, 每次发言之后回调fn
+func (my *Dialogue) Run(ctx context.Context, fn func(turn *Turn) error) error {
+	for i := 0; i < my.maxTurns; i++ {
+		var turn, err = my.Step(ctx)
+		if err != nil || turn == nil {
+			return err
+		}
+
+		if fn != nil {
+			if err2 := fn(turn); err2 != nil {
+				return err2
+			}
+		}
+	}
+
+	return nil
+}
+
+// Thread 返回共享历史, 可以用transcript导出
+func (my *Dialogue) Thread() *chat.Thread {
+	return my.thread
+}
+
+// Render 返回从name的视角看到的消息, 即发送给该persona的请求内容
+func (my *Dialogue) Render(name string) []*chat.Message {
+	var persona = my.find(name)
+	if persona == nil {
+		return nil
+	}
+
+	var history = my.thread.HistoryMessages()
+	return persona.render(history, my.participants(history))
+}
+
+func (my *Dialogue) find(name string) *Persona {
+	for _, persona := range my.personas {
+		if persona.Name == name {
+			return persona
+		}
+	}
+
+	return nil
+}
+
+// participants 包括所有persona与历史中出现过的人类参与者
+func (my *Dialogue) participants(history []*chat.Message) []string {
+	var names = make([]string, 0, len(my.personas))
+	var seen = make(map[string]bool)
+	for _, persona := range my.personas {
+		names = append(names, persona.Name)
+		seen[persona.Name] = true
+	}
+
+	for _, message := range history {
+		if message.Name != "" && !seen[message.Name] {
+			names = append(names, message.Name)
+			seen[message.Name] = true
+		}
+	}
+
+	return names
+}
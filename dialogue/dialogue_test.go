@@ -0,0 +1,104 @@
+package dialogue
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/lixianmin/agi/agitest"
+	"github.com/lixianmin/agi/deepseek"
+	"github.com/lixianmin/agi/siliconflow"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+func TestDialogue(t *testing.T) {
+	var server = agitest.NewTestServer(t)
+	server.SetChatHandler(func(request *agitest.ChatRequest) agitest.Reply {
+		var system = request.Messages[0].Content
+		switch {
+		case strings.Contains(system, "你是Alice"):
+			return agitest.Reply{Content: "Alice: 我支持甜豆花"}
+		case strings.Contains(system, "你是鲍勃"):
+			return agitest.Reply{Content: "我支持咸豆花"}
+		default:
+			return agitest.Reply{Content: "?"}
+		}
+	})
+
+	var personas = []*Persona{
+		{Name: "Alice", Prompt: "你喜欢甜食", Model: "deepseek-chat", Service: deepseek.NewChatService(deepseek.NewDeepSeekClient("sk-test", deepseek.WithBaseUrl(server.BaseUrl())))},
+		{Name: "鲍勃", Prompt: "你喜欢咸食", Model: "Qwen/Qwen2-7B-Instruct", Service: siliconflow.NewChatService(siliconflow.NewSiliconClient("sk-test", siliconflow.WithBaseUrl(server.BaseUrl())))},
+	}
+
+	var dialogue, err = NewDialogue(personas, WithMaxTurns(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dialogue.Say("host", "豆花应该是甜的还是咸的?")
+
+	var speakers []string
+	err = dialogue.Run(context.Background(), func(turn *Turn) error {
+		println(turn.Speaker + ": " + turn.Content)
+		speakers = append(speakers, turn.Speaker)
+		return nil
+	})
+
+	if err != nil || strings.Join(speakers, ",") != "Alice,鲍勃,Alice" {
+		t.Fatalf("speakers=%v, err=%v", speakers, err)
+	}
+
+	// 从鲍勃的视角, Alice的发言是带前缀与name的user消息, 自己的发言是assistant
+	var messages = dialogue.Render("鲍勃")
+	if messages[1].Content != "host: 豆花应该是甜的还是咸的?" || messages[2].Name != "Alice" || messages[2].Content != "Alice: 我支持甜豆花" || messages[3].Role != "assistant" {
+		t.Fatalf("unexpected perspective: %+v", messages[1:])
+	}
+
+	// 鲍勃不是合法的api name, 只通过前缀区分
+	var alice = dialogue.Render("Alice")
+	if alice[3].Role != "user" || alice[3].Name != "" || alice[2].Role != "assistant" {
+		t.Fatalf("unexpected perspective: %+v", alice[1:])
+	}
+
+	if server.RequestCount(agitest.PathChat) != 3 {
+		t.Fatalf("requests=%d", server.RequestCount(agitest.PathChat))
+	}
+}
+
+func TestChatModerator(t *testing.T) {
+	var server = agitest.NewTestServer(t)
+	var replies = []string{"下一位是Bob", "END"}
+	server.SetChatHandler(func(request *agitest.ChatRequest) agitest.Reply {
+		if strings.Contains(request.Messages[0].Content, "主持人") {
+			var reply = replies[0]
+			replies = replies[1:]
+			return agitest.Reply{Content: reply}
+		}
+		return agitest.Reply{Content: "你好"}
+	})
+
+	var service = deepseek.NewChatService(deepseek.NewDeepSeekClient("sk-test", deepseek.WithBaseUrl(server.BaseUrl())))
+	var personas = []*Persona{{Name: "Alice", Model: "deepseek-chat", Service: service}, {Name: "Bob", Model: "deepseek-chat", Service: service}}
+	var dialogue, _ = NewDialogue(personas, WithModerator(NewChatModerator(service, "deepseek-chat", "打招呼")))
+
+	var turns []*Turn
+	var err = dialogue.Run(context.Background(), func(turn *Turn) error {
+		turns = append(turns, turn)
+		return nil
+	})
+
+	if err != nil || len(turns) != 1 || turns[0].Speaker != "Bob" {
+		t.Fatalf("turns=%d, err=%v", len(turns), err)
+	}
+
+	var history = dialogue.Thread().HistoryMessages()
+	if len(history) != 1 || history[0].Name != "Bob" || history[0].Role != "assistant" {
+		t.Fatalf("unexpected history: %+v", history)
+	}
+}
@@ -0,0 +1,83 @@
+package dialogue
+
+import (
+	"context"
+	"strings"
+
+	"github.com/lixianmin/agi/chat"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+// Moderator 决定下一个发言的persona, 返回空串表示对话结束. turn是已经由persona完成的发言数
+type Moderator func(ctx context.Context, history []*chat.Message, personas []*Persona, turn int) (string, error)
+
+// RoundRobin 按顺序轮流发言
+func RoundRobin() Moderator {
+	return func(ctx context.Context, history []*chat.Message, personas []*Persona, turn int) (string, error) {
+		if len(personas) == 0 {
+			return "", nil
+		}
+
+		return personas[turn%len(personas)].Name, nil
+	}
+}
+
+const endMark = "END"
+
+// NewChatModerator 让模型阅读对话历史并选出下一个发言的persona, 模型认为对话已经结束时回答END
+func NewChatModerator(service chat.ChatService, model string, topic string) Moderator {
+	return func(ctx context.Context, history []*chat.Message, personas []*Persona, turn int) (string, error) {
+		var names = make([]string, 0, len(personas))
+		for _, persona := range personas {
+			names = append(names, persona.Name)
+		}
+
+		var transcript strings.Builder
+		for _, message := range history {
+			var speaker = message.Name
+			if speaker == "" {
+				speaker = message.Role
+			}
+			transcript.WriteString(speaker + ": " + message.Content + "\n")
+		}
+
+		var request = &chat.Request{
+			Model: model,
+			Messages: []*chat.Message{
+				{Role: "system", Content: "你是多人对话的主持人, 话题是: " + topic + ". 参与者有: " + strings.Join(names, ", ") +
+					". 根据对话记录选出下一个最适合发言的参与者, 只输出他的名字; 如果话题已经充分讨论, 只输出" + endMark + "."},
+				{Role: "user", Content: transcript.String()},
+			},
+		}
+
+		var response, err = service.Chat(ctx, request)
+		if err != nil {
+			return "", err
+		}
+
+		var answer = strings.TrimSpace(response.Message.Content)
+		if answer == endMark {
+			return "", nil
+		}
+
+		// 模型可能会多输出一些内容, 选第一个出现的名字
+		var next, position = "", len(answer) + 1
+		for _, name := range names {
+			if index := strings.Index(answer, name); index >= 0 && index < position {
+				next, position = name, index
+			}
+		}
+
+		if next == "" {
+			return RoundRobin()(ctx, history, personas, turn)
+		}
+
+		return next, nil
+	}
+}
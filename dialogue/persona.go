@@ -0,0 +1,80 @@
+package dialogue
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/lixianmin/agi/chat"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+// Persona 是参与对话的一个AI角色, 每个角色可以使用不同的provider与模型
+type Persona struct {
+	Name    string           // 在共享历史中的名字, 同一个Dialogue内唯一
+	Prompt  string           // 角色自己的system prompt
+	Service chat.ChatService // 任意provider的ChatService
+	Model   string
+	Params  *chat.Params // 可选的采样参数
+}
+
+// OpenAI要求name只能包含字母, 数字, 下划线与短横线
+var apiNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// render 从persona的视角渲染共享历史: 自己说过的话是assistant, 其他人说的话是user, 并在内容前标注说话人,
+// 因为不是所有provider都会使用name字段
+func (my *Persona) render(history []*chat.Message, participants []string) []*chat.Message {
+	var messages = make([]*chat.Message, 0, len(history)+1)
+	messages = append(messages, &chat.Message{Role: "system", Content: my.systemPrompt(participants)})
+
+	for _, message := range history {
+		if message.Name == my.Name {
+			messages = append(messages, &chat.Message{Role: "assistant", Content: message.Content})
+			continue
+		}
+
+		var speaker = message.Name
+		if speaker == "" {
+			speaker = message.Role
+		}
+
+		messages = append(messages, &chat.Message{
+			Role:    "user",
+			Content: speaker + ": " + message.Content,
+			Name:    apiName(message.Name),
+		})
+	}
+
+	return messages
+}
+
+func (my *Persona) systemPrompt(participants []string) string {
+	var others = make([]string, 0, len(participants))
+	for _, name := range participants {
+		if name != my.Name {
+			others = append(others, name)
+		}
+	}
+
+	var builder strings.Builder
+	builder.WriteString(my.Prompt)
+	builder.WriteString("\n\n你是")
+	builder.WriteString(my.Name)
+	builder.WriteString(", 正在与")
+	builder.WriteString(strings.Join(others, ", "))
+	builder.WriteString("进行多人对话. 其他人的发言以\"名字: 内容\"的形式给出, 你只需要直接输出自己的发言, 不要加名字前缀, 也不要替别人发言.")
+	return builder.String()
+}
+
+func apiName(name string) string {
+	if apiNamePattern.MatchString(name) {
+		return name
+	}
+
+	return ""
+}
@@ -0,0 +1,112 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/lixianmin/agi/ifs"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+const (
+	TypeChat          = "chat"
+	TypeEmbedding     = "embedding"
+	TypeTranscription = "transcription"
+)
+
+type (
+	// Config 是网关的配置, 通常从json文件加载. 真实的provider key只保存在服务端
+	Config struct {
+		Providers map[string]*ProviderConfig `json:"providers"` // ifs.ProviderDeepSeek, ifs.ProviderSiliconFlow
+		Models    []*ModelConfig             `json:"models"`
+		Keys      []*KeyConfig               `json:"keys"`
+	}
+
+	ProviderConfig struct {
+		ApiKey    string `json:"api_key,omitempty"`
+		ApiKeyEnv string `json:"api_key_env,omitempty"` // 从环境变量读取api key, 避免把key写进配置文件
+		BaseUrl   string `json:"base_url,omitempty"`
+	}
+
+	// ModelConfig 把对外暴露的别名映射到某个provider的真实模型
+	ModelConfig struct {
+		Alias    string `json:"alias"`
+		Provider string `json:"provider"`
+		Model    string `json:"model"`
+		Type     string `json:"type,omitempty"` // TypeChat(默认), TypeEmbedding, TypeTranscription
+	}
+
+	// KeyConfig 是网关签发的虚拟key, 配额为0表示不限制
+	KeyConfig struct {
+		Key         string `json:"key"`
+		Name        string `json:"name"`
+		MaxRequests int64  `json:"max_requests,omitempty"`
+		MaxTokens   int64  `json:"max_tokens,omitempty"`
+	}
+)
+
+func LoadConfig(path string) (*Config, error) {
+	var bts, err1 = os.ReadFile(path)
+	if err1 != nil {
+		return nil, err1
+	}
+
+	var config Config
+	if err2 := json.Unmarshal(bts, &config); err2 != nil {
+		return nil, fmt.Errorf("%s: %w", path, err2)
+	}
+
+	return &config, nil
+}
+
+func (my *Config) validate() error {
+	if len(my.Keys) == 0 {
+		return fmt.Errorf("no virtual keys are configured")
+	}
+
+	var keys = make(map[string]bool, len(my.Keys))
+	for _, key := range my.Keys {
+		if key.Key == "" || keys[key.Key] {
+			return fmt.Errorf("virtual key of %q is empty or duplicated", key.Name)
+		}
+		keys[key.Key] = true
+	}
+
+	var aliases = make(map[string]bool, len(my.Models))
+	for _, model := range my.Models {
+		if model.Alias == "" || model.Model == "" || aliases[model.Alias] {
+			return fmt.Errorf("model alias %q is empty or duplicated", model.Alias)
+		}
+		aliases[model.Alias] = true
+
+		if model.Type == "" {
+			model.Type = TypeChat
+		}
+
+		if my.Providers[model.Provider] == nil {
+			return fmt.Errorf("model %s uses unknown provider %s", model.Alias, model.Provider)
+		}
+
+		// 只有siliconflow提供了embeddings与transcription
+		if model.Type != TypeChat && model.Provider != ifs.ProviderSiliconFlow {
+			return fmt.Errorf("model %s: provider %s does not support %s", model.Alias, model.Provider, model.Type)
+		}
+	}
+
+	return nil
+}
+
+func (my *ProviderConfig) apiKey() string {
+	if my.ApiKey != "" {
+		return my.ApiKey
+	}
+
+	return os.Getenv(my.ApiKeyEnv)
+}
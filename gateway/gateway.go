@@ -0,0 +1,409 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lixianmin/agi/chat"
+	"github.com/lixianmin/agi/deepseek"
+	"github.com/lixianmin/agi/ifs"
+	"github.com/lixianmin/agi/siliconflow"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+const (
+	maxRequestBodySize = 1024 * 1024
+	maxAudioSize       = 32 * 1024 * 1024
+)
+
+type (
+	// Gateway 以OpenAI格式对外提供/v1/chat/completions, /v1/embeddings, /v1/audio/transcriptions与/v1/models,
+	// 按模型别名路由到deepseek或siliconflow, 调用方只持有网关签发的虚拟key
+	Gateway struct {
+		models   map[string]*ModelConfig
+		aliases  []string // 保持配置文件中的顺序
+		chats    map[string]chat.ChatService
+		silicon  *siliconflow.SiliconClient
+		keyring  *keyring
+		mux      *http.ServeMux
+		nowFunc  func() time.Time
+		idPrefix string
+	}
+
+	gatewayOptions struct {
+		httpClient   *http.Client
+		interceptors []ifs.Interceptor
+	}
+
+	GatewayOption func(*gatewayOptions)
+)
+
+// WithHttpClient 访问provider时使用的http.Client
+func WithHttpClient(client *http.Client) GatewayOption {
+	return func(options *gatewayOptions) {
+		options.httpClient = client
+	}
+}
+
+// WithInterceptors 加到所有provider client上的interceptor, 比如telemetry与cache
+func WithInterceptors(interceptors ...ifs.Interceptor) GatewayOption {
+	return func(options *gatewayOptions) {
+		options.interceptors = append(options.interceptors, interceptors...)
+	}
+}
+
+func New(config *Config, opts ...GatewayOption) (*Gateway, error) {
+	if config == nil {
+		return nil, fmt.Errorf("config is nil")
+	}
+
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	// 默认值
+	var options = gatewayOptions{}
+
+	// 初始化
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	var gateway = &Gateway{
+		models:   make(map[string]*ModelConfig, len(config.Models)),
+		chats:    make(map[string]chat.ChatService),
+		keyring:  newKeyring(config.Keys),
+		mux:      http.NewServeMux(),
+		nowFunc:  time.Now,
+		idPrefix: "chatcmpl-",
+	}
+
+	for name, provider := range config.Providers {
+		switch name {
+		case ifs.ProviderDeepSeek:
+			var client = deepseek.NewDeepSeekClient(provider.apiKey(), deepseek.WithBaseUrl(provider.BaseUrl),
+				deepseek.WithHttpClient(options.httpClient), deepseek.WithInterceptors(options.interceptors...))
+			gateway.chats[name] = deepseek.NewChatService(client)
+		case ifs.ProviderSiliconFlow:
+			var client = siliconflow.NewSiliconClient(provider.apiKey(), siliconflow.WithBaseUrl(provider.BaseUrl),
+				siliconflow.WithHttpClient(options.httpClient), siliconflow.WithInterceptors(options.interceptors...))
+			gateway.chats[name] = siliconflow.NewChatService(client)
+			gateway.silicon = client
+		default:
+			return nil, fmt.Errorf("unknown provider %s", name)
+		}
+	}
+
+	for _, model := range config.Models {
+		gateway.models[model.Alias] = model
+		gateway.aliases = append(gateway.aliases, model.Alias)
+	}
+
+	gateway.mux.HandleFunc("/v1/chat/completions", gateway.handleChat)
+	gateway.mux.HandleFunc("/v1/embeddings", gateway.handleEmbeddings)
+	gateway.mux.HandleFunc("/v1/audio/transcriptions", gateway.handleTranscription)
+	gateway.mux.HandleFunc("/v1/models", gateway.handleModels)
+	return gateway, nil
+}
+
+func (my *Gateway) ServeHTTP(writer http.ResponseWriter, r *http.Request) {
+	my.mux.ServeHTTP(writer, r)
+}
+
+// Usage 返回每个虚拟key的用量, 用于监控
+func (my *Gateway) Usage() []KeyUsage {
+	return my.keyring.snapshot()
+}
+
+func (my *Gateway) handleChat(writer http.ResponseWriter, r *http.Request) {
+	var key, ok = my.authorize(writer, r, http.MethodPost)
+	if !ok {
+		return
+	}
+
+	var request chatCompletionRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxRequestBodySize)).Decode(&request); err != nil {
+		writeError(writer, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+
+	var model, found = my.findModel(writer, request.Model, TypeChat)
+	if !found {
+		return
+	}
+
+	if len(request.Messages) == 0 {
+		writeError(writer, http.StatusBadRequest, "invalid_request_error", "messages are required")
+		return
+	}
+
+	var chatRequest = &chat.Request{
+		Model:    model.Model,
+		Messages: request.Messages,
+		Params: &chat.Params{
+			Temperature: request.Temperature,
+			TopP:        request.TopP,
+			TopK:        request.TopK,
+			MaxTokens:   request.MaxTokens,
+			Stop:        request.Stop,
+		},
+	}
+
+	var service = my.chats[model.Provider]
+	if request.Stream {
+		var includeUsage = request.StreamOptions != nil && request.StreamOptions.IncludeUsage
+		my.streamChat(r.Context(), writer, key, request.Model, service, chatRequest, includeUsage)
+		return
+	}
+
+	var response, err = service.Chat(r.Context(), chatRequest)
+	if err != nil {
+		writeUpstreamError(writer, err)
+		return
+	}
+
+	if response.Usage != nil {
+		my.keyring.charge(key, response.Usage.TotalTokens)
+	}
+
+	var finishReason = response.FinishReason
+	var message = response.Message
+	message.Role = "assistant"
+	writeJson(writer, http.StatusOK, &chatCompletion{
+		ID:      my.completionId(response.ID),
+		Object:  "chat.completion",
+		Created: my.nowFunc().Unix(),
+		Model:   request.Model, // 对外只暴露别名
+		Choices: []chatChoice{{Message: &message, FinishReason: &finishReason}},
+		Usage:   response.Usage,
+	})
+}
+
+// streamChat 把provider的chunk转换为OpenAI的chat.completion.chunk, 以SSE的形式转发
+func (my *Gateway) streamChat(ctx context.Context, writer http.ResponseWriter, key string, alias string, service chat.ChatService, request *chat.Request, includeUsage bool) {
+	var flusher, _ = writer.(http.Flusher)
+	var id = my.completionId("")
+	var created = my.nowFunc().Unix()
+	var started = false
+	var usage *chat.Usage
+
+	var send = func(chunk *chatCompletion) error {
+		if !started {
+			started = true
+			writer.Header().Set("Content-Type", "text/event-stream")
+			writer.Header().Set("Cache-Control", "no-cache")
+			writer.WriteHeader(http.StatusOK)
+		}
+
+		var data, _ = json.Marshal(chunk)
+		if _, err := fmt.Fprintf(writer, "data: %s\n\n", data); err != nil {
+			return err
+		}
+
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return nil
+	}
+
+	var first = true
+	var err = service.StreamChat(ctx, request, func(response *chat.Response) error {
+		if response.Usage != nil {
+			usage = response.Usage
+		}
+
+		if response.Message.Content == "" && response.FinishReason == "" {
+			return nil
+		}
+
+		var delta = &chat.Message{Content: response.Message.Content}
+		if first {
+			delta.Role = "assistant"
+			first = false
+		}
+
+		var choice = chatChoice{Delta: delta}
+		if response.FinishReason != "" {
+			var finishReason = response.FinishReason
+			choice.FinishReason = &finishReason
+		}
+
+		return send(&chatCompletion{ID: id, Object: "chat.completion.chunk", Created: created, Model: alias, Choices: []chatChoice{choice}})
+	})
+
+	if usage != nil {
+		my.keyring.charge(key, usage.TotalTokens)
+	}
+
+	if err != nil {
+		// 还没有开始发送时可以返回正常的错误响应, 否则只能中断stream
+		if !started {
+			writeUpstreamError(writer, err)
+		}
+		return
+	}
+
+	if includeUsage && usage != nil {
+		_ = send(&chatCompletion{ID: id, Object: "chat.completion.chunk", Created: created, Model: alias, Choices: []chatChoice{}, Usage: usage})
+	}
+
+	if !started {
+		_ = send(&chatCompletion{ID: id, Object: "chat.completion.chunk", Created: created, Model: alias, Choices: []chatChoice{}})
+	}
+
+	_, _ = io.WriteString(writer, "data: [DONE]\n\n")
+	if flusher != nil {
+		flusher.Flush()
+	}
+}
+
+func (my *Gateway) handleEmbeddings(writer http.ResponseWriter, r *http.Request) {
+	var key, ok = my.authorize(writer, r, http.MethodPost)
+	if !ok {
+		return
+	}
+
+	var request embeddingsRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxRequestBodySize)).Decode(&request); err != nil {
+		writeError(writer, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+
+	var model, found = my.findModel(writer, request.Model, TypeEmbedding)
+	if !found {
+		return
+	}
+
+	if len(request.Input) == 0 {
+		writeError(writer, http.StatusBadRequest, "invalid_request_error", "input is required")
+		return
+	}
+
+	var response, err = my.silicon.Embeddings(r.Context(), &siliconflow.EmbeddingRequest{
+		Model:          model.Model,
+		Input:          request.Input,
+		EncodingFormat: request.EncodingFormat,
+	})
+
+	if err != nil {
+		writeUpstreamError(writer, err)
+		return
+	}
+
+	if response.Usage != nil {
+		my.keyring.charge(key, response.Usage.TotalTokens)
+	}
+
+	response.Object = "list"
+	response.Model = request.Model
+	writeJson(writer, http.StatusOK, response)
+}
+
+func (my *Gateway) handleTranscription(writer http.ResponseWriter, r *http.Request) {
+	var _, ok = my.authorize(writer, r, http.MethodPost)
+	if !ok {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(writer, r.Body, maxAudioSize)
+	if err := r.ParseMultipartForm(maxAudioSize); err != nil {
+		writeError(writer, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+
+	var model, found = my.findModel(writer, r.FormValue("model"), TypeTranscription)
+	if !found {
+		return
+	}
+
+	var file, _, err1 = r.FormFile("file")
+	if err1 != nil {
+		writeError(writer, http.StatusBadRequest, "invalid_request_error", "file is required")
+		return
+	}
+	defer file.Close()
+
+	var audio, err2 = io.ReadAll(file)
+	if err2 != nil {
+		writeError(writer, http.StatusBadRequest, "invalid_request_error", err2.Error())
+		return
+	}
+
+	var text, err3 = my.silicon.TranscribeAudio(r.Context(), model.Model, audio)
+	if err3 != nil {
+		writeUpstreamError(writer, err3)
+		return
+	}
+
+	writeJson(writer, http.StatusOK, map[string]string{"text": text})
+}
+
+func (my *Gateway) handleModels(writer http.ResponseWriter, r *http.Request) {
+	if _, ok := my.authorize(writer, r, http.MethodGet); !ok {
+		return
+	}
+
+	var list = modelList{Object: "list", Data: make([]modelEntry, 0, len(my.aliases))}
+	for _, alias := range my.aliases {
+		var model = my.models[alias]
+		list.Data = append(list.Data, modelEntry{ID: alias, Object: "model", OwnedBy: model.Provider, Type: model.Type})
+	}
+
+	writeJson(writer, http.StatusOK, list)
+}
+
+// authorize 校验method与虚拟key, 并计入一次请求
+func (my *Gateway) authorize(writer http.ResponseWriter, r *http.Request, method string) (string, bool) {
+	if r.Method != method {
+		writeError(writer, http.StatusMethodNotAllowed, "invalid_request_error", "method not allowed")
+		return "", false
+	}
+
+	var key = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	var _, status = my.keyring.acquire(key)
+	switch status {
+	case http.StatusOK:
+		return key, true
+	case http.StatusTooManyRequests:
+		writeError(writer, status, "insufficient_quota", "quota exceeded")
+	default:
+		writeError(writer, status, "invalid_request_error", "invalid api key")
+	}
+
+	return "", false
+}
+
+func (my *Gateway) findModel(writer http.ResponseWriter, alias string, modelType string) (*ModelConfig, bool) {
+	var model, ok = my.models[alias]
+	if !ok || model.Type != modelType {
+		writeError(writer, http.StatusNotFound, "invalid_request_error", fmt.Sprintf("model %q does not exist or is not a %s model", alias, modelType))
+		return nil, false
+	}
+
+	return model, true
+}
+
+func (my *Gateway) completionId(upstream string) string {
+	if upstream != "" {
+		return upstream
+	}
+
+	return my.idPrefix + strconv.FormatInt(my.nowFunc().UnixNano(), 36)
+}
+
+func formatSeconds(seconds float64) string {
+	return strconv.Itoa(int(math.Ceil(seconds)))
+}
@@ -0,0 +1,220 @@
+package gateway
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lixianmin/agi/agitest"
+	"github.com/lixianmin/agi/chat"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+func newTestGateway(t *testing.T) (*httptest.Server, *agitest.Server) {
+	var upstream = agitest.NewTestServer(t)
+	var config = &Config{
+		Providers: map[string]*ProviderConfig{
+			"deepseek":    {ApiKey: "sk-deepseek", BaseUrl: upstream.BaseUrl()},
+			"siliconflow": {ApiKey: "sk-silicon", BaseUrl: upstream.BaseUrl()},
+		},
+		Models: []*ModelConfig{
+			{Alias: "chat", Provider: "deepseek", Model: "deepseek-chat"},
+			{Alias: "embedding", Provider: "siliconflow", Model: "BAAI/bge-m3", Type: TypeEmbedding},
+			{Alias: "whisper", Provider: "siliconflow", Model: "iic/SenseVoiceSmall", Type: TypeTranscription},
+		},
+		Keys: []*KeyConfig{
+			{Key: "sk-gw", Name: "dev"},
+			{Key: "sk-once", Name: "once", MaxRequests: 1},
+		},
+	}
+
+	var gateway, err = New(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var server = httptest.NewServer(gateway)
+	t.Cleanup(server.Close)
+	return server, upstream
+}
+
+func post(t *testing.T, url string, key string, body any) *http.Response {
+	var bts, _ = json.Marshal(body)
+	var request, _ = http.NewRequest(http.MethodPost, url, bytes.NewReader(bts))
+	request.Header.Set("Authorization", "Bearer "+key)
+	request.Header.Set("Content-Type", "application/json")
+
+	var response, err = http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { _ = response.Body.Close() })
+	return response
+}
+
+func TestChatCompletions(t *testing.T) {
+	var server, upstream = newTestGateway(t)
+	upstream.SetDefaultReply(agitest.Reply{Content: "你好"})
+
+	var body = map[string]any{
+		"model":       "chat",
+		"messages":    []*chat.Message{{Role: "user", Content: "hi"}},
+		"temperature": 0.5,
+	}
+
+	var response = post(t, server.URL+"/v1/chat/completions", "sk-gw", body)
+	var completion chatCompletion
+	_ = json.NewDecoder(response.Body).Decode(&completion)
+	if response.StatusCode != http.StatusOK || completion.Model != "chat" || len(completion.Choices) != 1 ||
+		completion.Choices[0].Message.Content != "你好" || completion.Usage == nil {
+		t.Fatalf("status=%d, completion=%+v", response.StatusCode, completion)
+	}
+
+	// 别名被替换为真实的模型名
+	if last := upstream.LastChatRequest(); last.Model != "deepseek-chat" || last.Temperature == nil || *last.Temperature != 0.5 {
+		t.Fatalf("last=%+v", last)
+	}
+
+	if response := post(t, server.URL+"/v1/chat/completions", "sk-wrong", body); response.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("status=%d", response.StatusCode)
+	}
+
+	body["model"] = "embedding"
+	if response := post(t, server.URL+"/v1/chat/completions", "sk-gw", body); response.StatusCode != http.StatusNotFound {
+		t.Fatalf("status=%d", response.StatusCode)
+	}
+}
+
+func TestStreamChatCompletions(t *testing.T) {
+	var server, upstream = newTestGateway(t)
+	upstream.SetDefaultReply(agitest.Reply{Content: "streaming works"})
+
+	var response = post(t, server.URL+"/v1/chat/completions", "sk-gw", map[string]any{
+		"model":          "chat",
+		"messages":       []*chat.Message{{Role: "user", Content: "hi"}},
+		"stream":         true,
+		"stream_options": map[string]bool{"include_usage": true},
+	})
+
+	if response.StatusCode != http.StatusOK || !strings.HasPrefix(response.Header.Get("Content-Type"), "text/event-stream") {
+		t.Fatalf("status=%d", response.StatusCode)
+	}
+
+	var text string
+	var usage *chat.Usage
+	var done bool
+	var scanner = bufio.NewScanner(response.Body)
+	for scanner.Scan() {
+		var data, ok = strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+
+		if data == "[DONE]" {
+			done = true
+			break
+		}
+
+		var chunk chatCompletion
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, choice := range chunk.Choices {
+			text += choice.Delta.Content
+		}
+
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+	}
+
+	println(text)
+	if text != "streaming works" || usage == nil || !done {
+		t.Fatalf("text=%q, usage=%v, done=%v", text, usage, done)
+	}
+}
+
+func TestEmbeddingsAndModels(t *testing.T) {
+	var server, _ = newTestGateway(t)
+
+	var response = post(t, server.URL+"/v1/embeddings", "sk-gw", map[string]any{"model": "embedding", "input": "hello"})
+	var result struct {
+		Data []struct {
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+		Model string `json:"model"`
+	}
+
+	_ = json.NewDecoder(response.Body).Decode(&result)
+	if response.StatusCode != http.StatusOK || len(result.Data) != 1 || len(result.Data[0].Embedding) == 0 || result.Model != "embedding" {
+		t.Fatalf("status=%d, result=%+v", response.StatusCode, result)
+	}
+
+	var request, _ = http.NewRequest(http.MethodGet, server.URL+"/v1/models", nil)
+	request.Header.Set("Authorization", "Bearer sk-gw")
+	var response2, err = http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response2.Body.Close()
+
+	var list modelList
+	_ = json.NewDecoder(response2.Body).Decode(&list)
+	if len(list.Data) != 3 || list.Data[0].ID != "chat" || list.Data[2].Type != TypeTranscription {
+		t.Fatalf("list=%+v", list)
+	}
+}
+
+func TestTranscriptions(t *testing.T) {
+	var server, upstream = newTestGateway(t)
+	upstream.SetTranscription("测试")
+
+	var body bytes.Buffer
+	var writer = multipart.NewWriter(&body)
+	_ = writer.WriteField("model", "whisper")
+	var part, _ = writer.CreateFormFile("file", "audio.wav")
+	_, _ = part.Write([]byte("RIFF fake wav"))
+	_ = writer.Close()
+
+	var request, _ = http.NewRequest(http.MethodPost, server.URL+"/v1/audio/transcriptions", &body)
+	request.Header.Set("Authorization", "Bearer sk-gw")
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+
+	var response, err = http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	var result map[string]string
+	_ = json.NewDecoder(response.Body).Decode(&result)
+	if response.StatusCode != http.StatusOK || result["text"] != "测试" {
+		t.Fatalf("status=%d, result=%v", response.StatusCode, result)
+	}
+}
+
+func TestQuota(t *testing.T) {
+	var server, _ = newTestGateway(t)
+	var body = map[string]any{"model": "chat", "messages": []*chat.Message{{Role: "user", Content: "hi"}}}
+
+	if response := post(t, server.URL+"/v1/chat/completions", "sk-once", body); response.StatusCode != http.StatusOK {
+		t.Fatalf("status=%d", response.StatusCode)
+	}
+
+	if response := post(t, server.URL+"/v1/chat/completions", "sk-once", body); response.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("status=%d", response.StatusCode)
+	}
+}
@@ -0,0 +1,93 @@
+package gateway
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+type (
+	// KeyUsage 是某个虚拟key目前为止的用量
+	KeyUsage struct {
+		Name     string `json:"name"`
+		Requests int64  `json:"requests"`
+		Tokens   int64  `json:"tokens"`
+	}
+
+	// keyring 校验虚拟key并统计配额, 用量只保存在内存中, 重启后清零
+	keyring struct {
+		keys  map[string]*KeyConfig
+		usage map[string]*KeyUsage
+		m     sync.Mutex
+	}
+)
+
+func newKeyring(keys []*KeyConfig) *keyring {
+	var ring = &keyring{
+		keys:  make(map[string]*KeyConfig, len(keys)),
+		usage: make(map[string]*KeyUsage, len(keys)),
+	}
+
+	for _, key := range keys {
+		ring.keys[key.Key] = key
+		ring.usage[key.Key] = &KeyUsage{Name: key.Name}
+	}
+
+	return ring
+}
+
+// acquire 校验key并计入一次请求, 返回http状态码: 401表示key不存在, 429表示超出配额
+func (my *keyring) acquire(key string) (*KeyConfig, int) {
+	my.m.Lock()
+	defer my.m.Unlock()
+
+	var config, ok = my.keys[key]
+	if !ok {
+		return nil, http.StatusUnauthorized
+	}
+
+	var usage = my.usage[key]
+	if config.MaxRequests > 0 && usage.Requests >= config.MaxRequests {
+		return nil, http.StatusTooManyRequests
+	}
+
+	if config.MaxTokens > 0 && usage.Tokens >= config.MaxTokens {
+		return nil, http.StatusTooManyRequests
+	}
+
+	usage.Requests++
+	return config, http.StatusOK
+}
+
+// charge 在请求完成之后计入token用量
+func (my *keyring) charge(key string, tokens int) {
+	if tokens <= 0 {
+		return
+	}
+
+	my.m.Lock()
+	if usage, ok := my.usage[key]; ok {
+		usage.Tokens += int64(tokens)
+	}
+	my.m.Unlock()
+}
+
+func (my *keyring) snapshot() []KeyUsage {
+	my.m.Lock()
+	defer my.m.Unlock()
+
+	var list = make([]KeyUsage, 0, len(my.usage))
+	for _, usage := range my.usage {
+		list = append(list, *usage)
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
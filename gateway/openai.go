@@ -0,0 +1,125 @@
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/lixianmin/agi/chat"
+	"github.com/lixianmin/agi/ifs"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+// 以下是OpenAI api的请求与响应格式, 只包含网关支持的字段
+
+type (
+	chatCompletionRequest struct {
+		Model         string          `json:"model"`
+		Messages      []*chat.Message `json:"messages"`
+		Stream        bool            `json:"stream,omitempty"`
+		StreamOptions *streamOptions  `json:"stream_options,omitempty"`
+		Temperature   *float32        `json:"temperature,omitempty"`
+		TopP          float32         `json:"top_p,omitempty"`
+		TopK          int32           `json:"top_k,omitempty"`
+		MaxTokens     int32           `json:"max_tokens,omitempty"`
+		Stop          stringList      `json:"stop,omitempty"`
+	}
+
+	streamOptions struct {
+		IncludeUsage bool `json:"include_usage"`
+	}
+
+	chatCompletion struct {
+		ID      string       `json:"id"`
+		Object  string       `json:"object"`
+		Created int64        `json:"created"`
+		Model   string       `json:"model"`
+		Choices []chatChoice `json:"choices"`
+		Usage   *chat.Usage  `json:"usage,omitempty"`
+	}
+
+	chatChoice struct {
+		Index        int           `json:"index"`
+		Message      *chat.Message `json:"message,omitempty"`
+		Delta        *chat.Message `json:"delta,omitempty"`
+		FinishReason *string       `json:"finish_reason"`
+	}
+
+	embeddingsRequest struct {
+		Model          string     `json:"model"`
+		Input          stringList `json:"input"`
+		EncodingFormat string     `json:"encoding_format,omitempty"`
+	}
+
+	modelList struct {
+		Object string       `json:"object"`
+		Data   []modelEntry `json:"data"`
+	}
+
+	modelEntry struct {
+		ID      string `json:"id"`
+		Object  string `json:"object"`
+		Created int64  `json:"created"`
+		OwnedBy string `json:"owned_by"`
+		Type    string `json:"type"`
+	}
+
+	errorBody struct {
+		Error errorDetail `json:"error"`
+	}
+
+	errorDetail struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+	}
+
+	// stringList 兼容OpenAI中既可以是字符串也可以是字符串数组的字段, 比如stop与input
+	stringList []string
+)
+
+func (my *stringList) UnmarshalJSON(bts []byte) error {
+	var text string
+	if err := json.Unmarshal(bts, &text); err == nil {
+		*my = stringList{text}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(bts, &list); err != nil {
+		return errors.New("expected a string or an array of strings")
+	}
+
+	*my = list
+	return nil
+}
+
+func writeJson(writer http.ResponseWriter, status int, body any) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	_ = json.NewEncoder(writer).Encode(body)
+}
+
+func writeError(writer http.ResponseWriter, status int, errorType string, message string) {
+	writeJson(writer, status, errorBody{Error: errorDetail{Message: message, Type: errorType}})
+}
+
+// writeUpstreamError provider返回的错误保留原始的状态码, 其它错误(比如网络错误)返回502
+func writeUpstreamError(writer http.ResponseWriter, err error) {
+	var statusErr *ifs.StatusError
+	if errors.As(err, &statusErr) {
+		if statusErr.RetryAfter > 0 {
+			writer.Header().Set("Retry-After", formatSeconds(statusErr.RetryAfter.Seconds()))
+		}
+
+		writeError(writer, statusErr.StatusCode, "upstream_error", statusErr.Message)
+		return
+	}
+
+	writeError(writer, http.StatusBadGateway, "upstream_error", err.Error())
+}
@@ -0,0 +1,28 @@
+package ifs
+
+import "github.com/lixianmin/agi/chat"
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+type (
+	// Completion 由各provider的chat响应(包括streaming的chunk)实现, 让interceptor可以不依赖具体的provider读取通用字段
+	Completion interface {
+		GetModel() string
+		GetContent() string
+		GetFinishReason() string
+		GetUsage() *chat.Usage
+	}
+
+	// Synthesizer 由各provider设置在Call上, 让短路的interceptor(比如缓存)可以合成provider自己的响应类型
+	Synthesizer interface {
+		// NewCompletion 返回非streaming调用的结果, 比如*deepseek.ChatCompletionChunk
+		NewCompletion(model string, content string, finishReason string, usage *chat.Usage) any
+		// NewChunk 返回streaming调用的chunk, 比如deepseek.ChatResponse
+		NewChunk(model string, content string, finishReason string, usage *chat.Usage, done bool) any
+	}
+)
@@ -1,6 +1,10 @@
 package ifs
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/lixianmin/agi/chat"
+)
 
 /*
 *******************************************************************
@@ -11,8 +15,24 @@ Copyright (C) - All Rights Reserved
 ********************************************************************
 */
 
-const ()
+const (
+	ProviderDeepSeek    = "deepseek"
+	ProviderSiliconFlow = "siliconflow"
+)
+
+const (
+	EndpointChat          = "chat"
+	EndpointStreamChat    = "stream_chat"
+	EndpointTranscription = "transcription"
+	EndpointEmbeddings    = "embeddings"
+	EndpointFiles         = "files"
+	EndpointBatches       = "batches"
+	EndpointModels        = "models"
+)
 
 var (
-	ErrRequestIsNil = errors.New("request is nil")
+	ErrRequestIsNil      = chat.ErrRequestIsNil // 与chat共用同一个error, ChatService的调用方可以用任意一个判断
+	ErrUnexpectedRequest = errors.New("unexpected request type")
+	ErrUnexpectedResult  = errors.New("unexpected result type")
+	ErrUnknownEndpoint   = errors.New("unknown endpoint")
 )
@@ -0,0 +1,15 @@
+package ifs
+
+import "context"
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+// Embedder 把一组文本转换为向量, 返回的向量与texts一一对应
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
@@ -0,0 +1,81 @@
+package ifs
+
+import (
+	"context"
+	"net/http"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+type (
+	// Call 描述一次对provider的API调用, interceptor可以在调用next之前修改它
+	Call struct {
+		Provider string // ProviderDeepSeek, ProviderSiliconFlow
+		Endpoint string // EndpointChat, EndpointStreamChat, EndpointTranscription
+		Model    string
+
+		// Request 是provider自己的请求类型的指针, 比如*deepseek.ChatRequest; 可以就地修改, 也可以替换成同类型的新对象
+		Request any
+
+		// Header 在默认header设置完成之后合并到http请求中, 可用于auth轮换, tracing等
+		Header http.Header
+
+		// OnChunk 只在streaming调用中有值, chunk是provider自己的ChatResponse. 包装它可以观察或修改每一个chunk;
+		// 直接调用它(而不调用next)可以返回合成的streaming响应
+		OnChunk func(chunk any) error
+
+		// Synthesizer 用于合成与当前provider匹配的响应
+		Synthesizer Synthesizer
+	}
+
+	// Invoker 执行一次调用. 对于非streaming调用, 返回值是provider自己的响应类型; streaming调用返回nil
+	Invoker func(ctx context.Context, call *Call) (any, error)
+
+	// Interceptor 包裹在Invoker外面, 不调用next即可短路并返回合成的响应
+	Interceptor func(ctx context.Context, call *Call, next Invoker) (any, error)
+)
+
+// ChainInterceptors 把多个interceptor串成一个, 第一个interceptor位于最外层
+func ChainInterceptors(interceptors ...Interceptor) Interceptor {
+	var list = make([]Interceptor, 0, len(interceptors))
+	for _, interceptor := range interceptors {
+		if interceptor != nil {
+			list = append(list, interceptor)
+		}
+	}
+
+	switch len(list) {
+	case 0:
+		return nil
+	case 1:
+		return list[0]
+	}
+
+	return func(ctx context.Context, call *Call, next Invoker) (any, error) {
+		return list[0](ctx, call, chainInvoker(list, 1, next))
+	}
+}
+
+func chainInvoker(list []Interceptor, index int, final Invoker) Invoker {
+	if index == len(list) {
+		return final
+	}
+
+	return func(ctx context.Context, call *Call) (any, error) {
+		return list[index](ctx, call, chainInvoker(list, index+1, final))
+	}
+}
+
+// Invoke 如果interceptor为nil, 则直接调用invoker
+func Invoke(ctx context.Context, interceptor Interceptor, call *Call, invoker Invoker) (any, error) {
+	if interceptor == nil {
+		return invoker(ctx, call)
+	}
+
+	return interceptor(ctx, call, invoker)
+}
@@ -0,0 +1,61 @@
+package ifs
+
+import (
+	"context"
+	"testing"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+func TestChainInterceptors(t *testing.T) {
+	var trace []string
+	var newInterceptor = func(name string) Interceptor {
+		return func(ctx context.Context, call *Call, next Invoker) (any, error) {
+			trace = append(trace, name+".before")
+			var result, err = next(ctx, call)
+			trace = append(trace, name+".after")
+			return result, err
+		}
+	}
+
+	var interceptor = ChainInterceptors(newInterceptor("a"), nil, newInterceptor("b"))
+	var result, _ = Invoke(context.Background(), interceptor, &Call{}, func(ctx context.Context, call *Call) (any, error) {
+		trace = append(trace, "invoke")
+		return "done", nil
+	})
+
+	var expected = []string{"a.before", "b.before", "invoke", "b.after", "a.after"}
+	if len(trace) != len(expected) {
+		t.Fatalf("trace=%v", trace)
+	}
+
+	for i := range expected {
+		if trace[i] != expected[i] {
+			t.Fatalf("trace=%v", trace)
+		}
+	}
+
+	if result != "done" {
+		t.Fatalf("result=%v", result)
+	}
+}
+
+func TestShortCircuit(t *testing.T) {
+	var shortCircuit = func(ctx context.Context, call *Call, next Invoker) (any, error) {
+		return "synthetic", nil
+	}
+
+	var result, _ = Invoke(context.Background(), shortCircuit, &Call{}, func(ctx context.Context, call *Call) (any, error) {
+		t.Fatal("invoker should not be called")
+		return nil, nil
+	})
+
+	if result != "synthetic" {
+		t.Fatalf("result=%v", result)
+	}
+}
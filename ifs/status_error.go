@@ -0,0 +1,70 @@
+package ifs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+// StatusError 表示provider返回了非200的http状态码
+type StatusError struct {
+	StatusCode int
+	Message    string
+	Type       string
+	RetryAfter time.Duration // 来自Retry-After header, 没有则为0
+}
+
+const maxErrorBodySize = 64 * 1024
+
+// NewStatusError 读取并解析openai格式的错误body: {"error":{"message":"...","type":"..."}}
+func NewStatusError(response *http.Response) *StatusError {
+	var err = &StatusError{
+		StatusCode: response.StatusCode,
+	}
+
+	var body, _ = io.ReadAll(io.LimitReader(response.Body, maxErrorBodySize))
+	var payload struct {
+		Error struct {
+			Message string `json:"message"`
+			Type    string `json:"type"`
+		} `json:"error"`
+		Message string `json:"message"`
+	}
+
+	if json.Unmarshal(body, &payload) == nil {
+		err.Message = payload.Error.Message
+		err.Type = payload.Error.Type
+		if err.Message == "" {
+			err.Message = payload.Message
+		}
+	}
+
+	if err.Message == "" {
+		err.Message = string(body)
+	}
+
+	if seconds, err1 := strconv.Atoi(response.Header.Get("Retry-After")); err1 == nil && seconds > 0 {
+		err.RetryAfter = time.Duration(seconds) * time.Second
+	}
+
+	return err
+}
+
+func (my *StatusError) Error() string {
+	return fmt.Sprintf("status=%d, message=%s", my.StatusCode, my.Message)
+}
+
+// Retryable 限流与服务端错误可以重试
+func (my *StatusError) Retryable() bool {
+	return my.StatusCode == http.StatusTooManyRequests || my.StatusCode >= http.StatusInternalServerError
+}
@@ -0,0 +1,31 @@
+package prompt
+
+import (
+	"embed"
+	"sync"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+//go:embed templates
+var builtinFS embed.FS
+
+var (
+	builtinOnce     sync.Once
+	builtinRegistry *Registry
+	builtinErr      error
+)
+
+// Builtin 返回随库发布的模板, 比如english_tutor
+func Builtin() (*Registry, error) {
+	builtinOnce.Do(func() {
+		builtinRegistry, builtinErr = LoadFS(builtinFS, "templates")
+	})
+
+	return builtinRegistry, builtinErr
+}
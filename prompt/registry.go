@@ -0,0 +1,253 @@
+package prompt
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+const (
+	templateExt = ".json"
+	partialExt  = ".partial"
+)
+
+// Registry 按名字与版本管理模板, 线程安全
+type Registry struct {
+	templates map[string][]*Template // 每个名字下的模板按版本从低到高排序
+	partials  map[string]string
+	m         sync.RWMutex
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		templates: make(map[string][]*Template),
+		partials:  make(map[string]string),
+	}
+}
+
+// LoadFS 加载fsys中dir目录下的所有模板(*.json)与partial(*.partial, 文件名即partial的名字), 支持embed.FS与os.DirFS
+func LoadFS(fsys fs.FS, dir string) (*Registry, error) {
+	var registry = NewRegistry()
+	var err = fs.WalkDir(fsys, dir, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		var bts, err1 = fs.ReadFile(fsys, filePath)
+		if err1 != nil {
+			return err1
+		}
+
+		switch path.Ext(filePath) {
+		case partialExt:
+			registry.AddPartial(strings.TrimSuffix(path.Base(filePath), partialExt), string(bts))
+		case templateExt:
+			var template Template
+			if err2 := json.Unmarshal(bts, &template); err2 != nil {
+				return fmt.Errorf("%s: %w", filePath, err2)
+			}
+
+			if err3 := registry.Add(&template); err3 != nil {
+				return fmt.Errorf("%s: %w", filePath, err3)
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return registry, nil
+}
+
+// LoadDir 加载磁盘上的模板目录
+func LoadDir(dir string) (*Registry, error) {
+	return LoadFS(os.DirFS(dir), ".")
+}
+
+// Add 加入一个模板, 会检查消息中引用的变量都已经声明
+func (my *Registry) Add(template *Template) error {
+	if template == nil || template.Name == "" || template.Version == "" {
+		return fmt.Errorf("template name and version are required")
+	}
+
+	if len(template.Messages) == 0 {
+		return fmt.Errorf("template %s@%s has no messages", template.Name, template.Version)
+	}
+
+	for _, message := range template.Messages {
+		for _, match := range placeholderPattern.FindAllStringSubmatch(message.Content, -1) {
+			if _, ok := template.Variables[match[2]]; match[1] == "" && !ok {
+				return fmt.Errorf("template %s@%s: variable %s is not declared", template.Name, template.Version, match[2])
+			}
+		}
+	}
+
+	if template.Weight <= 0 {
+		template.Weight = 1
+	}
+
+	my.m.Lock()
+	defer my.m.Unlock()
+
+	var list = my.templates[template.Name]
+	for _, item := range list {
+		if item.Version == template.Version {
+			return fmt.Errorf("template %s@%s already exists", template.Name, template.Version)
+		}
+	}
+
+	template.registry = my
+	list = append(list, template)
+	sort.Slice(list, func(i, j int) bool {
+		return compareVersion(list[i].Version, list[j].Version) < 0
+	})
+	my.templates[template.Name] = list
+	return nil
+}
+
+func (my *Registry) AddPartial(name string, content string) {
+	my.m.Lock()
+	my.partials[name] = content
+	my.m.Unlock()
+}
+
+// Get version为空时返回最新的版本
+func (my *Registry) Get(name string, version string) (*Template, error) {
+	my.m.RLock()
+	defer my.m.RUnlock()
+
+	var list = my.templates[name]
+	if len(list) == 0 {
+		return nil, fmt.Errorf("template %s not found", name)
+	}
+
+	if version == "" {
+		return list[len(list)-1], nil
+	}
+
+	for _, template := range list {
+		if template.Version == version {
+			return template, nil
+		}
+	}
+
+	return nil, fmt.Errorf("template %s@%s not found", name, version)
+}
+
+// Versions 返回name的所有版本, 从低到高排序
+func (my *Registry) Versions(name string) []string {
+	my.m.RLock()
+	defer my.m.RUnlock()
+
+	var versions = make([]string, 0, len(my.templates[name]))
+	for _, template := range my.templates[name] {
+		versions = append(versions, template.Version)
+	}
+
+	return versions
+}
+
+// Pick 按Weight在name的所有版本中做A/B分流, 相同的key(比如用户ID)总是得到相同的版本
+func (my *Registry) Pick(name string, key string) (*Template, error) {
+	my.m.RLock()
+	defer my.m.RUnlock()
+
+	var list = my.templates[name]
+	if len(list) == 0 {
+		return nil, fmt.Errorf("template %s not found", name)
+	}
+
+	var total = 0
+	for _, template := range list {
+		total += template.Weight
+	}
+
+	var hash = fnv.New32a()
+	_, _ = hash.Write([]byte(name + "\x00" + key))
+	var slot = int(hash.Sum32() % uint32(total))
+	for _, template := range list {
+		if slot < template.Weight {
+			return template, nil
+		}
+		slot -= template.Weight
+	}
+
+	return list[len(list)-1], nil
+}
+
+// Render 渲染name@version, version为空时使用最新的版本
+func (my *Registry) Render(name string, version string, values map[string]any) (*Rendered, error) {
+	var template, err = my.Get(name, version)
+	if err != nil {
+		return nil, err
+	}
+
+	return template.Render(values)
+}
+
+func (my *Registry) partial(name string) (string, bool) {
+	if my == nil {
+		return "", false
+	}
+
+	my.m.RLock()
+	defer my.m.RUnlock()
+
+	var content, ok = my.partials[name]
+	return content, ok
+}
+
+// compareVersion 自然排序: 数字部分按数值比较, 因此v2 < v10
+func compareVersion(a string, b string) int {
+	var i, j = 0, 0
+	for i < len(a) && j < len(b) {
+		if isDigit(a[i]) && isDigit(b[j]) {
+			var startA, startB = i, j
+			for i < len(a) && isDigit(a[i]) {
+				i++
+			}
+			for j < len(b) && isDigit(b[j]) {
+				j++
+			}
+
+			var numA = strings.TrimLeft(a[startA:i], "0")
+			var numB = strings.TrimLeft(b[startB:j], "0")
+			if len(numA) != len(numB) {
+				return len(numA) - len(numB)
+			}
+
+			if numA != numB {
+				return strings.Compare(numA, numB)
+			}
+			continue
+		}
+
+		if a[i] != b[j] {
+			return int(a[i]) - int(b[j])
+		}
+		i++
+		j++
+	}
+
+	return (len(a) - i) - (len(b) - j)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
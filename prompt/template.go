@@ -0,0 +1,258 @@
+package prompt
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lixianmin/agi/chat"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+const (
+	TypeString = "string"
+	TypeInt    = "int"
+	TypeFloat  = "float"
+	TypeBool   = "bool"
+)
+
+const maxPartialDepth = 8
+
+type (
+	// Template 是一个带版本的多消息模板, 通常从json文件加载. 消息内容中可以使用{{name}}引用变量, {{> name}}引用partial
+	Template struct {
+		Name      string               `json:"name"`
+		Version   string               `json:"version"`
+		Weight    int                  `json:"weight,omitempty"` // A/B实验中的权重, 默认为1
+		Variables map[string]*Variable `json:"variables,omitempty"`
+		Messages  []*chat.Message      `json:"messages"`
+
+		registry *Registry
+	}
+
+	Variable struct {
+		Type        string   `json:"type,omitempty"` // TypeString(默认), TypeInt, TypeFloat, TypeBool
+		Required    bool     `json:"required,omitempty"`
+		Default     any      `json:"default,omitempty"`
+		Enum        []string `json:"enum,omitempty"` // 只对string类型有效
+		Description string   `json:"description,omitempty"`
+	}
+
+	// Rendered 是渲染之后的消息, Name与Version用于记录是哪个版本的prompt生成了回答
+	Rendered struct {
+		Name     string
+		Version  string
+		Messages []*chat.Message
+	}
+
+	// ValidationError 列出所有不合法的变量
+	ValidationError struct {
+		Template string
+		Problems []string
+	}
+)
+
+var placeholderPattern = regexp.MustCompile(`\{\{\s*(>?)\s*([A-Za-z_][\w.\-]*)\s*}}`)
+
+// Render 校验变量之后渲染所有消息
+func (my *Template) Render(values map[string]any) (*Rendered, error) {
+	var resolved, err1 = my.validate(values)
+	if err1 != nil {
+		return nil, err1
+	}
+
+	var rendered = &Rendered{
+		Name:     my.Name,
+		Version:  my.Version,
+		Messages: make([]*chat.Message, 0, len(my.Messages)),
+	}
+
+	for _, message := range my.Messages {
+		var content, err2 = my.expand(message.Content, resolved, 0)
+		if err2 != nil {
+			return nil, err2
+		}
+
+		rendered.Messages = append(rendered.Messages, &chat.Message{Role: message.Role, Content: content})
+	}
+
+	return rendered, nil
+}
+
+// Label 返回name@version, 方便写日志
+func (my *Rendered) Label() string {
+	return my.Name + "@" + my.Version
+}
+
+// SystemPrompt 返回第一条system消息的内容
+func (my *Rendered) SystemPrompt() string {
+	for _, message := range my.Messages {
+		if message.Role == "system" {
+			return message.Content
+		}
+	}
+
+	return ""
+}
+
+// Apply 把第一条system消息设置为thread的prompt, 其余的消息(few-shot示例, 额外的system消息)替换thread的固定区, 因此不会被历史窗口淘汰
+func (my *Rendered) Apply(thread *chat.Thread) {
+	var pinned = make([]*chat.Message, 0, len(my.Messages))
+	var hasSystem = false
+	for _, message := range my.Messages {
+		if message.Role == "system" && !hasSystem {
+			thread.SetPrompt(message.Content)
+			hasSystem = true
+			continue
+		}
+
+		pinned = append(pinned, message)
+	}
+
+	thread.SetPinnedMessages(pinned)
+}
+
+func (my *Template) expand(text string, values map[string]string, depth int) (string, error) {
+	if depth > maxPartialDepth {
+		return "", fmt.Errorf("template %s: partials are nested too deeply", my.Name)
+	}
+
+	var err error
+	var result = placeholderPattern.ReplaceAllStringFunc(text, func(match string) string {
+		var groups = placeholderPattern.FindStringSubmatch(match)
+		var name = groups[2]
+		if groups[1] == "" {
+			if value, ok := values[name]; ok {
+				return value
+			}
+
+			err = errors.Join(err, fmt.Errorf("template %s: undefined variable %s", my.Name, name))
+			return match
+		}
+
+		var partial, ok = my.registry.partial(name)
+		if !ok {
+			err = errors.Join(err, fmt.Errorf("template %s: undefined partial %s", my.Name, name))
+			return match
+		}
+
+		var expanded, err2 = my.expand(partial, values, depth+1)
+		err = errors.Join(err, err2)
+		return expanded
+	})
+
+	return result, err
+}
+
+// validate 检查类型, 填充默认值, 并把所有值转换为字符串
+func (my *Template) validate(values map[string]any) (map[string]string, error) {
+	var resolved = make(map[string]string, len(my.Variables))
+	var problems []string
+
+	for name, variable := range my.Variables {
+		var value, ok = values[name]
+		if !ok || value == nil {
+			if variable.Required {
+				problems = append(problems, name+" is required")
+				continue
+			}
+
+			value = variable.Default
+			if value == nil {
+				resolved[name] = ""
+				continue
+			}
+		}
+
+		var text, err = variable.format(value)
+		if err != nil {
+			problems = append(problems, name+": "+err.Error())
+			continue
+		}
+
+		resolved[name] = text
+	}
+
+	for name := range values {
+		if _, ok := my.Variables[name]; !ok {
+			problems = append(problems, name+" is not declared")
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return nil, &ValidationError{Template: my.Name + "@" + my.Version, Problems: problems}
+	}
+
+	return resolved, nil
+}
+
+func (my *Variable) format(value any) (string, error) {
+	switch my.Type {
+	case "", TypeString:
+		var text, ok = value.(string)
+		if !ok {
+			return "", fmt.Errorf("expected string, got %T", value)
+		}
+
+		if len(my.Enum) > 0 && !contains(my.Enum, text) {
+			return "", fmt.Errorf("%q is not one of %v", text, my.Enum)
+		}
+		return text, nil
+	case TypeInt:
+		switch v := value.(type) {
+		case int:
+			return strconv.Itoa(v), nil
+		case int32:
+			return strconv.FormatInt(int64(v), 10), nil
+		case int64:
+			return strconv.FormatInt(v, 10), nil
+		case float64: // 来自json的数字
+			if v == math.Trunc(v) {
+				return strconv.FormatInt(int64(v), 10), nil
+			}
+		}
+		return "", fmt.Errorf("expected int, got %v", value)
+	case TypeFloat:
+		switch v := value.(type) {
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		case float32:
+			return strconv.FormatFloat(float64(v), 'f', -1, 32), nil
+		case int:
+			return strconv.Itoa(v), nil
+		}
+		return "", fmt.Errorf("expected float, got %T", value)
+	case TypeBool:
+		if v, ok := value.(bool); ok {
+			return strconv.FormatBool(v), nil
+		}
+		return "", fmt.Errorf("expected bool, got %T", value)
+	default:
+		return "", fmt.Errorf("unknown type %s", my.Type)
+	}
+}
+
+func (my *ValidationError) Error() string {
+	return fmt.Sprintf("template %s: %s", my.Template, strings.Join(my.Problems, "; "))
+}
+
+func contains(list []string, item string) bool {
+	for _, v := range list {
+		if v == item {
+			return true
+		}
+	}
+
+	return false
+}
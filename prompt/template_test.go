@@ -0,0 +1,115 @@
+package prompt
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lixianmin/agi/chat"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+func loadTestRegistry(t *testing.T) *Registry {
+	var registry, err = LoadDir("testdata/prompts")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return registry
+}
+
+func TestRender(t *testing.T) {
+	var registry = loadTestRegistry(t)
+	var rendered, err = registry.Render("answer", "v1", map[string]any{"persona": "导游"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	println(rendered.Label(), rendered.SystemPrompt())
+	if rendered.SystemPrompt() != "你是导游. 回答不超过50个字, 正式语气: false." {
+		t.Fatalf("unexpected system prompt: %q", rendered.SystemPrompt())
+	}
+
+	if len(rendered.Messages) != 3 || rendered.Messages[2].Role != "assistant" {
+		t.Fatalf("few-shot messages are lost: %d", len(rendered.Messages))
+	}
+
+	// version为空时取最新版本, 且v10 > v1
+	var latest, _ = registry.Render("answer", "", map[string]any{"persona": "导游", "max_words": 30})
+	if latest.Version != "v10" || latest.SystemPrompt() != "你是导游, 回答不超过30个字." {
+		t.Fatalf("unexpected latest: %s %q", latest.Label(), latest.SystemPrompt())
+	}
+}
+
+func TestValidation(t *testing.T) {
+	var registry = loadTestRegistry(t)
+	var _, err = registry.Render("answer", "v1", map[string]any{"max_words": "many", "unknown": 1})
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+
+	println(err.Error())
+	if len(validationErr.Problems) != 3 {
+		t.Fatalf("problems=%v", validationErr.Problems)
+	}
+
+	var template = &Template{Name: "bad", Version: "v1", Messages: []*chat.Message{{Role: "system", Content: "{{missing}}"}}}
+	if NewRegistry().Add(template) == nil {
+		t.Fatal("undeclared variable should be rejected")
+	}
+}
+
+func TestPick(t *testing.T) {
+	var registry = loadTestRegistry(t)
+	var counts = map[string]int{}
+	for i := 0; i < 1000; i++ {
+		var key = "user-" + strings.Repeat("x", i%7) + string(rune('a'+i%26)) + string(rune('a'+i/26))
+		var first, _ = registry.Pick("answer", key)
+		var second, _ = registry.Pick("answer", key)
+		if first != second {
+			t.Fatalf("pick is not deterministic for %s", key)
+		}
+		counts[first.Version]++
+	}
+
+	println("v1:", counts["v1"], "v10:", counts["v10"])
+	if counts["v1"] == 0 || counts["v10"] <= counts["v1"] {
+		t.Fatalf("weights are not respected: %v", counts)
+	}
+}
+
+func TestBuiltin(t *testing.T) {
+	var registry, err = Builtin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var rendered, _ = registry.Render("english_tutor", "v1", nil)
+	var thread, _ = chat.NewThread()
+	if rendered.SystemPrompt() != thread.CloneMessages()[0].Content {
+		t.Fatalf("english_tutor@v1 should match the default prompt: %q", rendered.SystemPrompt())
+	}
+
+	var v2, err2 = registry.Render("english_tutor", "", map[string]any{"level": "beginner"})
+	if err2 != nil {
+		t.Fatal(err2)
+	}
+
+	v2.Apply(thread)
+	var messages = thread.CloneMessages()
+	if len(messages) != 3 || !strings.Contains(messages[0].Content, "quote the wrong part") {
+		t.Fatalf("unexpected thread messages: %d %q", len(messages), messages[0].Content)
+	}
+
+	if _, err3 := registry.Render("english_tutor", "v2", map[string]any{"level": "expert"}); err3 == nil {
+		t.Fatal("enum should be validated")
+	}
+}
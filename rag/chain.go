@@ -0,0 +1,296 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lixianmin/agi/chat"
+	"github.com/lixianmin/agi/textsplit"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+type (
+	// Chain 把检索结果注入到chat.Thread的最后一个问题中, 回答之后把原始问题与回答写回thread,
+	// 因此thread的历史中不会包含大段的参考资料
+	Chain struct {
+		service   chat.ChatService
+		retriever Retriever
+		reranker  Reranker
+		model     string
+		topK      int
+		budget    int
+		rewrite   bool
+		length    textsplit.LengthFunc
+	}
+
+	Answer struct {
+		Content   string      `json:"content"`
+		Query     string      `json:"query"`     // 实际用于检索的query, 开启改写时与问题不同
+		Sources   []Source    `json:"sources"`   // 注入prompt的全部资料
+		Citations []Source    `json:"citations"` // 回答中实际引用的资料
+		Usage     *chat.Usage `json:"usage,omitempty"`
+	}
+
+	chainOptions struct {
+		model    string
+		reranker Reranker
+		topK     int
+		budget   int
+		rewrite  bool
+		length   textsplit.LengthFunc
+	}
+
+	ChainOption func(*chainOptions)
+)
+
+// ErrNoSourceFits 检索到了资料, 但是没有一条能放进token预算, 此时不会只发送原始问题
+var ErrNoSourceFits = errors.New("no source fits into the budget")
+
+var citationPattern = regexp.MustCompile(`\[(\d+)]`)
+
+func WithModel(model string) ChainOption {
+	return func(options *chainOptions) {
+		options.model = model
+	}
+}
+
+func WithReranker(reranker Reranker) ChainOption {
+	return func(options *chainOptions) {
+		options.reranker = reranker
+	}
+}
+
+// WithTopK 检索的文档数, 默认为5
+func WithTopK(k int) ChainOption {
+	return func(options *chainOptions) {
+		if k > 0 {
+			options.topK = k
+		}
+	}
+}
+
+// WithBudget 注入的参考资料最多占用的token数, 默认为2000; 放不下的资料被跳过, 排在后面的较短资料仍然可以注入.
+// 一条资料都放不下时返回ErrNoSourceFits
+func WithBudget(budget int) ChainOption {
+	return func(options *chainOptions) {
+		if budget > 0 {
+			options.budget = budget
+		}
+	}
+}
+
+// WithRewrite 有对话历史时, 先让模型把问题改写为独立的query再检索
+func WithRewrite(rewrite bool) ChainOption {
+	return func(options *chainOptions) {
+		options.rewrite = rewrite
+	}
+}
+
+// WithLengthFunc 计算token数的方法, 默认为textsplit.EstimateTokens
+func WithLengthFunc(length textsplit.LengthFunc) ChainOption {
+	return func(options *chainOptions) {
+		if length != nil {
+			options.length = length
+		}
+	}
+}
+
+func NewChain(service chat.ChatService, retriever Retriever, opts ...ChainOption) *Chain {
+	// 默认值
+	var options = chainOptions{
+		topK:   5,
+		budget: 2000,
+		length: textsplit.EstimateTokens,
+	}
+
+	// 初始化
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return &Chain{
+		service:   service,
+		retriever: retriever,
+		reranker:  options.reranker,
+		model:     options.model,
+		topK:      options.topK,
+		budget:    options.budget,
+		rewrite:   options.rewrite,
+		length:    options.length,
+	}
+}
+
+func (my *Chain) Ask(ctx context.Context, thread *chat.Thread, question string) (*Answer, error) {
+	var request, answer, err1 = my.prepare(ctx, thread, question)
+	if err1 != nil {
+		return nil, err1
+	}
+
+	var response, err2 = my.service.Chat(ctx, request)
+	if err2 != nil {
+		return nil, err2
+	}
+
+	answer.Usage = response.Usage
+	return my.finish(thread, question, response, answer), nil
+}
+
+// StreamAsk 与Ask相同, 但是把回答通过fn流式返回
+func (my *Chain) StreamAsk(ctx context.Context, thread *chat.Thread, question string, fn chat.ResponseFunc) (*Answer, error) {
+	if fn == nil {
+		return nil, errors.New("fn is nil")
+	}
+
+	var request, answer, err1 = my.prepare(ctx, thread, question)
+	if err1 != nil {
+		return nil, err1
+	}
+
+	var accumulator = chat.NewAccumulator(nil)
+	var err2 = my.service.StreamChat(ctx, request, func(response *chat.Response) error {
+		_ = accumulator.Add(response)
+		return fn(response)
+	})
+
+	if err2 != nil {
+		return nil, err2
+	}
+
+	var response = accumulator.Response()
+	answer.Usage = response.Usage
+	return my.finish(thread, question, response, answer), nil
+}
+
+func (my *Chain) prepare(ctx context.Context, thread *chat.Thread, question string) (*chat.Request, *Answer, error) {
+	if thread == nil {
+		return nil, nil, errors.New("thread is nil")
+	}
+
+	if question == "" {
+		return nil, nil, errors.New("question is empty")
+	}
+
+	// 改写只参考实时对话, 固定区的few-shot示例与当前问题无关
+	var query, err1 = my.rewriteQuery(ctx, thread.HistoryMessages(), question)
+	if err1 != nil {
+		return nil, nil, err1
+	}
+
+	var sources, err2 = my.retriever.Retrieve(ctx, query, my.topK)
+	if err2 != nil {
+		return nil, nil, err2
+	}
+
+	if my.reranker != nil && len(sources) > 0 {
+		if sources, err2 = my.reranker.Rerank(ctx, query, sources); err2 != nil {
+			return nil, nil, err2
+		}
+	}
+
+	// 在token预算之内按顺序注入, 编号从1开始
+	var builder strings.Builder
+	builder.WriteString(contextPrompt)
+	var used = 0
+	var injected = make([]Source, 0, len(sources))
+	for _, source := range sources {
+		source.Marker = len(injected) + 1
+		var text = formatSource(source)
+		var size = my.length(text)
+		if used+size > my.budget {
+			continue
+		}
+
+		used += size
+		injected = append(injected, source)
+		builder.WriteString(text)
+	}
+
+	if len(injected) == 0 && len(sources) > 0 {
+		return nil, nil, fmt.Errorf("%w: %d sources, budget=%d", ErrNoSourceFits, len(sources), my.budget)
+	}
+
+	builder.WriteString("\n问题: ")
+	builder.WriteString(question)
+
+	var content = question
+	if len(injected) > 0 {
+		content = builder.String()
+	}
+
+	// 与Thread.NewRequest一样带上thread的采样参数
+	var request = thread.NewRequest(my.model)
+	request.Messages = append(request.Messages, &chat.Message{Role: "user", Content: content})
+	var answer = &Answer{Query: query, Sources: injected}
+	return request, answer, nil
+}
+
+func (my *Chain) rewriteQuery(ctx context.Context, messages []*chat.Message, question string) (string, error) {
+	var hasHistory = false
+	for _, message := range messages {
+		if message.Role != "system" {
+			hasHistory = true
+			break
+		}
+	}
+
+	if !my.rewrite || !hasHistory {
+		return question, nil
+	}
+
+	var request = &chat.Request{
+		Model: my.model,
+		Messages: []*chat.Message{
+			{Role: "system", Content: rewritePrompt},
+			{Role: "user", Content: formatHistory(messages, question)},
+		},
+	}
+
+	var response, err = my.service.Chat(ctx, request)
+	if err != nil {
+		return "", err
+	}
+
+	var query = strings.TrimSpace(response.Message.Content)
+	if query == "" {
+		return question, nil
+	}
+
+	return query, nil
+}
+
+func (my *Chain) finish(thread *chat.Thread, question string, response *chat.Response, answer *Answer) *Answer {
+	var content = response.Message.Content
+	answer.Content = content
+	answer.Citations = citedSources(content, answer.Sources)
+
+	thread.AddUserMessage(question)
+	thread.AddResponse(response)
+	return answer
+}
+
+// citedSources 按在回答中第一次出现的顺序返回被引用的资料
+func citedSources(content string, sources []Source) []Source {
+	var citations []Source
+	var seen = make(map[int]bool)
+	for _, match := range citationPattern.FindAllStringSubmatch(content, -1) {
+		var marker, _ = strconv.Atoi(match[1])
+		if marker < 1 || marker > len(sources) || seen[marker] {
+			continue
+		}
+
+		seen[marker] = true
+		citations = append(citations, sources[marker-1])
+	}
+
+	return citations
+}
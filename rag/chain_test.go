@@ -0,0 +1,123 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lixianmin/agi/agitest"
+	"github.com/lixianmin/agi/chat"
+	"github.com/lixianmin/agi/deepseek"
+	"github.com/lixianmin/agi/siliconflow"
+	"github.com/lixianmin/agi/textsplit"
+	"github.com/lixianmin/agi/vector"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+func TestChain(t *testing.T) {
+	var server = agitest.NewTestServer(t)
+	server.SetChatHandler(func(request *agitest.ChatRequest) agitest.Reply {
+		var last = request.LastUserMessage()
+		switch {
+		case strings.HasPrefix(request.Messages[0].Content, "根据下面的对话历史"):
+			return agitest.Reply{Content: "agi如何安装?"}
+		case strings.Contains(last, "参考资料") && strings.Contains(last, "go get"):
+			// 引用安装那一节的编号, 再引用一个不存在的编号
+			var index = strings.Index(last, "(agi > 安装)")
+			var marker = last[index-3 : index-2]
+			return agitest.Reply{Content: "使用go get安装[" + marker + "], 详见[9]."}
+		default:
+			return agitest.Reply{Content: "不知道"}
+		}
+	})
+
+	var siliconClient = siliconflow.NewSiliconClient("sk-test", siliconflow.WithBaseUrl(server.BaseUrl()))
+	var embedder = siliconflow.NewEmbedder(siliconClient, "BAAI/bge-m3")
+	var store = vector.NewStore(64)
+
+	var markdown = "# agi\nagi是一个golang库。\n\n## 安装\n使用go get github.com/lixianmin/agi安装。\n\n## 许可\n版权所有。\n"
+	var chunks = textsplit.NewSplitter().Split(&textsplit.Document{Source: "README.md", Type: textsplit.TypeMarkdown, Content: markdown})
+	for _, chunk := range chunks {
+		var err = store.UpsertTexts(context.Background(), embedder, &vector.Document{ID: chunk.ID, Content: chunk.Content, Metadata: chunk.Metadata})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var service = deepseek.NewChatService(deepseek.NewDeepSeekClient("sk-test", deepseek.WithBaseUrl(server.BaseUrl())))
+	var chain = NewChain(service, NewVectorRetriever(store, embedder, nil), WithModel("deepseek-chat"), WithTopK(3), WithRewrite(true))
+
+	var thread, _ = chat.NewThread(chat.WithPrompt("你是agi的文档助手"), chat.WithTemperature(0.2), chat.WithMaxTokens(512))
+	thread.AddUserMessage("agi是什么?")
+	thread.AddBotMessage("一个golang库")
+
+	var answer, err = chain.Ask(context.Background(), thread, "怎么安装它?")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if answer.Query != "agi如何安装?" || len(answer.Sources) != 3 || len(answer.Citations) != 1 {
+		t.Fatalf("answer=%+v", answer)
+	}
+
+	if !strings.Contains(answer.Citations[0].Content, "go get") {
+		t.Fatalf("citations=%+v", answer.Citations)
+	}
+
+	// 回答问题的请求带上了thread的采样参数
+	if last := server.LastChatRequest(); last.Temperature == nil || *last.Temperature != 0.2 || last.MaxTokens != 512 {
+		t.Fatalf("thread params are dropped: %+v", last)
+	}
+
+	// thread中只保存原始的问题, 不包含参考资料
+	var messages = thread.CloneMessages()
+	if last := messages[len(messages)-2]; last.Content != "怎么安装它?" {
+		t.Fatalf("last=%+v", last)
+	}
+
+	// 预算只够放下一条资料
+	chain = NewChain(service, NewVectorRetriever(store, embedder, nil), WithModel("deepseek-chat"), WithBudget(20))
+	var text string
+	var other, _ = chat.NewThread()
+	answer, err = chain.StreamAsk(context.Background(), other, "怎么安装agi?", func(response *chat.Response) error {
+		text += response.Message.Content
+		return nil
+	})
+
+	if err != nil || len(answer.Sources) != 1 || answer.Content != text {
+		t.Fatalf("answer=%+v, err=%v", answer, err)
+	}
+}
+
+func TestBudget(t *testing.T) {
+	var server = agitest.NewTestServer(t)
+	var service = deepseek.NewChatService(deepseek.NewDeepSeekClient("sk-test", deepseek.WithBaseUrl(server.BaseUrl())))
+	var retriever = RetrieverFunc(func(ctx context.Context, query string, k int) ([]Source, error) {
+		return []Source{
+			{ID: "long", Content: strings.Repeat("很长的资料", 100)},
+			{ID: "short", Content: "短资料"},
+		}, nil
+	})
+
+	// 排在前面的资料超出预算时被跳过, 后面较短的资料仍然注入, 编号从1开始
+	var chain = NewChain(service, retriever, WithModel("deepseek-chat"), WithBudget(30))
+	var thread, _ = chat.NewThread()
+	var answer, err = chain.Ask(context.Background(), thread, "问题")
+	if err != nil || len(answer.Sources) != 1 || answer.Sources[0].ID != "short" || answer.Sources[0].Marker != 1 {
+		t.Fatalf("answer=%+v, err=%v", answer, err)
+	}
+
+	// 一条都放不下时返回错误, 而不是只发送原始问题
+	chain = NewChain(service, retriever, WithModel("deepseek-chat"), WithBudget(1))
+	if _, err = chain.Ask(context.Background(), thread, "问题"); !errors.Is(err, ErrNoSourceFits) {
+		t.Fatalf("err=%v", err)
+	}
+	server.AssertRequestCount(t, agitest.PathChat, 1)
+}
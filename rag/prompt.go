@@ -0,0 +1,64 @@
+package rag
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/lixianmin/agi/chat"
+	"github.com/lixianmin/agi/textsplit"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+const (
+	rewritePrompt = "根据下面的对话历史, 把用户最后的问题改写为一个不依赖上下文, 可以独立用于检索的问题. 只输出改写之后的问题, 不要回答它."
+
+	contextPrompt = "请根据下面编号的参考资料回答问题. 引用资料时在句子后面用方括号标注编号, 比如[1]; 如果资料中没有答案, 请直接说不知道, 不要编造.\n\n参考资料:\n"
+)
+
+// formatSource 生成一条带编号的参考资料, 有标题路径时写在前面方便模型理解上下文
+func formatSource(source Source) string {
+	var builder strings.Builder
+	builder.WriteString("[")
+	builder.WriteString(strconv.Itoa(source.Marker))
+	builder.WriteString("] ")
+
+	var title = source.Metadata[textsplit.MetaHeadings]
+	if title == "" {
+		title = source.Metadata[textsplit.MetaSource]
+	}
+
+	if title != "" {
+		builder.WriteString("(")
+		builder.WriteString(title)
+		builder.WriteString(") ")
+	}
+
+	builder.WriteString(source.Content)
+	builder.WriteString("\n")
+	return builder.String()
+}
+
+// formatHistory 把对话历史格式化为改写query时使用的纯文本
+func formatHistory(messages []*chat.Message, question string) string {
+	var builder strings.Builder
+	for _, message := range messages {
+		if message.Role == "system" {
+			continue
+		}
+
+		builder.WriteString(message.Role)
+		builder.WriteString(": ")
+		builder.WriteString(message.Content)
+		builder.WriteString("\n")
+	}
+
+	builder.WriteString("user: ")
+	builder.WriteString(question)
+	return builder.String()
+}
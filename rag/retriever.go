@@ -0,0 +1,77 @@
+package rag
+
+import (
+	"context"
+
+	"github.com/lixianmin/agi/ifs"
+	"github.com/lixianmin/agi/vector"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+type (
+	// Source 是检索到的一个文档片段
+	Source struct {
+		ID       string            `json:"id"`
+		Content  string            `json:"content"`
+		Metadata map[string]string `json:"metadata,omitempty"`
+		Score    float32           `json:"score"`
+		Marker   int               `json:"marker,omitempty"` // 注入prompt时的引用编号, 从1开始
+	}
+
+	Retriever interface {
+		Retrieve(ctx context.Context, query string, k int) ([]Source, error)
+	}
+
+	// Reranker 对检索结果重新排序, 可以只返回其中的一部分
+	Reranker interface {
+		Rerank(ctx context.Context, query string, sources []Source) ([]Source, error)
+	}
+
+	RetrieverFunc func(ctx context.Context, query string, k int) ([]Source, error)
+	RerankerFunc  func(ctx context.Context, query string, sources []Source) ([]Source, error)
+
+	// VectorRetriever 使用vector.Store与embedder检索
+	VectorRetriever struct {
+		store    *vector.Store
+		embedder ifs.Embedder
+		filter   vector.Filter
+	}
+)
+
+func (fn RetrieverFunc) Retrieve(ctx context.Context, query string, k int) ([]Source, error) {
+	return fn(ctx, query, k)
+}
+
+func (fn RerankerFunc) Rerank(ctx context.Context, query string, sources []Source) ([]Source, error) {
+	return fn(ctx, query, sources)
+}
+
+// NewVectorRetriever filter可以为nil
+func NewVectorRetriever(store *vector.Store, embedder ifs.Embedder, filter vector.Filter) *VectorRetriever {
+	return &VectorRetriever{store: store, embedder: embedder, filter: filter}
+}
+
+func (my *VectorRetriever) Retrieve(ctx context.Context, query string, k int) ([]Source, error) {
+	var results, err = my.store.SearchText(ctx, my.embedder, query, k, my.filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var sources = make([]Source, len(results))
+	for i, result := range results {
+		sources[i] = Source{
+			ID:       result.Document.ID,
+			Content:  result.Document.Content,
+			Metadata: result.Document.Metadata,
+			Score:    result.Score,
+		}
+	}
+
+	return sources, nil
+}
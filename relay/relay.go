@@ -0,0 +1,147 @@
+package relay
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/lixianmin/agi/chat"
+	"github.com/lixianmin/agi/session"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+const (
+	EventChunk = "chunk" // 一段增量文本
+	EventUsage = "usage" // 本轮的token用量, 在done之前发送
+	EventDone  = "done"  // 本轮结束, 回答已经加入thread
+	EventError = "error"
+)
+
+type (
+	// Request 是浏览器发来的一轮对话
+	Request struct {
+		ThreadID string `json:"thread_id"`
+		Message  string `json:"message"`
+		Model    string `json:"model,omitempty"` // 为空时使用WithModel设置的默认模型
+	}
+
+	// Event 是推送给浏览器的事件, SSE中作为data, WebSocket中作为一条text消息
+	Event struct {
+		Type         string      `json:"type"`
+		Content      string      `json:"content,omitempty"`
+		Usage        *chat.Usage `json:"usage,omitempty"`
+		FinishReason string      `json:"finish_reason,omitempty"`
+		MessageID    string      `json:"message_id,omitempty"` // done事件中回答在thread中的ID
+		Error        string      `json:"error,omitempty"`
+	}
+
+	relayOptions struct {
+		model     string
+		heartbeat time.Duration
+		sessions  *session.Manager
+	}
+
+	RelayOption func(*relayOptions)
+
+	// relay 是SSE与WebSocket共用的逻辑: 在session上串行执行一轮对话, 并把chunk转换为Event
+	relay struct {
+		service   chat.ChatService
+		model     string
+		heartbeat time.Duration
+		sessions  *session.Manager
+	}
+)
+
+// WithModel 请求中没有指定模型时使用的默认模型
+func WithModel(model string) RelayOption {
+	return func(options *relayOptions) {
+		options.model = model
+	}
+}
+
+// WithHeartbeat 心跳间隔, 避免代理因为空闲断开连接, 默认为15秒
+func WithHeartbeat(interval time.Duration) RelayOption {
+	return func(options *relayOptions) {
+		if interval > 0 {
+			options.heartbeat = interval
+		}
+	}
+}
+
+// WithSessions 共享的session管理器, 默认每个handler创建一个自己的
+func WithSessions(sessions *session.Manager) RelayOption {
+	return func(options *relayOptions) {
+		if sessions != nil {
+			options.sessions = sessions
+		}
+	}
+}
+
+func newRelay(service chat.ChatService, opts []RelayOption) *relay {
+	// 默认值
+	var options = relayOptions{
+		heartbeat: 15 * time.Second,
+	}
+
+	// 初始化
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if options.sessions == nil {
+		options.sessions = session.NewManager()
+	}
+
+	return &relay{
+		service:   service,
+		model:     options.model,
+		heartbeat: options.heartbeat,
+		sessions:  options.sessions,
+	}
+}
+
+// serveTurn ctx取消(比如浏览器断开)时上游请求也会被取消, 失败时撤回本轮的问题, 保证thread中不会留下没有回答的问题
+func (my *relay) serveTurn(ctx context.Context, request *Request, emit func(event *Event) error) error {
+	if request.ThreadID == "" || request.Message == "" {
+		return errors.New("thread_id and message are required")
+	}
+
+	var model = request.Model
+	if model == "" {
+		model = my.model
+	}
+
+	return my.sessions.Do(ctx, request.ThreadID, func(thread *chat.Thread) error {
+		var questionId = thread.AddUserMessage(request.Message)
+		var accumulator = chat.NewAccumulator(nil)
+		var err = my.service.StreamChat(ctx, thread.NewRequest(model), func(response *chat.Response) error {
+			_ = accumulator.Add(response)
+			if response.Message.Content == "" {
+				return nil
+			}
+
+			return emit(&Event{Type: EventChunk, Content: response.Message.Content})
+		})
+
+		if err != nil {
+			_ = thread.Truncate(questionId)
+			return err
+		}
+
+		var response = accumulator.Response()
+		var answerId = thread.AddResponse(response)
+		if response.Usage != nil {
+			if err2 := emit(&Event{Type: EventUsage, Usage: response.Usage}); err2 != nil {
+				return err2
+			}
+		}
+
+		return emit(&Event{Type: EventDone, FinishReason: response.FinishReason, MessageID: answerId})
+	})
+}
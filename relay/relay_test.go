@@ -0,0 +1,178 @@
+package relay
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lixianmin/agi/agitest"
+	"github.com/lixianmin/agi/chat"
+	"github.com/lixianmin/agi/deepseek"
+	"github.com/lixianmin/agi/session"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+func newService(t *testing.T) chat.ChatService {
+	var server = agitest.NewTestServer(t)
+	server.SetDefaultReply(agitest.Reply{Content: "你好, 有什么可以帮你?"})
+	return deepseek.NewChatService(deepseek.NewDeepSeekClient("sk-test", deepseek.WithBaseUrl(server.BaseUrl())))
+}
+
+func TestSSE(t *testing.T) {
+	var sessions = session.NewManager()
+	defer sessions.Close(context.Background())
+
+	var server = httptest.NewServer(NewSSEHandler(newService(t), WithModel("deepseek-chat"), WithSessions(sessions)))
+	defer server.Close()
+
+	var response, err = http.Get(server.URL + "?thread_id=t1&message=" + url.QueryEscape("你好"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+
+	var events []string
+	var content string
+	var scanner = bufio.NewScanner(response.Body)
+	for scanner.Scan() {
+		var line = scanner.Text()
+		println(line)
+		if strings.HasPrefix(line, "data: ") {
+			var event Event
+			_ = json.Unmarshal([]byte(line[6:]), &event)
+			events = append(events, event.Type)
+			content += event.Content
+		}
+	}
+
+	if events[len(events)-1] != EventDone || events[len(events)-2] != EventUsage || content != "你好, 有什么可以帮你?" {
+		t.Fatalf("events=%v, content=%q", events, content)
+	}
+
+	var thread, release, _ = sessions.Get(context.Background(), "t1")
+	defer release()
+	if history := thread.HistoryMessages(); len(history) != 2 || history[1].Content != content {
+		t.Fatalf("answer should be appended to the thread: %d", len(history))
+	}
+}
+
+// blockingService 一直阻塞到ctx被取消, 用于验证断开连接会取消上游
+type blockingService struct {
+	cancelled chan struct{}
+}
+
+func (my *blockingService) Chat(ctx context.Context, request *chat.Request) (*chat.Response, error) {
+	return nil, nil
+}
+
+func (my *blockingService) StreamChat(ctx context.Context, request *chat.Request, fn chat.ResponseFunc) error {
+	if err := fn(&chat.Response{Message: chat.Message{Content: "..."}}); err != nil {
+		return err
+	}
+
+	<-ctx.Done()
+	close(my.cancelled)
+	return ctx.Err()
+}
+
+func TestSSEDisconnect(t *testing.T) {
+	var service = &blockingService{cancelled: make(chan struct{})}
+	var sessions = session.NewManager()
+	defer sessions.Close(context.Background())
+
+	var server = httptest.NewServer(NewSSEHandler(service, WithHeartbeat(10*time.Millisecond), WithSessions(sessions)))
+	defer server.Close()
+
+	var response, err = http.Post(server.URL, "application/json", strings.NewReader(`{"thread_id":"t2","message":"hi"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 读到第一个chunk与心跳之后断开
+	var reader = bufio.NewReader(response.Body)
+	for heartbeat := false; !heartbeat; {
+		var line, _ = reader.ReadString('\n')
+		heartbeat = strings.HasPrefix(line, ": ping")
+	}
+	_ = response.Body.Close()
+
+	select {
+	case <-service.cancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("upstream should be cancelled after the client disconnects")
+	}
+
+	// 失败的一轮不会在thread中留下问题
+	time.Sleep(20 * time.Millisecond)
+	var thread, release, _ = sessions.Get(context.Background(), "t2")
+	defer release()
+	if len(thread.HistoryMessages()) != 0 {
+		t.Fatal("question should be withdrawn")
+	}
+}
+
+func TestWebSocket(t *testing.T) {
+	var server = httptest.NewServer(NewWebSocketHandler(newService(t), WithModel("deepseek-chat")))
+	defer server.Close()
+
+	var netConn, err = net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const key = "dGhlIHNhbXBsZSBub25jZQ=="
+	_, _ = netConn.Write([]byte("GET / HTTP/1.1\r\nHost: localhost\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Key: " + key + "\r\nSec-WebSocket-Version: 13\r\n\r\n"))
+
+	var reader = bufio.NewReader(netConn)
+	var handshake, _ = http.ReadResponse(reader, nil)
+	if handshake.StatusCode != http.StatusSwitchingProtocols || handshake.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("handshake failed: %d", handshake.StatusCode)
+	}
+
+	var conn = newWsConn(netConn, reader, true)
+	defer conn.Close()
+
+	// 同一条连接上进行两轮对话
+	for round := 0; round < 2; round++ {
+		_ = conn.writeFrame(opText, []byte(`{"thread_id":"ws","message":"hi"}`))
+
+		var content string
+		for {
+			var message, err2 = conn.readMessage()
+			if err2 != nil {
+				t.Fatal(err2)
+			}
+
+			var event Event
+			_ = json.Unmarshal(message, &event)
+			content += event.Content
+			if event.Type == EventDone {
+				if event.MessageID == "" {
+					t.Fatal("done event should carry the message id")
+				}
+				break
+			}
+
+			if event.Type == EventError {
+				t.Fatal(event.Error)
+			}
+		}
+
+		if content != "你好, 有什么可以帮你?" {
+			t.Fatalf("content=%q", content)
+		}
+	}
+}
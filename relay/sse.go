@@ -0,0 +1,131 @@
+package relay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/lixianmin/agi/chat"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+// SSEHandler 把一轮对话的chunk以Server-Sent Events推送给浏览器.
+// 支持POST json(Request)与GET查询参数(thread_id, message, model), 后者可以直接配合浏览器的EventSource使用
+type SSEHandler struct {
+	relay *relay
+}
+
+func NewSSEHandler(service chat.ChatService, opts ...RelayOption) *SSEHandler {
+	return &SSEHandler{relay: newRelay(service, opts)}
+}
+
+func (my *SSEHandler) ServeHTTP(writer http.ResponseWriter, r *http.Request) {
+	var request, err1 = parseRequest(r)
+	if err1 != nil {
+		http.Error(writer, err1.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var flusher, ok = writer.(http.Flusher)
+	if !ok {
+		http.Error(writer, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	var header = writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no") // 禁止nginx缓冲
+	writer.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// 浏览器断开时r.Context()被取消, 进而取消上游请求
+	var ctx, cancel = context.WithCancel(r.Context())
+	defer cancel()
+
+	var m sync.Mutex
+	var write = func(text string) error {
+		m.Lock()
+		defer m.Unlock()
+
+		if _, err := fmt.Fprint(writer, text); err != nil {
+			cancel()
+			return err
+		}
+
+		flusher.Flush()
+		return nil
+	}
+
+	var emit = func(event *Event) error {
+		var data, _ = json.Marshal(event)
+		return write("event: " + event.Type + "\ndata: " + string(data) + "\n\n")
+	}
+
+	// handler返回之后不能再写writer, 因此需要等待心跳goroutine退出
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		goHeartbeat(ctx, my.relay.heartbeat, func() error {
+			return write(": ping\n\n")
+		})
+	}()
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	if err2 := my.relay.serveTurn(ctx, request, emit); err2 != nil && ctx.Err() == nil {
+		_ = emit(&Event{Type: EventError, Error: err2.Error()})
+	}
+}
+
+func parseRequest(r *http.Request) (*Request, error) {
+	var request Request
+	switch r.Method {
+	case http.MethodGet:
+		var query = r.URL.Query()
+		request.ThreadID = query.Get("thread_id")
+		request.Message = query.Get("message")
+		request.Model = query.Get("model")
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("method %s is not allowed", r.Method)
+	}
+
+	if request.ThreadID == "" || request.Message == "" {
+		return nil, fmt.Errorf("thread_id and message are required")
+	}
+
+	return &request, nil
+}
+
+func goHeartbeat(ctx context.Context, interval time.Duration, beat func() error) {
+	var ticker = time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if beat() != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package relay
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/lixianmin/agi/chat"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+// WebSocketHandler 与SSEHandler相同, 但是在一条websocket连接上可以进行多轮对话:
+// 浏览器每发送一条json(Request), 服务端就推送这一轮的chunk/usage/done事件, 每个事件是一条text消息
+type WebSocketHandler struct {
+	relay *relay
+}
+
+func NewWebSocketHandler(service chat.ChatService, opts ...RelayOption) *WebSocketHandler {
+	return &WebSocketHandler{relay: newRelay(service, opts)}
+}
+
+func (my *WebSocketHandler) ServeHTTP(writer http.ResponseWriter, r *http.Request) {
+	var key = r.Header.Get("Sec-WebSocket-Key")
+	if r.Method != http.MethodGet || key == "" || !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") {
+		http.Error(writer, "websocket upgrade required", http.StatusBadRequest)
+		return
+	}
+
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		writer.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(writer, "unsupported websocket version", http.StatusUpgradeRequired)
+		return
+	}
+
+	var hijacker, ok = writer.(http.Hijacker)
+	if !ok {
+		http.Error(writer, "websocket unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	var netConn, buffer, err1 = hijacker.Hijack()
+	if err1 != nil {
+		return
+	}
+
+	var response = "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: " + acceptKey(key) + "\r\n\r\n"
+	if _, err2 := buffer.WriteString(response); err2 != nil {
+		_ = netConn.Close()
+		return
+	}
+
+	if err3 := buffer.Flush(); err3 != nil {
+		_ = netConn.Close()
+		return
+	}
+
+	var conn = newWsConn(netConn, buffer.Reader, false)
+	my.serveConn(conn)
+}
+
+func (my *WebSocketHandler) serveConn(conn *wsConn) {
+	// 连接断开时cancel, 进而取消正在进行的上游请求
+	var ctx, cancel = context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		_ = conn.Close()
+		wg.Wait()
+	}()
+
+	var requests = make(chan []byte)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		defer cancel()
+		for {
+			var message, err = conn.readMessage()
+			if err != nil {
+				return
+			}
+
+			select {
+			case requests <- message:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		goHeartbeat(ctx, my.relay.heartbeat, func() error {
+			return conn.writeFrame(opPing, nil)
+		})
+	}()
+
+	var emit = func(event *Event) error {
+		var data, _ = json.Marshal(event)
+		return conn.writeFrame(opText, data)
+	}
+
+	for {
+		select {
+		case message := <-requests:
+			var request Request
+			if err := json.Unmarshal(message, &request); err != nil {
+				_ = emit(&Event{Type: EventError, Error: err.Error()})
+				continue
+			}
+
+			if err := my.relay.serveTurn(ctx, &request, emit); err != nil && ctx.Err() == nil {
+				_ = emit(&Event{Type: EventError, Error: err.Error()})
+			}
+		case <-ctx.Done():
+			_ = conn.writeFrame(opClose, nil)
+			return
+		}
+	}
+}
+
+func headerContains(header http.Header, name string, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, item := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(item), token) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
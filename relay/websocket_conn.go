@@ -0,0 +1,181 @@
+package relay
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+// 按RFC 6455实现的最小websocket连接, 只支持text消息, 不支持扩展(比如permessage-deflate)
+
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+
+	websocketGuid       = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	maxWebSocketMessage = 1024 * 1024
+)
+
+var errMessageTooLarge = errors.New("websocket message too large")
+
+type wsConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	client bool // 客户端发送的帧必须mask, 服务端发送的帧不能mask
+	m      sync.Mutex
+}
+
+func newWsConn(conn net.Conn, reader *bufio.Reader, client bool) *wsConn {
+	return &wsConn{conn: conn, reader: reader, client: client}
+}
+
+// acceptKey 计算握手响应中的Sec-WebSocket-Accept
+func acceptKey(key string) string {
+	var hash = sha1.Sum([]byte(key + websocketGuid))
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+// readMessage 返回下一条完整的text/binary消息, 期间自动回复ping; 收到close时回复close并返回io.EOF
+func (my *wsConn) readMessage() ([]byte, error) {
+	var message []byte
+	for {
+		var fin, opcode, payload, err = my.readFrame()
+		if err != nil {
+			return nil, err
+		}
+
+		switch opcode {
+		case opPing:
+			if err2 := my.writeFrame(opPong, payload); err2 != nil {
+				return nil, err2
+			}
+		case opPong:
+		case opClose:
+			_ = my.writeFrame(opClose, payload)
+			return nil, io.EOF
+		case opText, opBinary, opContinuation:
+			message = append(message, payload...)
+			if len(message) > maxWebSocketMessage {
+				return nil, errMessageTooLarge
+			}
+
+			if fin {
+				return message, nil
+			}
+		default:
+			return nil, errors.New("unknown websocket opcode")
+		}
+	}
+}
+
+func (my *wsConn) readFrame() (bool, byte, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(my.reader, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+
+	var fin = header[0]&0x80 != 0
+	var opcode = header[0] & 0x0F
+	var masked = header[1]&0x80 != 0
+	var length = uint64(header[1] & 0x7F)
+
+	switch length {
+	case 126:
+		var extended [2]byte
+		if _, err := io.ReadFull(my.reader, extended[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		if _, err := io.ReadFull(my.reader, extended[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(extended[:])
+	}
+
+	if length > maxWebSocketMessage {
+		return false, 0, nil, errMessageTooLarge
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(my.reader, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+
+	var payload = make([]byte, length)
+	if _, err := io.ReadFull(my.reader, payload); err != nil {
+		return false, 0, nil, err
+	}
+
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+
+	return fin, opcode, payload, nil
+}
+
+// writeFrame 线程安全, 每条消息作为一个完整的帧发送
+func (my *wsConn) writeFrame(opcode byte, payload []byte) error {
+	var frame = make([]byte, 0, len(payload)+14)
+	frame = append(frame, 0x80|opcode)
+
+	var maskBit byte = 0
+	if my.client {
+		maskBit = 0x80
+	}
+
+	var length = len(payload)
+	switch {
+	case length < 126:
+		frame = append(frame, maskBit|byte(length))
+	case length <= 0xFFFF:
+		frame = append(frame, maskBit|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(length))
+	default:
+		frame = append(frame, maskBit|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(length))
+	}
+
+	if my.client {
+		var mask [4]byte
+		_, _ = rand.Read(mask[:])
+		frame = append(frame, mask[:]...)
+		for i, b := range payload {
+			frame = append(frame, b^mask[i%4])
+		}
+	} else {
+		frame = append(frame, payload...)
+	}
+
+	my.m.Lock()
+	defer my.m.Unlock()
+
+	var _, err = my.conn.Write(frame)
+	return err
+}
+
+func (my *wsConn) Close() error {
+	return my.conn.Close()
+}
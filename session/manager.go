@@ -0,0 +1,510 @@
+package session
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/lixianmin/agi/chat"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+// ErrClosed Close之后不能再获取session, 否则新的session既不会被淘汰也不会被保存
+var ErrClosed = errors.New("session manager is closed")
+
+type (
+	// Manager 按session id管理chat.Thread, 空闲超过ttl或者数量超过capacity时淘汰最久未使用的session.
+	// 配置了ThreadStore时, 被淘汰的session会先保存下来, 之后再次访问时恢复
+	Manager struct {
+		ttl           time.Duration
+		capacity      int
+		store         ThreadStore
+		threadOptions []chat.ThreadOption
+
+		sessions map[string]*session
+		spilling map[string]*session // 正在写入store的session, 此时再次访问可以直接复活
+		lru      *list.List          // 队首是最近使用的session
+		stats    Stats
+		m        sync.Mutex
+
+		closed    bool
+		closeChan chan struct{}
+		closeOnce sync.Once
+		idleChan  chan struct{} // 有session的refs降为0时通知Close
+	}
+
+	// Stats 用于监控
+	Stats struct {
+		Active      int   // 当前在内存中的session数
+		Busy        int   // 正在进行对话的session数
+		Created     int64 // 新建的session数
+		Loaded      int64 // 从store恢复的session数
+		Evicted     int64 // 被淘汰的session数
+		Spilled     int64 // 被淘汰时成功写入store的session数
+		SpillErrors int64
+	}
+
+	session struct {
+		id         string
+		thread     *chat.Thread
+		lastAccess time.Time
+		refs       int           // 正在使用的调用数, 大于0时不会被淘汰
+		deleted    bool          // 已经被Delete, 正在写入store的副本需要在写完之后删除
+		turn       chan struct{} // 容量为1, 用于串行化同一个session上的对话, 同时支持ctx取消
+		element    *list.Element
+	}
+
+	managerOptions struct {
+		ttl             time.Duration
+		capacity        int
+		store           ThreadStore
+		threadOptions   []chat.ThreadOption
+		cleanupInterval time.Duration
+	}
+
+	ManagerOption func(*managerOptions)
+)
+
+// WithTTL session空闲超过ttl后被淘汰, 默认为30分钟
+func WithTTL(ttl time.Duration) ManagerOption {
+	return func(options *managerOptions) {
+		if ttl > 0 {
+			options.ttl = ttl
+		}
+	}
+}
+
+// WithCapacity 内存中最多保留的session数, 默认为10000
+func WithCapacity(capacity int) ManagerOption {
+	return func(options *managerOptions) {
+		if capacity > 0 {
+			options.capacity = capacity
+		}
+	}
+}
+
+// WithStore 被淘汰的session写入store, 默认直接丢弃
+func WithStore(store ThreadStore) ManagerOption {
+	return func(options *managerOptions) {
+		options.store = store
+	}
+}
+
+// WithThreadOptions 新建session时使用的thread选项
+func WithThreadOptions(opts ...chat.ThreadOption) ManagerOption {
+	return func(options *managerOptions) {
+		options.threadOptions = append(options.threadOptions, opts...)
+	}
+}
+
+// WithCleanupInterval 后台检查ttl的间隔, 默认为1分钟
+func WithCleanupInterval(interval time.Duration) ManagerOption {
+	return func(options *managerOptions) {
+		if interval > 0 {
+			options.cleanupInterval = interval
+		}
+	}
+}
+
+func NewManager(opts ...ManagerOption) *Manager {
+	// 默认值
+	var options = managerOptions{
+		ttl:             30 * time.Minute,
+		capacity:        10000,
+		cleanupInterval: time.Minute,
+	}
+
+	// 初始化
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	var manager = &Manager{
+		ttl:           options.ttl,
+		capacity:      options.capacity,
+		store:         options.store,
+		threadOptions: options.threadOptions,
+		sessions:      make(map[string]*session),
+		spilling:      make(map[string]*session),
+		lru:           list.New(),
+		closeChan:     make(chan struct{}),
+		idleChan:      make(chan struct{}, 1),
+	}
+
+	go manager.goCleanup(options.cleanupInterval)
+	return manager
+}
+
+// Get 返回id对应的thread, 不存在时从store恢复或者新建. 调用release之前session不会被淘汰, 用完之后必须调用release.
+// 返回的thread不受串行化保护, 多轮对话请使用Do
+func (my *Manager) Get(ctx context.Context, id string) (*chat.Thread, func(), error) {
+	var item, err = my.acquire(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var once sync.Once
+	return item.thread, func() { once.Do(func() { my.release(item) }) }, nil
+}
+
+// Do 在持有session的情况下调用fn, 同一个session上的并发调用按顺序执行, 保证一问一答不会交错
+func (my *Manager) Do(ctx context.Context, id string, fn func(thread *chat.Thread) error) error {
+	if fn == nil {
+		return errors.New("fn is nil")
+	}
+
+	var item, err1 = my.lockTurn(ctx, id)
+	if err1 != nil {
+		return err1
+	}
+	defer my.release(item)
+	defer func() { <-item.turn }()
+
+	return fn(item.thread)
+}
+
+// Delete 删除session, 同时删除store中的副本. 正在进行的对话会先结束, 排队中的对话在删除之后使用新的session.
+// 正在写入store的副本由spill在写完之后删除
+func (my *Manager) Delete(ctx context.Context, id string) error {
+	my.m.Lock()
+	var item, ok = my.sessions[id]
+	if ok {
+		item.refs++
+	}
+
+	if spilled, found := my.spilling[id]; found {
+		spilled.deleted = true
+		delete(my.spilling, id)
+	}
+	my.m.Unlock()
+
+	if !ok {
+		return my.deleteStore(ctx, id)
+	}
+	defer my.release(item)
+
+	// 占用turn等待正在进行的对话结束, 否则对话会继续写入已经被删除的thread
+	select {
+	case item.turn <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-item.turn }()
+
+	// 先删除store中的副本再从内存中移除, 否则并发的acquire可能从store中恢复出旧的session
+	if err := my.deleteStore(ctx, id); err != nil {
+		return err
+	}
+
+	my.m.Lock()
+	item.deleted = true
+	if my.sessions[id] == item {
+		my.lru.Remove(item.element)
+		delete(my.sessions, id)
+	}
+	my.m.Unlock()
+
+	return nil
+}
+
+func (my *Manager) deleteStore(ctx context.Context, id string) error {
+	if my.store != nil {
+		return my.store.Delete(ctx, id)
+	}
+
+	return nil
+}
+
+// Len 返回内存中的session数
+func (my *Manager) Len() int {
+	my.m.Lock()
+	defer my.m.Unlock()
+	return len(my.sessions)
+}
+
+func (my *Manager) Stats() Stats {
+	my.m.Lock()
+	defer my.m.Unlock()
+
+	var stats = my.stats
+	stats.Active = len(my.sessions)
+	for _, item := range my.sessions {
+		if item.refs > 0 {
+			stats.Busy++
+		}
+	}
+
+	return stats
+}
+
+// Evict 立即淘汰所有过期的session, 通常由后台定时调用
+func (my *Manager) Evict(ctx context.Context) {
+	my.m.Lock()
+	var victims = my.collectVictims(time.Now())
+	my.m.Unlock()
+
+	my.spill(ctx, victims)
+}
+
+// Close 停止后台清理, 并把内存中的session全部写入store, 之后的Get与Do返回ErrClosed. 正在对话的session会等到对话结束之后再写入,
+// ctx结束时不再等待, 这些session不会被保存
+func (my *Manager) Close(ctx context.Context) {
+	my.closeOnce.Do(func() {
+		my.m.Lock()
+		my.closed = true
+		my.m.Unlock()
+		close(my.closeChan)
+
+		for {
+			var victims, busy = my.collectIdle()
+			my.spill(ctx, victims)
+			if busy == 0 {
+				return
+			}
+
+			select {
+			case <-my.idleChan:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+}
+
+// collectIdle 取出所有空闲的session用于写入store, 返回仍在使用中的session数
+func (my *Manager) collectIdle() ([]*session, int) {
+	my.m.Lock()
+	defer my.m.Unlock()
+
+	var victims = make([]*session, 0, len(my.sessions))
+	var busy = 0
+	for id, item := range my.sessions {
+		if item.refs > 0 {
+			busy++
+			continue
+		}
+
+		victims = append(victims, item)
+		my.lru.Remove(item.element)
+		my.spilling[id] = item
+		delete(my.sessions, id)
+	}
+
+	my.stats.Evicted += int64(len(victims))
+	return victims, busy
+}
+
+func (my *Manager) acquire(ctx context.Context, id string) (*session, error) {
+	my.m.Lock()
+	if my.closed {
+		my.m.Unlock()
+		return nil, ErrClosed
+	}
+
+	if item, ok := my.sessions[id]; ok {
+		my.touch(item)
+		my.m.Unlock()
+		return item, nil
+	}
+
+	if item, ok := my.spilling[id]; ok {
+		delete(my.spilling, id)
+		my.insert(item)
+		my.m.Unlock()
+		return item, nil
+	}
+	my.m.Unlock()
+
+	// 在锁外访问store, 避免阻塞其它session
+	var thread, loaded, err = my.loadOrCreate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	my.m.Lock()
+	// 加载期间Close了, 不能再加入新的session
+	if my.closed {
+		my.m.Unlock()
+		return nil, ErrClosed
+	}
+
+	// 加载期间可能已经有其它goroutine创建了同一个session
+	if item, ok := my.sessions[id]; ok {
+		my.touch(item)
+		my.m.Unlock()
+		return item, nil
+	}
+
+	var item = &session{id: id, thread: thread, turn: make(chan struct{}, 1)}
+	my.insert(item)
+	if loaded {
+		my.stats.Loaded++
+	} else {
+		my.stats.Created++
+	}
+
+	var victims = my.collectVictims(time.Now())
+	my.m.Unlock()
+
+	my.spill(ctx, victims)
+	return item, nil
+}
+
+// lockTurn 获取session并占用它的turn, 等待期间session被Delete时重新获取
+func (my *Manager) lockTurn(ctx context.Context, id string) (*session, error) {
+	for {
+		var item, err = my.acquire(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		select {
+		case item.turn <- struct{}{}:
+		case <-ctx.Done():
+			my.release(item)
+			return nil, ctx.Err()
+		}
+
+		my.m.Lock()
+		var deleted = item.deleted
+		my.m.Unlock()
+
+		if !deleted {
+			return item, nil
+		}
+
+		<-item.turn
+		my.release(item)
+	}
+}
+
+func (my *Manager) loadOrCreate(ctx context.Context, id string) (*chat.Thread, bool, error) {
+	if my.store != nil {
+		var thread, err = my.store.Load(ctx, id)
+		if err == nil {
+			return thread, true, nil
+		}
+
+		if !errors.Is(err, ErrNotFound) {
+			return nil, false, err
+		}
+	}
+
+	var thread, err = chat.NewThread(my.threadOptions...)
+	return thread, false, err
+}
+
+func (my *Manager) release(item *session) {
+	my.m.Lock()
+	item.refs--
+	item.lastAccess = time.Now()
+	if my.sessions[item.id] == item {
+		my.lru.MoveToFront(item.element)
+	}
+	var idle = item.refs == 0
+	my.m.Unlock()
+
+	if idle {
+		select {
+		case my.idleChan <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// touch 需要在锁内调用
+func (my *Manager) touch(item *session) {
+	item.refs++
+	item.lastAccess = time.Now()
+	my.lru.MoveToFront(item.element)
+}
+
+// insert 需要在锁内调用
+func (my *Manager) insert(item *session) {
+	item.refs++
+	item.lastAccess = time.Now()
+	item.element = my.lru.PushFront(item)
+	my.sessions[item.id] = item
+}
+
+// collectVictims 需要在锁内调用, 从队尾开始淘汰过期或者超出容量的session, 正在使用的session不会被淘汰
+func (my *Manager) collectVictims(now time.Time) []*session {
+	var victims []*session
+	var overflow = len(my.sessions) - my.capacity
+	for element := my.lru.Back(); element != nil; {
+		var item = element.Value.(*session)
+		var prev = element.Prev()
+
+		var expired = now.Sub(item.lastAccess) >= my.ttl
+		if !expired && overflow <= 0 {
+			break
+		}
+
+		if item.refs == 0 {
+			my.lru.Remove(element)
+			delete(my.sessions, item.id)
+			my.spilling[item.id] = item
+			victims = append(victims, item)
+			overflow--
+		}
+
+		element = prev
+	}
+
+	my.stats.Evicted += int64(len(victims))
+	return victims
+}
+
+// spill 在锁外把被淘汰的session写入store, 写入期间再次访问的session会被acquire复活
+func (my *Manager) spill(ctx context.Context, victims []*session) {
+	for _, item := range victims {
+		var err error
+		if my.store != nil {
+			err = my.store.Save(ctx, item.id, item.thread)
+		}
+
+		my.m.Lock()
+		if my.spilling[item.id] == item {
+			delete(my.spilling, item.id)
+		}
+		var deleted = item.deleted
+		my.m.Unlock()
+
+		// 写入期间session被Delete了, 删除刚刚写入的副本, 避免被删除的session复活
+		if deleted && my.store != nil && err == nil {
+			err = my.store.Delete(ctx, item.id)
+		}
+
+		my.m.Lock()
+		if my.store != nil {
+			if err != nil {
+				my.stats.SpillErrors++
+			} else {
+				my.stats.Spilled++
+			}
+		}
+		my.m.Unlock()
+	}
+}
+
+func (my *Manager) goCleanup(interval time.Duration) {
+	var ticker = time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			my.Evict(context.Background())
+		case <-my.closeChan:
+			return
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package session
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lixianmin/agi/chat"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+func TestEvictAndReload(t *testing.T) {
+	var ctx = context.Background()
+	var store, _ = NewDiskStore(t.TempDir())
+	var manager = NewManager(WithTTL(50*time.Millisecond), WithCapacity(2), WithStore(store), WithCleanupInterval(time.Hour))
+	defer manager.Close(ctx)
+
+	for i := 0; i < 3; i++ {
+		var id = "user/" + strconv.Itoa(i)
+		_ = manager.Do(ctx, id, func(thread *chat.Thread) error {
+			thread.AddUserMessage("hello from " + id)
+			return nil
+		})
+	}
+
+	// 容量为2, 最早的session被写入store
+	var stats = manager.Stats()
+	println("active:", stats.Active, "evicted:", stats.Evicted, "spilled:", stats.Spilled)
+	if stats.Active != 2 || stats.Evicted != 1 || stats.Spilled != 1 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	manager.Evict(ctx)
+	if manager.Len() != 0 {
+		t.Fatalf("idle sessions should expire, len=%d", manager.Len())
+	}
+
+	var thread, release, err = manager.Get(ctx, "user/0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	var history = thread.HistoryMessages()
+	if len(history) != 1 || history[0].Content != "hello from user/0" || manager.Stats().Loaded != 1 {
+		t.Fatalf("session should be restored from store: %d", len(history))
+	}
+
+	// release之前即使过期也不会被淘汰, 调用方写入的内容不会丢失
+	time.Sleep(60 * time.Millisecond)
+	manager.Evict(ctx)
+	if manager.Len() != 1 {
+		t.Fatalf("session in use should not be evicted, len=%d", manager.Len())
+	}
+}
+
+func TestSerializedTurns(t *testing.T) {
+	var ctx = context.Background()
+	var manager = NewManager()
+	defer manager.Close(ctx)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_ = manager.Do(ctx, "same", func(thread *chat.Thread) error {
+				var question = "q" + strconv.Itoa(i)
+				thread.AddUserMessage(question)
+				time.Sleep(time.Millisecond)
+				thread.AddBotMessage("a" + question[1:])
+				return nil
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	var thread, release, _ = manager.Get(ctx, "same")
+	defer release()
+	var history = thread.HistoryMessages()
+	for i := 0; i+1 < len(history); i += 2 {
+		if history[i].Content[1:] != history[i+1].Content[1:] {
+			t.Fatalf("turns are interleaved: %s %s", history[i].Content, history[i+1].Content)
+		}
+	}
+
+	var cancelled, cancel = context.WithCancel(ctx)
+	var started = make(chan struct{})
+	go func() {
+		_ = manager.Do(ctx, "same", func(thread *chat.Thread) error {
+			close(started)
+			time.Sleep(50 * time.Millisecond)
+			return nil
+		})
+	}()
+
+	<-started
+	cancel()
+	if err := manager.Do(cancelled, "same", func(thread *chat.Thread) error { return nil }); err != context.Canceled {
+		t.Fatalf("waiting turn should be cancelled, err=%v", err)
+	}
+}
+
+// slowStore 的Save会阻塞到release关闭, 用于模拟写入store期间的并发操作
+type slowStore struct {
+	*MemoryStore
+	saving  chan struct{}
+	release chan struct{}
+}
+
+func (my *slowStore) Save(ctx context.Context, id string, thread *chat.Thread) error {
+	close(my.saving)
+	<-my.release
+	return my.MemoryStore.Save(ctx, id, thread)
+}
+
+func TestDeleteWhileSpilling(t *testing.T) {
+	var ctx = context.Background()
+	var store = &slowStore{MemoryStore: NewMemoryStore(), saving: make(chan struct{}), release: make(chan struct{})}
+	var manager = NewManager(WithTTL(time.Millisecond), WithStore(store), WithCleanupInterval(time.Hour))
+	defer manager.Close(ctx)
+
+	_ = manager.Do(ctx, "deleted", func(thread *chat.Thread) error {
+		thread.AddUserMessage("hello")
+		return nil
+	})
+
+	time.Sleep(5 * time.Millisecond)
+	var done = make(chan struct{})
+	go func() {
+		manager.Evict(ctx)
+		close(done)
+	}()
+
+	// Save还没有写完时删除session, 写完之后store中不应该再有副本
+	<-store.saving
+	if err := manager.Delete(ctx, "deleted"); err != nil {
+		t.Fatal(err)
+	}
+	close(store.release)
+	<-done
+
+	if _, err := store.Load(ctx, "deleted"); err != ErrNotFound {
+		t.Fatalf("deleted session is resurrected, err=%v", err)
+	}
+}
+
+func TestCloseWaitsForBusySession(t *testing.T) {
+	var ctx = context.Background()
+	var store = NewMemoryStore()
+	var manager = NewManager(WithStore(store), WithCleanupInterval(time.Hour))
+
+	var started = make(chan struct{})
+	var finished = make(chan struct{})
+	go func() {
+		_ = manager.Do(ctx, "busy", func(thread *chat.Thread) error {
+			close(started)
+			time.Sleep(20 * time.Millisecond)
+			thread.AddUserMessage("late")
+			return nil
+		})
+		close(finished)
+	}()
+
+	<-started
+	manager.Close(ctx)
+	<-finished
+
+	// Close等到对话结束之后才写入store, 因此保存的是对话之后的内容
+	var thread, err = store.Load(ctx, "busy")
+	if err != nil || len(thread.HistoryMessages()) != 1 {
+		t.Fatalf("busy session should be saved after it is released, err=%v", err)
+	}
+
+	// Close之后不能再创建或者恢复session
+	if err := manager.Do(ctx, "busy", func(thread *chat.Thread) error { return nil }); err != ErrClosed {
+		t.Fatalf("err=%v", err)
+	}
+
+	if _, _, err := manager.Get(ctx, "new"); err != ErrClosed || manager.Len() != 0 {
+		t.Fatalf("err=%v, len=%d", err, manager.Len())
+	}
+}
+
+func TestDeleteBusySession(t *testing.T) {
+	var ctx = context.Background()
+	var store = NewMemoryStore()
+	var manager = NewManager(WithStore(store), WithCleanupInterval(time.Hour))
+	defer manager.Close(ctx)
+
+	var started = make(chan struct{})
+	var finished atomic.Bool
+	go func() {
+		_ = manager.Do(ctx, "busy", func(thread *chat.Thread) error {
+			close(started)
+			time.Sleep(20 * time.Millisecond)
+			thread.AddUserMessage("late")
+			finished.Store(true)
+			return nil
+		})
+	}()
+
+	<-started
+	if err := manager.Delete(ctx, "busy"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Delete等到正在进行的对话结束之后才删除, 之后获取的是一个新的session
+	if !finished.Load() {
+		t.Fatal("delete should wait for the running turn")
+	}
+
+	var thread, release, err = manager.Get(ctx, "busy")
+	if err != nil || len(thread.HistoryMessages()) != 0 || manager.Stats().Created != 2 {
+		t.Fatalf("deleted session is still alive, err=%v", err)
+	}
+	release()
+}
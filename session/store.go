@@ -0,0 +1,144 @@
+package session
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/lixianmin/agi/chat"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+var ErrNotFound = errors.New("session not found")
+
+type (
+	// ThreadStore 保存被淘汰的session, 之后再次访问时恢复. 需要线程安全, 找不到时Load返回ErrNotFound
+	ThreadStore interface {
+		Save(ctx context.Context, id string, thread *chat.Thread) error
+		Load(ctx context.Context, id string) (*chat.Thread, error)
+		Delete(ctx context.Context, id string) error
+	}
+
+	// MemoryStore 以json的形式保存在内存中, 主要用于测试
+	MemoryStore struct {
+		threads map[string][]byte
+		m       sync.Mutex
+	}
+
+	// DiskStore 每个session保存为目录下的一个json文件
+	DiskStore struct {
+		dir string
+	}
+)
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{threads: make(map[string][]byte)}
+}
+
+func (my *MemoryStore) Save(ctx context.Context, id string, thread *chat.Thread) error {
+	var bts, err = json.Marshal(thread)
+	if err != nil {
+		return err
+	}
+
+	my.m.Lock()
+	my.threads[id] = bts
+	my.m.Unlock()
+	return nil
+}
+
+func (my *MemoryStore) Load(ctx context.Context, id string) (*chat.Thread, error) {
+	my.m.Lock()
+	var bts, ok = my.threads[id]
+	my.m.Unlock()
+
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return unmarshalThread(bts)
+}
+
+func (my *MemoryStore) Delete(ctx context.Context, id string) error {
+	my.m.Lock()
+	delete(my.threads, id)
+	my.m.Unlock()
+	return nil
+}
+
+func NewDiskStore(dir string) (*DiskStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	return &DiskStore{dir: dir}, nil
+}
+
+func (my *DiskStore) Save(ctx context.Context, id string, thread *chat.Thread) error {
+	var bts, err1 = json.Marshal(thread)
+	if err1 != nil {
+		return err1
+	}
+
+	// 先写临时文件再rename, 避免并发读到写了一半的文件
+	var temp, err2 = os.CreateTemp(my.dir, "session.*.tmp")
+	if err2 != nil {
+		return err2
+	}
+
+	var _, err3 = temp.Write(bts)
+	var err4 = temp.Close()
+	if err := errors.Join(err3, err4); err != nil {
+		_ = os.Remove(temp.Name())
+		return err
+	}
+
+	return os.Rename(temp.Name(), my.path(id))
+}
+
+func (my *DiskStore) Load(ctx context.Context, id string) (*chat.Thread, error) {
+	var bts, err = os.ReadFile(my.path(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return unmarshalThread(bts)
+}
+
+func (my *DiskStore) Delete(ctx context.Context, id string) error {
+	var err = os.Remove(my.path(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+// path session id可能包含任意字符, 因此用base64编码作为文件名
+func (my *DiskStore) path(id string) string {
+	return filepath.Join(my.dir, base64.RawURLEncoding.EncodeToString([]byte(id))+".json")
+}
+
+func unmarshalThread(bts []byte) (*chat.Thread, error) {
+	var thread = &chat.Thread{}
+	if err := json.Unmarshal(bts, thread); err != nil {
+		return nil, err
+	}
+
+	return thread, nil
+}
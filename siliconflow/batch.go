@@ -0,0 +1,386 @@
+package siliconflow
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/lixianmin/agi/ifs"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+const (
+	BatchValidating = "validating"
+	BatchFailed     = "failed"
+	BatchInProgress = "in_progress"
+	BatchFinalizing = "finalizing"
+	BatchCompleted  = "completed"
+	BatchExpired    = "expired"
+	BatchCancelling = "cancelling"
+	BatchCancelled  = "cancelled"
+
+	batchEndpoint = "/v1/chat/completions"
+)
+
+type (
+	// BatchRequest 是batch输入文件中的一个请求, CustomID用于把结果对应回请求, 在同一个batch中必须唯一
+	BatchRequest struct {
+		CustomID string
+		Request  *ChatRequest
+	}
+
+	// CreateBatchRequest 的CompletionWindow为空时使用24h
+	CreateBatchRequest struct {
+		InputFileID      string            `json:"input_file_id"`
+		Endpoint         string            `json:"endpoint"`
+		CompletionWindow string            `json:"completion_window"`
+		Metadata         map[string]string `json:"metadata,omitempty"`
+	}
+
+	Batch struct {
+		ID               string            `json:"id"`
+		Object           string            `json:"object"`
+		Endpoint         string            `json:"endpoint"`
+		Errors           *BatchErrors      `json:"errors,omitempty"`
+		InputFileID      string            `json:"input_file_id"`
+		CompletionWindow string            `json:"completion_window"`
+		Status           string            `json:"status"`
+		OutputFileID     string            `json:"output_file_id,omitempty"`
+		ErrorFileID      string            `json:"error_file_id,omitempty"`
+		CreatedAt        int64             `json:"created_at"`
+		InProgressAt     int64             `json:"in_progress_at,omitempty"`
+		ExpiresAt        int64             `json:"expires_at,omitempty"`
+		CompletedAt      int64             `json:"completed_at,omitempty"`
+		FailedAt         int64             `json:"failed_at,omitempty"`
+		CancelledAt      int64             `json:"cancelled_at,omitempty"`
+		RequestCounts    BatchCounts       `json:"request_counts"`
+		Metadata         map[string]string `json:"metadata,omitempty"`
+	}
+
+	BatchCounts struct {
+		Total     int `json:"total"`
+		Completed int `json:"completed"`
+		Failed    int `json:"failed"`
+	}
+
+	// BatchErrors 是batch整体的校验错误, 比如输入文件格式不正确
+	BatchErrors struct {
+		Data []BatchError `json:"data"`
+	}
+
+	BatchError struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+		Line    int    `json:"line,omitempty"`
+	}
+
+	// BatchResult 是结果文件中的一行. 成功时Response不为nil, 失败时Error不为nil
+	BatchResult struct {
+		ID         string
+		CustomID   string
+		StatusCode int
+		Response   *ChatCompletionChunk
+		Error      *BatchError
+	}
+
+	batchList struct {
+		Object  string   `json:"object"`
+		Data    []*Batch `json:"data"`
+		HasMore bool     `json:"has_more"`
+	}
+
+	batchLine struct {
+		CustomID string       `json:"custom_id"`
+		Method   string       `json:"method"`
+		Url      string       `json:"url"`
+		Body     *ChatRequest `json:"body"`
+	}
+
+	batchResultLine struct {
+		ID       string `json:"id"`
+		CustomID string `json:"custom_id"`
+		Response *struct {
+			StatusCode int             `json:"status_code"`
+			Body       json.RawMessage `json:"body"`
+		} `json:"response"`
+		Error *BatchError `json:"error"`
+	}
+
+	waitOptions struct {
+		interval    time.Duration
+		maxInterval time.Duration
+		onPoll      func(batch *Batch)
+	}
+
+	WaitOption func(*waitOptions)
+)
+
+// WithPollInterval 第一次轮询的间隔, 之后每次乘以1.5, 直到maxInterval. 默认为5秒到1分钟
+func WithPollInterval(interval time.Duration, maxInterval time.Duration) WaitOption {
+	return func(options *waitOptions) {
+		if interval > 0 {
+			options.interval = interval
+		}
+
+		if maxInterval >= options.interval {
+			options.maxInterval = maxInterval
+		}
+	}
+}
+
+// WithPollCallback 每次轮询之后回调, 可以用于打印进度
+func WithPollCallback(fn func(batch *Batch)) WaitOption {
+	return func(options *waitOptions) {
+		options.onPoll = fn
+	}
+}
+
+// IsTerminal 判断batch是否已经结束, 结束之后状态不会再变化
+func (my *Batch) IsTerminal() bool {
+	switch my.Status {
+	case BatchCompleted, BatchFailed, BatchExpired, BatchCancelled:
+		return true
+	default:
+		return false
+	}
+}
+
+// EncodeBatchRequests 把请求编码为batch输入文件(jsonl). 每个请求的采样参数与thread参数在这里合并与裁剪
+func EncodeBatchRequests(requests []*BatchRequest) ([]byte, error) {
+	var buffer bytes.Buffer
+	var encoder = json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+
+	var ids = make(map[string]bool, len(requests))
+	for i, request := range requests {
+		if request == nil || request.CustomID == "" || request.Request == nil {
+			return nil, fmt.Errorf("request %d: custom id and request are required", i)
+		}
+
+		if ids[request.CustomID] {
+			return nil, fmt.Errorf("duplicate custom id %s", request.CustomID)
+		}
+		ids[request.CustomID] = true
+
+		var resolved, err1 = request.Request.resolveParams()
+		if err1 != nil {
+			return nil, fmt.Errorf("request %s: %w", request.CustomID, err1)
+		}
+		resolved.Stream = false
+
+		if err2 := encoder.Encode(&batchLine{CustomID: request.CustomID, Method: http.MethodPost, Url: batchEndpoint, Body: resolved}); err2 != nil {
+			return nil, err2
+		}
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// SubmitBatch 上传请求并创建batch
+func (my *SiliconClient) SubmitBatch(ctx context.Context, requests []*BatchRequest, metadata map[string]string) (*Batch, error) {
+	var content, err1 = EncodeBatchRequests(requests)
+	if err1 != nil {
+		return nil, err1
+	}
+
+	var file, err2 = my.UploadFile(ctx, "batch.jsonl", PurposeBatch, content)
+	if err2 != nil {
+		return nil, err2
+	}
+
+	return my.CreateBatch(ctx, &CreateBatchRequest{InputFileID: file.ID, Metadata: metadata})
+}
+
+func (my *SiliconClient) CreateBatch(ctx context.Context, request *CreateBatchRequest) (*Batch, error) {
+	if request == nil || request.InputFileID == "" {
+		return nil, errors.New("invalid parameters")
+	}
+
+	var body = *request
+	if body.Endpoint == "" {
+		body.Endpoint = batchEndpoint
+	}
+
+	if body.CompletionWindow == "" {
+		body.CompletionWindow = "24h"
+	}
+
+	var batch Batch
+	if err := my.getJson(ctx, ifs.EndpointBatches, http.MethodPost, "/batches", &body, &batch); err != nil {
+		return nil, err
+	}
+
+	return &batch, nil
+}
+
+func (my *SiliconClient) GetBatch(ctx context.Context, batchId string) (*Batch, error) {
+	if batchId == "" {
+		return nil, errors.New("invalid parameters")
+	}
+
+	var batch Batch
+	if err := my.getJson(ctx, ifs.EndpointBatches, http.MethodGet, "/batches/"+url.PathEscape(batchId), nil, &batch); err != nil {
+		return nil, err
+	}
+
+	return &batch, nil
+}
+
+// CancelBatch 取消之后batch先进入cancelling, 已经完成的结果仍然可以下载
+func (my *SiliconClient) CancelBatch(ctx context.Context, batchId string) (*Batch, error) {
+	if batchId == "" {
+		return nil, errors.New("invalid parameters")
+	}
+
+	var batch Batch
+	if err := my.getJson(ctx, ifs.EndpointBatches, http.MethodPost, "/batches/"+url.PathEscape(batchId)+"/cancel", nil, &batch); err != nil {
+		return nil, err
+	}
+
+	return &batch, nil
+}
+
+// ListBatches 按创建时间倒序返回, after是上一页最后一个batch的ID, limit<=0时使用服务端默认值
+func (my *SiliconClient) ListBatches(ctx context.Context, after string, limit int) ([]*Batch, bool, error) {
+	var query = url.Values{}
+	if after != "" {
+		query.Set("after", after)
+	}
+
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+
+	var path = "/batches"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var list batchList
+	if err := my.getJson(ctx, ifs.EndpointBatches, http.MethodGet, path, nil, &list); err != nil {
+		return nil, false, err
+	}
+
+	return list.Data, list.HasMore, nil
+}
+
+// WaitBatch 轮询直到batch结束, 轮询间隔按指数退避增长. 查询失败时直接返回错误, 由调用方决定是否重新等待
+func (my *SiliconClient) WaitBatch(ctx context.Context, batchId string, opts ...WaitOption) (*Batch, error) {
+	// 默认值
+	var options = waitOptions{
+		interval:    5 * time.Second,
+		maxInterval: time.Minute,
+	}
+
+	// 初始化
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	var interval = options.interval
+	for {
+		var batch, err = my.GetBatch(ctx, batchId)
+		if err != nil {
+			return nil, err
+		}
+
+		if options.onPoll != nil {
+			options.onPoll(batch)
+		}
+
+		if batch.IsTerminal() {
+			return batch, nil
+		}
+
+		var timer = time.NewTimer(interval)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return batch, ctx.Err()
+		}
+
+		interval = min(interval*3/2, options.maxInterval)
+	}
+}
+
+// GetBatchResults 下载batch的结果文件与错误文件, 按CustomID返回每个请求的结果
+func (my *SiliconClient) GetBatchResults(ctx context.Context, batch *Batch) (map[string]*BatchResult, error) {
+	if batch == nil {
+		return nil, errors.New("batch is nil")
+	}
+
+	var results = make(map[string]*BatchResult, batch.RequestCounts.Total)
+	for _, fileId := range []string{batch.OutputFileID, batch.ErrorFileID} {
+		if fileId == "" {
+			continue
+		}
+
+		var content, err1 = my.DownloadFile(ctx, fileId)
+		if err1 != nil {
+			return nil, err1
+		}
+
+		var parsed, err2 = ParseBatchResults(bytes.NewReader(content))
+		if err2 != nil {
+			return nil, err2
+		}
+
+		for _, result := range parsed {
+			results[result.CustomID] = result
+		}
+	}
+
+	return results, nil
+}
+
+// ParseBatchResults 解析结果文件(jsonl), 非200的响应被转换为Error
+func ParseBatchResults(reader io.Reader) ([]*BatchResult, error) {
+	var scanner = bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, maxBufferSize), 16*maxBufferSize)
+
+	var results []*BatchResult
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		var line = scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		var item batchResultLine
+		if err := json.Unmarshal(line, &item); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+
+		var result = &BatchResult{ID: item.ID, CustomID: item.CustomID, Error: item.Error}
+		if item.Response != nil {
+			result.StatusCode = item.Response.StatusCode
+			if result.StatusCode == http.StatusOK {
+				var response ChatCompletionChunk
+				if err := json.Unmarshal(item.Response.Body, &response); err != nil {
+					return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+				}
+				result.Response = &response
+			} else if result.Error == nil {
+				result.Error = &BatchError{Code: strconv.Itoa(result.StatusCode), Message: string(item.Response.Body)}
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	return results, scanner.Err()
+}
@@ -0,0 +1,139 @@
+package siliconflow
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lixianmin/agi/agitest"
+	"github.com/lixianmin/agi/chat"
+	"github.com/lixianmin/agi/ifs"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+func TestBatch(t *testing.T) {
+	var server = agitest.NewTestServer(t)
+	server.SetChatHandler(func(request *agitest.ChatRequest) agitest.Reply {
+		if request.LastUserMessage() == "fail" {
+			return agitest.ErrorReply(http.StatusBadRequest, "bad prompt")
+		}
+		return agitest.Reply{Content: "re: " + request.LastUserMessage()}
+	})
+
+	// 文件与batch接口同样经过interceptor链
+	var endpoints = make(map[string]int)
+	var counter = func(ctx context.Context, call *ifs.Call, next ifs.Invoker) (any, error) {
+		endpoints[call.Endpoint]++
+		return next(ctx, call)
+	}
+
+	var client = NewSiliconClient("sk-test", WithBaseUrl(server.BaseUrl()+"/v1"), WithInterceptors(counter))
+	var newRequest = func(content string) *ChatRequest {
+		return &ChatRequest{
+			Request: chat.Request{
+				Model:    "Qwen/Qwen2-7B-Instruct",
+				Messages: []*chat.Message{{Role: "user", Content: content}},
+				Params:   &chat.Params{Temperature: chat.Float32(5)},
+			},
+		}
+	}
+
+	var requests = []*BatchRequest{
+		{CustomID: "a", Request: newRequest("hello")},
+		{CustomID: "b", Request: newRequest("fail")},
+		{CustomID: "c", Request: newRequest("world")},
+	}
+
+	var ctx = context.Background()
+	var batch, err1 = client.SubmitBatch(ctx, requests, map[string]string{"job": "test"})
+	if err1 != nil || batch.Status != BatchInProgress || batch.OutputFileID != "" {
+		t.Fatalf("batch=%+v, err=%v", batch, err1)
+	}
+
+	// 采样参数在编码时被裁剪
+	if last := server.LastChatRequest(); *last.Temperature != maxTemperature {
+		t.Fatalf("temperature=%v", *last.Temperature)
+	}
+
+	var polls = 0
+	var done, err2 = client.WaitBatch(ctx, batch.ID, WithPollInterval(time.Millisecond, 5*time.Millisecond), WithPollCallback(func(*Batch) { polls++ }))
+	if err2 != nil || done.Status != BatchCompleted || polls != 2 || done.RequestCounts.Failed != 1 {
+		t.Fatalf("done=%+v, polls=%d, err=%v", done, polls, err2)
+	}
+
+	var results, err3 = client.GetBatchResults(ctx, done)
+	if err3 != nil || len(results) != 3 {
+		t.Fatalf("results=%v, err=%v", results, err3)
+	}
+
+	if results["a"].Response.Choices[0].Message.Content != "re: hello" || results["c"].Response.Usage == nil {
+		t.Fatalf("a=%+v, c=%+v", results["a"], results["c"])
+	}
+
+	if results["b"].Response != nil || results["b"].StatusCode != http.StatusBadRequest || !strings.Contains(results["b"].Error.Message, "bad prompt") {
+		t.Fatalf("b=%+v", results["b"])
+	}
+
+	var batches, _, err4 = client.ListBatches(ctx, "", 10)
+	if err4 != nil || len(batches) != 1 || batches[0].Metadata["job"] != "test" {
+		t.Fatalf("batches=%v, err=%v", batches, err4)
+	}
+
+	var files, err5 = client.ListFiles(ctx, PurposeBatch)
+	if err5 != nil || len(files) != 1 {
+		t.Fatalf("files=%v, err=%v", files, err5)
+	}
+
+	if err := client.DeleteFile(ctx, files[0].ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.DownloadFile(ctx, files[0].ID); err == nil {
+		t.Fatal("deleted file should not be downloadable")
+	}
+
+	// 上传, 列出, 删除, 下载结果与下载已删除的文件; 创建, 轮询两次与列出batch
+	if endpoints[ifs.EndpointFiles] != 6 || endpoints[ifs.EndpointBatches] != 4 {
+		t.Fatalf("endpoints=%v", endpoints)
+	}
+}
+
+func TestCancelBatch(t *testing.T) {
+	var server = agitest.NewTestServer(t)
+	var client = NewSiliconClient("sk-test", WithBaseUrl(server.BaseUrl()))
+
+	var batch, err1 = client.SubmitBatch(context.Background(), []*BatchRequest{{CustomID: "a", Request: &ChatRequest{
+		Request: chat.Request{Model: "Qwen/Qwen2-7B-Instruct", Messages: []*chat.Message{{Role: "user", Content: "hi"}}},
+	}}}, nil)
+	if err1 != nil {
+		t.Fatal(err1)
+	}
+
+	var cancelled, err2 = client.CancelBatch(context.Background(), batch.ID)
+	if err2 != nil || cancelled.Status != BatchCancelled || !cancelled.IsTerminal() {
+		t.Fatalf("cancelled=%+v, err=%v", cancelled, err2)
+	}
+
+	if _, err := EncodeBatchRequests([]*BatchRequest{{CustomID: "a", Request: &ChatRequest{}}, {CustomID: "a", Request: &ChatRequest{}}}); err == nil {
+		t.Fatal("duplicate custom id should fail")
+	}
+}
+
+func TestDecodeEnvelope(t *testing.T) {
+	var file File
+	if err := decodeJson([]byte(`{"code":20000,"message":"Ok","status":true,"data":{"id":"file-1","object":"file","purpose":"batch"}}`), &file); err != nil || file.ID != "file-1" {
+		t.Fatalf("file=%+v, err=%v", file, err)
+	}
+
+	if err := decodeJson([]byte(`{"code":20015,"message":"invalid file","status":false,"data":null}`), &file); err == nil || !strings.Contains(err.Error(), "invalid file") {
+		t.Fatalf("err=%v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package siliconflow
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/lixianmin/agi/ifs"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+const defaultBaseUrl = "https://api.siliconflow.cn/v1"
+
+type clientOptions struct {
+	httpClient   *http.Client
+	baseUrl      string
+	interceptors []ifs.Interceptor
+}
+
+type ClientOption func(*clientOptions)
+
+func WithHttpClient(client *http.Client) ClientOption {
+	return func(options *clientOptions) {
+		if client != nil {
+			options.httpClient = client
+		}
+	}
+}
+
+// WithBaseUrl 替换默认的api地址, 比如指向测试用的mock server或内部网关
+func WithBaseUrl(baseUrl string) ClientOption {
+	return func(options *clientOptions) {
+		if baseUrl != "" {
+			options.baseUrl = strings.TrimRight(baseUrl, "/")
+		}
+	}
+}
+
+// WithInterceptors 按顺序追加interceptor, 先追加的位于外层
+func WithInterceptors(interceptors ...ifs.Interceptor) ClientOption {
+	return func(options *clientOptions) {
+		options.interceptors = append(options.interceptors, interceptors...)
+	}
+}
@@ -0,0 +1,81 @@
+package siliconflow
+
+import (
+	"time"
+
+	"github.com/lixianmin/agi/chat"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+// synthesizer 实现ifs.Synthesizer
+type synthesizer struct{}
+
+func (my *ChatCompletionChunk) GetModel() string {
+	return my.Model
+}
+
+func (my *ChatCompletionChunk) GetContent() string {
+	if len(my.Choices) > 0 {
+		return my.Choices[0].Message.Content
+	}
+
+	return ""
+}
+
+func (my *ChatCompletionChunk) GetFinishReason() string {
+	if len(my.Choices) > 0 {
+		return my.Choices[0].FinishReason
+	}
+
+	return ""
+}
+
+func (my *ChatCompletionChunk) GetUsage() *chat.Usage {
+	return my.Usage
+}
+
+func (my ChatResponse) GetModel() string {
+	return my.Model
+}
+
+func (my ChatResponse) GetContent() string {
+	return my.Message.Content
+}
+
+func (my ChatResponse) GetFinishReason() string {
+	return my.DoneReason
+}
+
+func (my ChatResponse) GetUsage() *chat.Usage {
+	return my.Usage
+}
+
+func (synthesizer) NewCompletion(model string, content string, finishReason string, usage *chat.Usage) any {
+	return &ChatCompletionChunk{
+		Object:  "chat.completion",
+		Created: time.Now().Unix(),
+		Model:   model,
+		Choices: []ChunkedChoice{{
+			Message:      chat.Message{Role: "assistant", Content: content},
+			FinishReason: finishReason,
+		}},
+		Usage: usage,
+	}
+}
+
+func (synthesizer) NewChunk(model string, content string, finishReason string, usage *chat.Usage, done bool) any {
+	return ChatResponse{
+		Model:      model,
+		CreatedAt:  time.Now(),
+		Message:    chat.Message{Role: "assistant", Content: content},
+		DoneReason: finishReason,
+		Usage:      usage,
+		Done:       done,
+	}
+}
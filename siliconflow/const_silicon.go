@@ -9,4 +9,10 @@ Copyright (C) - All Rights Reserved
 
 const (
 	maxBufferSize = 512 * 1024
+
+	// 采样参数的取值范围, 参考https://docs.siliconflow.cn/api-reference/chat-completions/chat-completions
+	maxTemperature = 2
+	maxTopK        = 100
+	maxTokens      = 16384
+	maxStopCount   = 4
 )
@@ -0,0 +1,124 @@
+package siliconflow
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/lixianmin/agi/chat"
+	"github.com/lixianmin/agi/ifs"
+	"github.com/lixianmin/got/convert"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+type (
+	EmbeddingRequest struct {
+		Model          string   `json:"model"`
+		Input          []string `json:"input"`
+		EncodingFormat string   `json:"encoding_format,omitempty"`
+	}
+
+	EmbeddingResponse struct {
+		Object string          `json:"object"`
+		Model  string          `json:"model"`
+		Data   []EmbeddingData `json:"data"`
+		Usage  *chat.Usage     `json:"usage,omitempty"`
+	}
+
+	EmbeddingData struct {
+		Object    string    `json:"object"`
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	}
+
+	// Embedder 使用siliconflow的embeddings接口实现ifs.Embedder
+	Embedder struct {
+		client *SiliconClient
+		model  string
+	}
+)
+
+var _ ifs.Embedder = (*Embedder)(nil)
+
+func (my *SiliconClient) Embeddings(ctx context.Context, request *EmbeddingRequest) (*EmbeddingResponse, error) {
+	if request == nil {
+		return nil, ifs.ErrRequestIsNil
+	}
+
+	if request.Model == "" || len(request.Input) == 0 {
+		return nil, errors.New("invalid parameters")
+	}
+
+	var call = my.newCall(ifs.EndpointEmbeddings, request.Model, request)
+	var result, err = ifs.Invoke(ctx, my.interceptor, call, my.invoke)
+	if err != nil {
+		return nil, err
+	}
+
+	var response, ok = result.(*EmbeddingResponse)
+	if !ok {
+		return nil, ifs.ErrUnexpectedResult
+	}
+
+	return response, nil
+}
+
+func (my *SiliconClient) embeddings(ctx context.Context, request *EmbeddingRequest, extra http.Header) (*EmbeddingResponse, error) {
+	var response1, err1 = my.sendJsonRequest(ctx, http.MethodPost, "/embeddings", request, extra)
+	if err1 != nil {
+		return nil, err1
+	}
+	defer response1.Body.Close()
+
+	if response1.StatusCode != http.StatusOK {
+		return nil, ifs.NewStatusError(response1)
+	}
+
+	var bts, err2 = io.ReadAll(response1.Body)
+	if err2 != nil {
+		return nil, err2
+	}
+
+	var response EmbeddingResponse
+	if err3 := convert.FromJsonE(bts, &response); err3 != nil {
+		return nil, err3
+	}
+
+	// 按index排序, 保证与Input一一对应
+	sort.Slice(response.Data, func(i, j int) bool {
+		return response.Data[i].Index < response.Data[j].Index
+	})
+
+	return &response, nil
+}
+
+// NewEmbedder model比如BAAI/bge-m3
+func NewEmbedder(client *SiliconClient, model string) *Embedder {
+	return &Embedder{client: client, model: model}
+}
+
+func (my *Embedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	var response, err = my.client.Embeddings(ctx, &EmbeddingRequest{Model: my.model, Input: texts})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(response.Data) != len(texts) {
+		return nil, errors.New("embedding count mismatch")
+	}
+
+	var vectors = make([][]float32, len(response.Data))
+	for i, data := range response.Data {
+		vectors[i] = data.Embedding
+	}
+
+	return vectors, nil
+}
@@ -0,0 +1,229 @@
+package siliconflow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/lixianmin/agi/ifs"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+文件, batch与模型列表等管理接口与chat一样经过interceptor链, 因此鉴权, 脱敏, telemetry等interceptor同样生效
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+const (
+	PurposeBatch = "batch"
+
+	maxDownloadSize = 512 * 1024 * 1024
+)
+
+type (
+	File struct {
+		ID        string `json:"id"`
+		Object    string `json:"object"`
+		Bytes     int64  `json:"bytes"`
+		CreatedAt int64  `json:"created_at"`
+		Filename  string `json:"filename"`
+		Purpose   string `json:"purpose"`
+	}
+
+	fileList struct {
+		Object string  `json:"object"`
+		Data   []*File `json:"data"`
+	}
+
+	// ApiRequest 是文件, batch等管理接口的请求, Body为nil时不带body
+	ApiRequest struct {
+		Method      string
+		Path        string // 相对于baseUrl的路径; 以http开头时是完整的url, 比如结果文件的下载链接
+		Body        []byte
+		ContentType string
+	}
+
+	// envelope 是siliconflow部分管理接口的外层格式: {"code":20000,"message":"Ok","status":true,"data":{...}}
+	envelope struct {
+		Code    int             `json:"code"`
+		Message string          `json:"message"`
+		Status  *bool           `json:"status"`
+		Data    json.RawMessage `json:"data"`
+	}
+)
+
+// UploadFile 上传文件, purpose通常为PurposeBatch, content是jsonl格式的请求
+func (my *SiliconClient) UploadFile(ctx context.Context, filename string, purpose string, content []byte) (*File, error) {
+	if filename == "" || purpose == "" || len(content) == 0 {
+		return nil, errors.New("invalid parameters")
+	}
+
+	var requestBody bytes.Buffer
+	var writer = multipart.NewWriter(&requestBody)
+	_ = writer.WriteField("purpose", purpose)
+
+	var part, err1 = writer.CreateFormFile("file", filename)
+	if err1 != nil {
+		return nil, err1
+	}
+
+	if _, err2 := part.Write(content); err2 != nil {
+		return nil, err2
+	}
+	_ = writer.Close()
+
+	var request = &ApiRequest{Method: http.MethodPost, Path: "/files", Body: requestBody.Bytes(), ContentType: writer.FormDataContentType()}
+	var bts, err3 = my.callApi(ctx, ifs.EndpointFiles, request)
+	if err3 != nil {
+		return nil, err3
+	}
+
+	var file File
+	if err4 := decodeJson(bts, &file); err4 != nil {
+		return nil, err4
+	}
+
+	return &file, nil
+}
+
+// ListFiles purpose为空时返回所有文件
+func (my *SiliconClient) ListFiles(ctx context.Context, purpose string) ([]*File, error) {
+	var path = "/files"
+	if purpose != "" {
+		path += "?purpose=" + url.QueryEscape(purpose)
+	}
+
+	var list fileList
+	if err := my.getJson(ctx, ifs.EndpointFiles, http.MethodGet, path, nil, &list); err != nil {
+		return nil, err
+	}
+
+	return list.Data, nil
+}
+
+func (my *SiliconClient) DeleteFile(ctx context.Context, fileId string) error {
+	if fileId == "" {
+		return errors.New("invalid parameters")
+	}
+
+	return my.getJson(ctx, ifs.EndpointFiles, http.MethodDelete, "/files/"+url.PathEscape(fileId), nil, nil)
+}
+
+// DownloadFile 下载文件内容; fileId也可以是完整的下载链接
+func (my *SiliconClient) DownloadFile(ctx context.Context, fileId string) ([]byte, error) {
+	if fileId == "" {
+		return nil, errors.New("invalid parameters")
+	}
+
+	var path = "/files/" + url.PathEscape(fileId) + "/content"
+	if isExternal(fileId) {
+		path = fileId
+	}
+
+	return my.callApi(ctx, ifs.EndpointFiles, &ApiRequest{Method: http.MethodGet, Path: path})
+}
+
+// getJson 通过interceptor链发送json请求并把结果解析到result中, result为nil时丢弃body
+func (my *SiliconClient) getJson(ctx context.Context, endpoint string, method string, path string, request any, result any) error {
+	var apiRequest = &ApiRequest{Method: method, Path: path}
+	if request != nil {
+		var bts, err1 = json.Marshal(request)
+		if err1 != nil {
+			return err1
+		}
+
+		apiRequest.Body = bts
+		apiRequest.ContentType = "application/json"
+	}
+
+	var bts, err2 = my.callApi(ctx, endpoint, apiRequest)
+	if err2 != nil || result == nil {
+		return err2
+	}
+
+	return decodeJson(bts, result)
+}
+
+// callApi 通过interceptor链发送管理接口的请求, 返回响应的body
+func (my *SiliconClient) callApi(ctx context.Context, endpoint string, request *ApiRequest) ([]byte, error) {
+	var call = my.newCall(endpoint, "", request)
+	var result, err = ifs.Invoke(ctx, my.interceptor, call, my.invoke)
+	if err != nil {
+		return nil, err
+	}
+
+	var bts, ok = result.([]byte)
+	if !ok {
+		return nil, ifs.ErrUnexpectedResult
+	}
+
+	return bts, nil
+}
+
+func (my *SiliconClient) sendApiRequest(ctx context.Context, request *ApiRequest, extra http.Header) ([]byte, error) {
+	var requestUrl = my.baseUrl + request.Path
+	var external = isExternal(request.Path)
+	if external {
+		requestUrl = request.Path
+	}
+
+	var requestBody io.Reader
+	if request.Body != nil {
+		requestBody = bytes.NewReader(request.Body)
+	}
+
+	var request2, err1 = http.NewRequestWithContext(ctx, request.Method, requestUrl, requestBody)
+	if err1 != nil {
+		return nil, err1
+	}
+
+	var header = request2.Header
+	header.Set("accept", "application/json")
+	if request.ContentType != "" {
+		header.Set("Content-Type", request.ContentType)
+	}
+
+	// 外部链接通常是带签名的对象存储地址, 不能带上api key以及interceptor注入的header
+	if !external {
+		header.Set("authorization", my.authorization)
+		mergeHeader(header, extra)
+	}
+
+	var response, err2 = my.client.Do(request2)
+	if err2 != nil {
+		return nil, err2
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, ifs.NewStatusError(response)
+	}
+
+	return io.ReadAll(io.LimitReader(response.Body, maxDownloadSize))
+}
+
+// decodeJson 同时支持openai的原始格式与siliconflow的envelope格式
+func decodeJson(bts []byte, result any) error {
+	var wrapper envelope
+	if json.Unmarshal(bts, &wrapper) == nil && wrapper.Status != nil && len(wrapper.Data) > 0 {
+		if !*wrapper.Status {
+			return &ifs.StatusError{StatusCode: http.StatusOK, Message: wrapper.Message}
+		}
+		bts = wrapper.Data
+	}
+
+	return json.Unmarshal(bts, result)
+}
+
+func isExternal(path string) bool {
+	return strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://")
+}
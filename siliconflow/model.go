@@ -0,0 +1,77 @@
+package siliconflow
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/lixianmin/agi/ifs"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+const (
+	ModelQwen2Instruct   = "Qwen/Qwen2-7B-Instruct"
+	ModelBgeM3           = "BAAI/bge-m3"
+	ModelSenseVoiceSmall = "iic/SenseVoiceSmall"
+)
+
+// ListModels的type与sub_type过滤条件, 参考https://docs.siliconflow.cn/api-reference/models/get-model-list
+const (
+	ModelTypeText  = "text"
+	ModelTypeImage = "image"
+	ModelTypeAudio = "audio"
+	ModelTypeVideo = "video"
+
+	SubTypeChat         = "chat"
+	SubTypeEmbedding    = "embedding"
+	SubTypeReranker     = "reranker"
+	SubTypeTextToImage  = "text-to-image"
+	SubTypeImageToImage = "image-to-image"
+	SubTypeSpeechToText = "speech-to-text"
+	SubTypeTextToSpeech = "text-to-speech"
+	SubTypeTextToVideo  = "text-to-video"
+)
+
+type (
+	Model struct {
+		ID      string `json:"id"`
+		Object  string `json:"object"`
+		Created int64  `json:"created"`
+		OwnedBy string `json:"owned_by"`
+	}
+
+	modelList struct {
+		Object string   `json:"object"`
+		Data   []*Model `json:"data"`
+	}
+)
+
+// ListModels 返回可用的模型, modelType与subType为空时不过滤, 比如(ModelTypeText, SubTypeEmbedding)返回所有embedding模型
+func (my *SiliconClient) ListModels(ctx context.Context, modelType string, subType string) ([]*Model, error) {
+	var query = url.Values{}
+	if modelType != "" {
+		query.Set("type", modelType)
+	}
+
+	if subType != "" {
+		query.Set("sub_type", subType)
+	}
+
+	var path = "/models"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var list modelList
+	if err := my.getJson(ctx, ifs.EndpointModels, http.MethodGet, path, nil, &list); err != nil {
+		return nil, err
+	}
+
+	return list.Data, nil
+}
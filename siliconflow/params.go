@@ -0,0 +1,51 @@
+package siliconflow
+
+import (
+	"fmt"
+
+	"github.com/lixianmin/agi/chat"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+// resolveParams 返回一个新的请求: 以Request.Params(thread级默认值)为基础, 用请求中显式设置的字段覆盖,
+// 再按SiliconFlow的取值范围裁剪
+func (my *ChatRequest) resolveParams() (*ChatRequest, error) {
+	var params = chat.Params{}
+	if my.Params != nil {
+		params = *my.Params
+	}
+
+	params = params.Merge(chat.Params{
+		Temperature: my.Temperature,
+		TopP:        my.TopP,
+		TopK:        my.TopK,
+		MaxTokens:   my.MaxTokens,
+		Stop:        my.Stop,
+	})
+
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	if len(params.Stop) > maxStopCount {
+		return nil, fmt.Errorf("too many stop sequences: %d > %d", len(params.Stop), maxStopCount)
+	}
+
+	var resolved = *my
+	resolved.Params = nil
+	resolved.Temperature = params.Temperature
+	if resolved.Temperature != nil {
+		resolved.Temperature = chat.Float32(min(*resolved.Temperature, maxTemperature))
+	}
+	resolved.TopP = params.TopP
+	resolved.TopK = min(params.TopK, maxTopK)
+	resolved.MaxTokens = min(params.MaxTokens, maxTokens)
+	resolved.Stop = params.Stop
+	return &resolved, nil
+}
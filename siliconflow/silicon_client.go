@@ -27,7 +27,9 @@ Copyright (C) - All Rights Reserved
 type (
 	SiliconClient struct {
 		client        *http.Client
+		baseUrl       string
 		authorization string
+		interceptor   ifs.Interceptor
 	}
 
 	ChatRequest struct {
@@ -36,9 +38,17 @@ type (
 		FrequencyPenalty float32  `json:"frequency_penalty,omitempty"`
 		MaxTokens        int32    `json:"max_tokens,omitempty"`
 		Stop             []string `json:"stop,omitempty"`
-		Temperature      float32  `json:"temperature,omitempty"`
+		Temperature      *float32 `json:"temperature,omitempty"` // nil表示不设置, 0会被发送
 		TopK             int32    `json:"top_k,omitempty"`
 		TopP             float32  `json:"top_p,omitempty"`
+
+		// StreamOptions 由StreamChat设置, 调用方不需要关心
+		StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+	}
+
+	// StreamOptions 中include_usage为true时, 服务端在data: [DONE]之前单独发送一个choices为空的usage chunk
+	StreamOptions struct {
+		IncludeUsage bool `json:"include_usage"`
 	}
 
 	ChatResponse struct {
@@ -46,6 +56,7 @@ type (
 		CreatedAt  time.Time    `json:"created_at"`
 		Message    chat.Message `json:"message"`
 		DoneReason string       `json:"done_reason,omitempty"`
+		Usage      *chat.Usage  `json:"usage,omitempty"`
 
 		Done bool `json:"done"`
 	}
@@ -57,23 +68,46 @@ type (
 		Model             string          `json:"model"`
 		SystemFingerprint string          `json:"system_fingerprint"`
 		Choices           []ChunkedChoice `json:"choices"`
+		Usage             *chat.Usage     `json:"usage,omitempty"`
 	}
 
 	ChunkedChoice struct {
 		Index        int          `json:"index"`
 		Message      chat.Message `json:"message"`
+		Delta        chat.Message `json:"delta"`
 		FinishReason string       `json:"finish_reason"`
 	}
 
+	TranscriptionRequest struct {
+		Model string
+		Audio []byte
+	}
+
 	ChatResponseFunc func(ChatResponse) error
 )
 
 // NewSiliconClient 线程安全+无状态
-func NewSiliconClient(secretKey string) *SiliconClient {
-	var client = &http.Client{}
+func NewSiliconClient(secretKey string, opts ...ClientOption) *SiliconClient {
+	// 默认值
+	var options = clientOptions{
+		baseUrl: defaultBaseUrl,
+	}
+
+	// 初始化
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	var client = options.httpClient
+	if client == nil {
+		client = &http.Client{}
+	}
+
 	return &SiliconClient{
 		client:        client,
+		baseUrl:       options.baseUrl,
 		authorization: "Bearer " + secretKey,
+		interceptor:   ifs.ChainInterceptors(options.interceptors...),
 	}
 }
 
@@ -82,13 +116,135 @@ func (my *SiliconClient) Chat(ctx context.Context, request *ChatRequest) (*ChatC
 		return nil, ifs.ErrRequestIsNil
 	}
 
+	var resolved, err1 = request.resolveParams()
+	if err1 != nil {
+		return nil, err1
+	}
+
+	var call = my.newCall(ifs.EndpointChat, resolved.Model, resolved)
+	var result, err = ifs.Invoke(ctx, my.interceptor, call, my.invoke)
+	if err != nil {
+		return nil, err
+	}
+
+	var response, ok = result.(*ChatCompletionChunk)
+	if !ok {
+		return nil, ifs.ErrUnexpectedResult
+	}
+
+	return response, nil
+}
+
+func (my *SiliconClient) StreamChat(ctx context.Context, request *ChatRequest, fn ChatResponseFunc) error {
+	if request == nil {
+		return ifs.ErrRequestIsNil
+	}
+
+	if fn == nil {
+		return errors.New("fn is nil")
+	}
+
+	var resolved, err1 = request.resolveParams()
+	if err1 != nil {
+		return err1
+	}
+
+	var call = my.newCall(ifs.EndpointStreamChat, resolved.Model, resolved)
+	call.OnChunk = func(chunk any) error {
+		var chatResponse, ok = chunk.(ChatResponse)
+		if !ok {
+			return ifs.ErrUnexpectedResult
+		}
+
+		return fn(chatResponse)
+	}
+
+	var _, err = ifs.Invoke(ctx, my.interceptor, call, my.invoke)
+	return err
+}
+
+func (my *SiliconClient) TranscribeAudio(ctx context.Context, modelName string, audioData []byte) (string, error) {
+	if modelName == "" || len(audioData) == 0 {
+		return "", errors.New("invalid parameters")
+	}
+
+	var request = &TranscriptionRequest{Model: modelName, Audio: audioData}
+	var call = my.newCall(ifs.EndpointTranscription, modelName, request)
+	var result, err = ifs.Invoke(ctx, my.interceptor, call, my.invoke)
+	if err != nil {
+		return "", err
+	}
+
+	var text, ok = result.(string)
+	if !ok {
+		return "", ifs.ErrUnexpectedResult
+	}
+
+	return text, nil
+}
+
+func (my *SiliconClient) newCall(endpoint string, model string, request any) *ifs.Call {
+	return &ifs.Call{
+		Provider:    ifs.ProviderSiliconFlow,
+		Endpoint:    endpoint,
+		Model:       model,
+		Request:     request,
+		Header:      http.Header{},
+		Synthesizer: synthesizer{},
+	}
+}
+
+// invoke 是interceptor链的最内层, 真正发送http请求
+func (my *SiliconClient) invoke(ctx context.Context, call *ifs.Call) (any, error) {
+	switch call.Endpoint {
+	case ifs.EndpointChat:
+		var request, ok = call.Request.(*ChatRequest)
+		if !ok {
+			return nil, ifs.ErrUnexpectedRequest
+		}
+		return my.chat(ctx, request, call.Header)
+	case ifs.EndpointStreamChat:
+		var request, ok = call.Request.(*ChatRequest)
+		if !ok {
+			return nil, ifs.ErrUnexpectedRequest
+		}
+		return nil, my.streamChat(ctx, request, call.Header, call.OnChunk)
+	case ifs.EndpointTranscription:
+		var request, ok = call.Request.(*TranscriptionRequest)
+		if !ok {
+			return nil, ifs.ErrUnexpectedRequest
+		}
+		return my.transcribeAudio(ctx, request, call.Header)
+	case ifs.EndpointEmbeddings:
+		var request, ok = call.Request.(*EmbeddingRequest)
+		if !ok {
+			return nil, ifs.ErrUnexpectedRequest
+		}
+		return my.embeddings(ctx, request, call.Header)
+	case ifs.EndpointFiles, ifs.EndpointBatches, ifs.EndpointModels:
+		var request, ok = call.Request.(*ApiRequest)
+		if !ok {
+			return nil, ifs.ErrUnexpectedRequest
+		}
+		return my.sendApiRequest(ctx, request, call.Header)
+	default:
+		return nil, ifs.ErrUnknownEndpoint
+	}
+}
+
+func (my *SiliconClient) chat(ctx context.Context, request *ChatRequest, extra http.Header) (*ChatCompletionChunk, error) {
 	request.Stream = false
-	var response1, err1 = my.sendChatRequest(ctx, request)
+	request.StreamOptions = nil
+	var response1, err1 = my.sendChatRequest(ctx, request, extra)
 	if err1 != nil {
 		return nil, err1
 	}
 	defer response1.Body.Close()
 
+	if response1.StatusCode != http.StatusOK {
+		return nil, ifs.NewStatusError(response1)
+	}
+
 	var bts, err2 = io.ReadAll(response1.Body)
 	if err2 != nil {
 		return nil, err2
@@ -102,22 +258,23 @@ func (my *SiliconClient) Chat(ctx context.Context, request *ChatRequest) (*ChatC
 	return &response, nil
 }
 
-func (my *SiliconClient) StreamChat(ctx context.Context, request *ChatRequest, fn ChatResponseFunc) error {
-	if request == nil {
-		return ifs.ErrRequestIsNil
-	}
-
+func (my *SiliconClient) streamChat(ctx context.Context, request *ChatRequest, extra http.Header, fn func(chunk any) error) error {
 	if fn == nil {
 		return errors.New("fn is nil")
 	}
 
 	request.Stream = true
-	var response1, err1 = my.sendChatRequest(ctx, request)
+	request.StreamOptions = &StreamOptions{IncludeUsage: true}
+	var response1, err1 = my.sendChatRequest(ctx, request, extra)
 	if err1 != nil {
 		return err1
 	}
 	defer response1.Body.Close()
 
+	if response1.StatusCode != http.StatusOK {
+		return ifs.NewStatusError(response1)
+	}
+
 	var scanner = bufio.NewScanner(response1.Body)
 	// increase the buffer size to avoid running out of space
 	var scanBuf = make([]byte, 0, maxBufferSize)
@@ -147,66 +304,89 @@ func (my *SiliconClient) StreamChat(ctx context.Context, request *ChatRequest, f
 		var chunk ChatCompletionChunk
 		convert.FromJsonS(cleanLine, &chunk)
 
+		if len(chunk.Choices) == 0 && chunk.Usage != nil {
+			// include_usage时finish chunk之后还有一个带usage的chunk, choices为空
+			var chatResponse = ChatResponse{
+				Model:     chunk.Model,
+				CreatedAt: time.Unix(chunk.Created, 0),
+				Usage:     chunk.Usage,
+			}
+
+			if err4 := fn(chatResponse); err4 != nil {
+				return err4
+			}
+		}
+
 		if len(chunk.Choices) > 0 {
 			var choice = chunk.Choices[0]
+			// 按openai的格式, streaming的内容在delta中
+			var message = choice.Delta
+			if message.Content == "" && message.Role == "" {
+				message = choice.Message
+			}
+
 			var chatResponse = ChatResponse{
 				Model:     chunk.Model,
 				CreatedAt: time.Unix(chunk.Created, 0),
 				Message: chat.Message{
-					Role:    choice.Message.Role,
-					Content: choice.Message.Content,
+					Role:    message.Role,
+					Content: message.Content,
 				},
 				DoneReason: choice.FinishReason,
-				Done:       choice.FinishReason == "stop",
+				Usage:      chunk.Usage,
 			}
 
+			// 收到finish reason之后不能返回, usage chunk在它后面, 以data: [DONE]作为结束
 			if err5 := fn(chatResponse); err5 != nil {
 				return err5
 			}
-
-			if chatResponse.Done {
-				return nil
-			}
 		}
 	}
 
-	return nil
+	return scanner.Err()
 }
 
-func (my *SiliconClient) sendChatRequest(ctx context.Context, request *ChatRequest) (*http.Response, error) {
-	const requestUrl = "https://api.siliconflow.cn/v1/chat/completions"
-	var bts1, err1 = convert.ToJsonE(request)
-	if err1 != nil {
-		return nil, err1
+func (my *SiliconClient) sendChatRequest(ctx context.Context, request *ChatRequest, extra http.Header) (*http.Response, error) {
+	return my.sendJsonRequest(ctx, http.MethodPost, "/chat/completions", request, extra)
+}
+
+// sendJsonRequest 发送json格式的请求, request为nil时不带body
+func (my *SiliconClient) sendJsonRequest(ctx context.Context, method string, path string, request any, extra http.Header) (*http.Response, error) {
+	var requestUrl = my.baseUrl + path
+	var requestBody io.Reader
+	if request != nil {
+		var bts1, err1 = convert.ToJsonE(request)
+		if err1 != nil {
+			return nil, err1
+		}
+		requestBody = bytes.NewBuffer(bts1)
 	}
 
-	var requestBody = bytes.NewBuffer(bts1)
-	var request2, err2 = http.NewRequestWithContext(ctx, http.MethodPost, requestUrl, requestBody)
+	var request2, err2 = http.NewRequestWithContext(ctx, method, requestUrl, requestBody)
 	if err2 != nil {
 		return nil, err2
 	}
 
 	var header = request2.Header
 	header.Set("accept", "application/json")
-	header.Set("Content-Type", "application/json")
+	if request != nil {
+		header.Set("Content-Type", "application/json")
+	}
 	header.Set("authorization", my.authorization)
+	mergeHeader(header, extra)
 
 	var response3, err3 = my.client.Do(request2)
 	return response3, err3
 }
 
-func (my *SiliconClient) TranscribeAudio(ctx context.Context, modelName string, audioData []byte) (string, error) {
-	if modelName == "" || len(audioData) == 0 {
-		return "", errors.New("invalid parameters")
-	}
-
-	const requestUrl = "https://api.siliconflow.cn/v1/audio/transcriptions"
+func (my *SiliconClient) transcribeAudio(ctx context.Context, request *TranscriptionRequest, extra http.Header) (string, error) {
+	var requestUrl = my.baseUrl + "/audio/transcriptions"
 
 	var requestBody bytes.Buffer
 	var writer = multipart.NewWriter(&requestBody)
 
 	// Add form fields
-	_ = writer.WriteField("model", modelName)
+	_ = writer.WriteField("model", request.Model)
 
 	// 虽然文件扩展名是.mp3, 但上传.wav也是可以的, 至少iic/SenseVoiceSmall这个模型是可以的
 	var part1, err1 = writer.CreateFormFile("file", "audio.mp3")
@@ -215,7 +395,7 @@ func (my *SiliconClient) TranscribeAudio(ctx context.Context, modelName string,
 	}
 
 	// Write the byte array to the form file
-	var _, err2 = part1.Write(audioData)
+	var _, err2 = part1.Write(request.Audio)
 	if err2 != nil {
 		return "", err2
 	}
@@ -232,6 +412,7 @@ func (my *SiliconClient) TranscribeAudio(ctx context.Context, modelName string,
 	header.Set("accept", "application/json")
 	header.Set("authorization", my.authorization)
 	header.Set("Content-Type", writer.FormDataContentType())
+	mergeHeader(header, extra)
 
 	var response4, err4 = my.client.Do(request3)
 	if err4 != nil {
@@ -239,6 +420,10 @@ func (my *SiliconClient) TranscribeAudio(ctx context.Context, modelName string,
 	}
 	defer response4.Body.Close()
 
+	if response4.StatusCode != http.StatusOK {
+		return "", ifs.NewStatusError(response4)
+	}
+
 	var body, err5 = io.ReadAll(response4.Body)
 	if err5 != nil {
 		return "", err5
@@ -252,3 +437,12 @@ func (my *SiliconClient) TranscribeAudio(ctx context.Context, modelName string,
 	convert.FromJson(body, &output)
 	return output.Text, nil
 }
+
+func mergeHeader(header http.Header, extra http.Header) {
+	for key, values := range extra {
+		header.Del(key)
+		for _, value := range values {
+			header.Add(key, value)
+		}
+	}
+}
@@ -5,10 +5,12 @@ import (
 	"encoding/json"
 	"log"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/lixianmin/agi/cassette"
 	"github.com/lixianmin/agi/chat"
 )
 
@@ -29,12 +31,68 @@ func getSecretKey() string {
 	return sk
 }
 
+// newTestClient 默认回放testdata中的cassette; 设置AGI_CASSETTE=record并提供.env后可以录制真实的cassette
+func newTestClient(t *testing.T) *SiliconClient {
+	var mode = cassette.ModeFromEnv()
+	var recorder, err = cassette.NewRecorder(cassettePath(t, mode), cassette.WithMode(mode))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		if err := recorder.Stop(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	var sk = "sk-replay"
+	if recorder.Mode() != cassette.ModeReplay {
+		sk = getSecretKey()
+	}
+
+	return NewSiliconClient(sk, WithHttpClient(recorder.Client()))
+}
+
+// getAudioData 录制时必须通过AGI_AUDIO_FILE指定一段真实的录音. 回放时使用synthetic中的静音文件, 因为回放不比较multipart的内容,
+// 合成cassette中的识别结果与这段音频无关
+func getAudioData(t *testing.T) []byte {
+	var path = os.Getenv("AGI_AUDIO_FILE")
+	if path == "" {
+		if cassette.ModeFromEnv() != cassette.ModeReplay {
+			t.Skip("AGI_AUDIO_FILE is required to record a real transcription")
+		}
+
+		path = filepath.Join("testdata", "synthetic", "silence.wav")
+	}
+
+	var bts, err = os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return bts
+}
+
+// cassettePath 录制的cassette保存在testdata中, 回放时优先使用. testdata/synthetic中是按照api文档手写的合成数据,
+// 不是真实的录制, 只能验证client的解析逻辑, 不能证明与真实服务端的格式一致
+func cassettePath(t *testing.T, mode cassette.Mode) string {
+	var recorded = filepath.Join("testdata", t.Name()+".json")
+	if mode != cassette.ModeReplay {
+		return recorded
+	}
+
+	if _, err := os.Stat(recorded); err == nil {
+		return recorded
+	}
+
+	return filepath.Join("testdata", "synthetic", t.Name()+".json")
+}
+
 func TestChat(t *testing.T) {
-	var sk = getSecretKey()
-	var client = NewSiliconClient(sk)
+	var client = newTestClient(t)
 
 	const modelName = "Qwen/Qwen2-7B-Instruct"
-	var chatThread = chat.NewThread()
+	var chatThread, _ = chat.NewThread()
 	chatThread.SetPrompt("你是一个脑残人士, 无论我说什么, 你都回答`是的`. 现在开始: ")
 	chatThread.AddUserMessage("今天天气怎么样?")
 	chatThread.AddBotMessage("是的")
@@ -45,9 +103,9 @@ func TestChat(t *testing.T) {
 			Model:    modelName,
 			Messages: chatThread.CloneMessages(),
 		},
-		// Temperature: chatThread.GetTemperature(),
-		// TopK:        chatThread.GetTopK(),
-		// TopP:        chatThread.GetTopP(),
+		Temperature: chatThread.GetTemperature(),
+		TopK:        chatThread.GetTopK(),
+		TopP:        chatThread.GetTopP(),
 	}
 
 	var req, _ = json.Marshal(request)
@@ -58,29 +116,33 @@ func TestChat(t *testing.T) {
 
 	var response, err = client.Chat(ctx, request)
 	if err != nil {
-		log.Fatalf("chat error: %v", err)
-		return
+		t.Fatalf("chat error: %v", err)
 	}
 
 	var result, _ = json.Marshal(response)
 	println(string(result))
+
+	if response.GetContent() == "" {
+		t.Fatal("content is empty")
+	}
 }
 
 func TestTranscribeAudio(t *testing.T) {
-	var sk = getSecretKey()
-	var client = NewSiliconClient(sk)
+	var client = newTestClient(t)
 
 	const modelName = "iic/SenseVoiceSmall"
 
 	var ctx, cancel = context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
 
-	var bts, _ = os.ReadFile("C:\\Users\\user\\Downloads\\temp\\record_out.wav")
+	var bts = getAudioData(t)
 	var result, err = client.TranscribeAudio(ctx, modelName, bts)
 	if err != nil {
-		log.Fatalf("transcribe error: %v", err)
-		return
+		t.Fatalf("transcribe error: %v", err)
 	}
 
 	println(result)
+	if result == "" {
+		t.Fatal("result is empty")
+	}
 }
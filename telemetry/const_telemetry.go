@@ -0,0 +1,42 @@
+package telemetry
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+// 属性名遵循OpenTelemetry GenAI semantic conventions
+const (
+	AttrOperationName        = "gen_ai.operation.name"
+	AttrSystem               = "gen_ai.system"
+	AttrRequestModel         = "gen_ai.request.model"
+	AttrResponseModel        = "gen_ai.response.model"
+	AttrResponseFinishReason = "gen_ai.response.finish_reasons"
+	AttrUsageInputTokens     = "gen_ai.usage.input_tokens"
+	AttrUsageOutputTokens    = "gen_ai.usage.output_tokens"
+	AttrTokenType            = "gen_ai.token.type"
+	AttrStreaming            = "gen_ai.request.streaming"
+	AttrErrorType            = "error.type"
+)
+
+const (
+	MetricOperationDuration = "gen_ai.client.operation.duration"       // 秒
+	MetricTokenUsage        = "gen_ai.client.token.usage"              // token数, 以AttrTokenType区分input/output
+	MetricTimeToFirstToken  = "gen_ai.server.time_to_first_token"      // 秒, 只有streaming调用才有
+	MetricTokensPerSecond   = "gen_ai.client.output_tokens_per_second" // 输出token的生成速度
+	MetricRequests          = "gen_ai.client.requests"                 // 调用次数
+	MetricErrors            = "gen_ai.client.errors"                   // 出错次数
+)
+
+const (
+	tokenTypeInput  = "input"
+	tokenTypeOutput = "output"
+)
+
+const (
+	errorTypeCanceled = "canceled"
+	errorTypeTimeout  = "timeout"
+	errorTypeOther    = "_OTHER"
+)
@@ -0,0 +1,195 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/lixianmin/agi/ifs"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+type (
+	instruments struct {
+		duration        Histogram
+		tokenUsage      Histogram
+		timeToFirst     Histogram
+		tokensPerSecond Histogram
+		requests        Counter
+		errors          Counter
+	}
+
+	// streamObserver 记录streaming过程中的首token时间以及最后出现的model, finish reason与usage
+	streamObserver struct {
+		firstTime    time.Time
+		model        string
+		finishReason string
+		input        int
+		output       int
+	}
+)
+
+// NewInterceptor 为每一次调用创建span并记录metrics, tracer与meter可以为nil
+func NewInterceptor(tracer Tracer, meter Meter) ifs.Interceptor {
+	if tracer == nil {
+		tracer = noopTracer{}
+	}
+
+	if meter == nil {
+		meter = noopMeter{}
+	}
+
+	var ins = &instruments{
+		duration:        meter.Histogram(MetricOperationDuration),
+		tokenUsage:      meter.Histogram(MetricTokenUsage),
+		timeToFirst:     meter.Histogram(MetricTimeToFirstToken),
+		tokensPerSecond: meter.Histogram(MetricTokensPerSecond),
+		requests:        meter.Counter(MetricRequests),
+		errors:          meter.Counter(MetricErrors),
+	}
+
+	return func(ctx context.Context, call *ifs.Call, next ifs.Invoker) (any, error) {
+		var operation = operationName(call.Endpoint)
+		var ctx2, span = tracer.Start(ctx, operation+" "+call.Model)
+		defer span.End()
+
+		var streaming = call.OnChunk != nil
+		span.SetAttributes(
+			Attr(AttrOperationName, operation),
+			Attr(AttrSystem, call.Provider),
+			Attr(AttrRequestModel, call.Model),
+			Attr(AttrStreaming, streaming),
+		)
+
+		var observer = &streamObserver{}
+		if streaming {
+			var onChunk = call.OnChunk
+			call.OnChunk = func(chunk any) error {
+				observer.observeFirst(chunk)
+				observer.observe(chunk)
+				return onChunk(chunk)
+			}
+		}
+
+		var startTime = time.Now()
+		var result, err = next(ctx2, call)
+		var elapsed = time.Since(startTime)
+
+		if completion, ok := result.(ifs.Completion); ok && !isNil(completion) {
+			observer.observe(completion)
+		}
+
+		var attrs = []Attribute{
+			Attr(AttrOperationName, operation),
+			Attr(AttrSystem, call.Provider),
+			Attr(AttrRequestModel, call.Model),
+		}
+
+		if observer.model != "" {
+			attrs = append(attrs, Attr(AttrResponseModel, observer.model))
+		}
+
+		ins.requests.Add(ctx, 1, attrs...)
+		if err != nil {
+			var errorType = errorTypeOf(err)
+			span.RecordError(err)
+			span.SetAttributes(Attr(AttrErrorType, errorType))
+			attrs = append(attrs, Attr(AttrErrorType, errorType))
+			ins.errors.Add(ctx, 1, attrs...)
+			ins.duration.Record(ctx, elapsed.Seconds(), attrs...)
+			return result, err
+		}
+
+		ins.duration.Record(ctx, elapsed.Seconds(), attrs...)
+		if observer.model != "" {
+			span.SetAttributes(Attr(AttrResponseModel, observer.model))
+		}
+
+		if observer.finishReason != "" {
+			span.SetAttributes(Attr(AttrResponseFinishReason, []string{observer.finishReason}))
+		}
+
+		if observer.input > 0 || observer.output > 0 {
+			span.SetAttributes(Attr(AttrUsageInputTokens, observer.input), Attr(AttrUsageOutputTokens, observer.output))
+			ins.tokenUsage.Record(ctx, float64(observer.input), append(attrs, Attr(AttrTokenType, tokenTypeInput))...)
+			ins.tokenUsage.Record(ctx, float64(observer.output), append(attrs, Attr(AttrTokenType, tokenTypeOutput))...)
+		}
+
+		// 输出速度不包括首token之前的排队与prefill时间
+		var generation = elapsed
+		if !observer.firstTime.IsZero() {
+			var ttft = observer.firstTime.Sub(startTime)
+			ins.timeToFirst.Record(ctx, ttft.Seconds(), attrs...)
+			generation = elapsed - ttft
+		}
+
+		if observer.output > 0 && generation > 0 {
+			ins.tokensPerSecond.Record(ctx, float64(observer.output)/generation.Seconds(), attrs...)
+		}
+
+		return result, err
+	}
+}
+
+func (my *streamObserver) observeFirst(chunk any) {
+	if completion, ok := chunk.(ifs.Completion); ok && my.firstTime.IsZero() && completion.GetContent() != "" {
+		my.firstTime = time.Now()
+	}
+}
+
+func (my *streamObserver) observe(chunk any) {
+	var completion, ok = chunk.(ifs.Completion)
+	if !ok {
+		return
+	}
+
+	if model := completion.GetModel(); model != "" {
+		my.model = model
+	}
+
+	if reason := completion.GetFinishReason(); reason != "" {
+		my.finishReason = reason
+	}
+
+	if usage := completion.GetUsage(); usage != nil {
+		my.input = usage.PromptTokens
+		my.output = usage.CompletionTokens
+	}
+}
+
+func operationName(endpoint string) string {
+	switch endpoint {
+	case ifs.EndpointChat, ifs.EndpointStreamChat:
+		return "chat"
+	default:
+		return endpoint
+	}
+}
+
+// errorTypeOf 按semantic conventions取值: http错误使用状态码, 取消与超时使用固定的字符串, 其它为_OTHER, 保证metrics的基数可控
+func errorTypeOf(err error) string {
+	var statusErr *ifs.StatusError
+	switch {
+	case errors.As(err, &statusErr):
+		return strconv.Itoa(statusErr.StatusCode)
+	case errors.Is(err, context.Canceled):
+		return errorTypeCanceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return errorTypeTimeout
+	default:
+		return errorTypeOther
+	}
+}
+
+func isNil(v any) bool {
+	var value = reflect.ValueOf(v)
+	return value.Kind() == reflect.Pointer && value.IsNil()
+}
@@ -0,0 +1,128 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/lixianmin/agi/agitest"
+	"github.com/lixianmin/agi/chat"
+	"github.com/lixianmin/agi/deepseek"
+	"github.com/lixianmin/agi/ifs"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+func TestStreamChat(t *testing.T) {
+	var exporter = NewMemoryExporter()
+	var mock = func(ctx context.Context, call *ifs.Call, next ifs.Invoker) (any, error) {
+		time.Sleep(10 * time.Millisecond)
+		_ = call.OnChunk(deepseek.ChatResponse{Model: "deepseek-chat", Message: chat.Message{Content: "是"}})
+		time.Sleep(10 * time.Millisecond)
+		_ = call.OnChunk(deepseek.ChatResponse{Model: "deepseek-chat", Message: chat.Message{Content: "的"}, DoneReason: "stop",
+			Usage: &chat.Usage{PromptTokens: 12, CompletionTokens: 2, TotalTokens: 14}, Done: true})
+		return nil, nil
+	}
+
+	var client = deepseek.NewDeepSeekClient("", deepseek.WithInterceptors(NewInterceptor(exporter, exporter), mock))
+	var request = &deepseek.ChatRequest{Request: chat.Request{Model: "deepseek-chat"}}
+	var err = client.StreamChat(context.Background(), request, func(response deepseek.ChatResponse) error {
+		return nil
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var spans = exporter.Spans()
+	if len(spans) != 1 || spans[0].Name != "chat deepseek-chat" {
+		t.Fatalf("spans=%v", spans)
+	}
+
+	var attributes = spans[0].Attributes
+	if attributes[AttrSystem] != ifs.ProviderDeepSeek || attributes[AttrUsageOutputTokens] != 2 || attributes[AttrResponseModel] != "deepseek-chat" {
+		t.Fatalf("attributes=%v", attributes)
+	}
+
+	if ttft := exporter.Points(MetricTimeToFirstToken); len(ttft) != 1 || ttft[0].Value < 0.01 {
+		t.Fatalf("ttft=%v", ttft)
+	}
+
+	if exporter.Sum(MetricTokenUsage) != 14 || len(exporter.Points(MetricTokensPerSecond)) != 1 {
+		t.Fatal("token metrics are not recorded")
+	}
+}
+
+// TestStreamUsage 经过真实的http stream, usage在finish chunk之后单独发送
+func TestStreamUsage(t *testing.T) {
+	var server = agitest.NewTestServer(t)
+	server.SetDefaultReply(agitest.Reply{Content: "是的", ChunkDelay: 5 * time.Millisecond, Usage: &chat.Usage{PromptTokens: 12, CompletionTokens: 2, TotalTokens: 14}})
+
+	var exporter = NewMemoryExporter()
+	var client = deepseek.NewDeepSeekClient("sk-test", deepseek.WithBaseUrl(server.BaseUrl()), deepseek.WithInterceptors(NewInterceptor(exporter, exporter)))
+	var request = &deepseek.ChatRequest{Request: chat.Request{Model: "deepseek-chat", Messages: []*chat.Message{{Role: "user", Content: "你好"}}}}
+	if err := client.StreamChat(context.Background(), request, func(response deepseek.ChatResponse) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+
+	if last := server.LastChatRequest(); last.StreamOptions == nil || !last.StreamOptions.IncludeUsage {
+		t.Fatal("include_usage is not requested")
+	}
+
+	var attributes = exporter.Spans()[0].Attributes
+	if attributes[AttrUsageInputTokens] != 12 || attributes[AttrUsageOutputTokens] != 2 || exporter.Sum(MetricTokenUsage) != 14 {
+		t.Fatalf("attributes=%v", attributes)
+	}
+
+	if len(exporter.Points(MetricTokensPerSecond)) != 1 {
+		t.Fatal("tokens per second is not recorded")
+	}
+}
+
+func TestChatError(t *testing.T) {
+	var exporter = NewMemoryExporter()
+	var mock = func(ctx context.Context, call *ifs.Call, next ifs.Invoker) (any, error) {
+		return nil, errors.New("rate limited")
+	}
+
+	var client = deepseek.NewDeepSeekClient("", deepseek.WithInterceptors(NewInterceptor(exporter, exporter), mock))
+	var _, err = client.Chat(context.Background(), &deepseek.ChatRequest{Request: chat.Request{Model: "deepseek-chat"}})
+	if err == nil {
+		t.Fatal("error expected")
+	}
+
+	if exporter.Sum(MetricErrors) != 1 || exporter.Spans()[0].Err == nil {
+		t.Fatal("error is not recorded")
+	}
+
+	if errorType := exporter.Spans()[0].Attributes[AttrErrorType]; errorType != errorTypeOther {
+		t.Fatalf("errorType=%v", errorType)
+	}
+}
+
+func TestErrorType(t *testing.T) {
+	var statusErr = fmt.Errorf("chat failed: %w", &ifs.StatusError{StatusCode: http.StatusTooManyRequests})
+	var timeout, cancel = context.WithTimeout(context.Background(), 0)
+	defer cancel()
+
+	var cases = map[error]string{
+		statusErr:               "429",
+		context.Canceled:        errorTypeCanceled,
+		timeout.Err():           errorTypeTimeout,
+		errors.New("malformed"): errorTypeOther,
+	}
+
+	for err, expected := range cases {
+		if errorType := errorTypeOf(err); errorType != expected {
+			t.Fatalf("err=%v, errorType=%s, expected=%s", err, errorType, expected)
+		}
+	}
+}
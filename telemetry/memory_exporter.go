@@ -0,0 +1,164 @@
+package telemetry
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+type (
+	// MemoryExporter 同时实现Tracer与Meter, 把数据保存在内存中, 用于测试或者进程内的简单统计
+	MemoryExporter struct {
+		spans  []*SpanData
+		points []MetricPoint
+		m      sync.Mutex
+	}
+
+	SpanData struct {
+		Name       string
+		Attributes map[string]any
+		Err        error
+		StartTime  time.Time
+		EndTime    time.Time
+	}
+
+	MetricPoint struct {
+		Name       string
+		Value      float64
+		Attributes map[string]any
+		Time       time.Time
+	}
+
+	memorySpan struct {
+		exporter *MemoryExporter
+		data     *SpanData
+		once     sync.Once
+	}
+
+	memoryMetric struct {
+		exporter *MemoryExporter
+		name     string
+	}
+)
+
+func NewMemoryExporter() *MemoryExporter {
+	return &MemoryExporter{}
+}
+
+func (my *MemoryExporter) Start(ctx context.Context, name string) (context.Context, Span) {
+	var span = &memorySpan{
+		exporter: my,
+		data: &SpanData{
+			Name:       name,
+			Attributes: make(map[string]any),
+			StartTime:  time.Now(),
+		},
+	}
+
+	return ctx, span
+}
+
+func (my *MemoryExporter) Counter(name string) Counter {
+	return &memoryMetric{exporter: my, name: name}
+}
+
+func (my *MemoryExporter) Histogram(name string) Histogram {
+	return &memoryMetric{exporter: my, name: name}
+}
+
+// Spans 返回已经结束的span
+func (my *MemoryExporter) Spans() []*SpanData {
+	my.m.Lock()
+	defer my.m.Unlock()
+
+	var cloned = make([]*SpanData, len(my.spans))
+	copy(cloned, my.spans)
+	return cloned
+}
+
+// Points 返回名为name的所有数据点
+func (my *MemoryExporter) Points(name string) []MetricPoint {
+	my.m.Lock()
+	defer my.m.Unlock()
+
+	var results []MetricPoint
+	for _, point := range my.points {
+		if point.Name == name {
+			results = append(results, point)
+		}
+	}
+
+	return results
+}
+
+// Sum 返回名为name的所有数据点之和
+func (my *MemoryExporter) Sum(name string) float64 {
+	var sum float64
+	for _, point := range my.Points(name) {
+		sum += point.Value
+	}
+
+	return sum
+}
+
+func (my *MemoryExporter) Reset() {
+	my.m.Lock()
+	my.spans = nil
+	my.points = nil
+	my.m.Unlock()
+}
+
+func (my *memorySpan) SetAttributes(attrs ...Attribute) {
+	my.exporter.m.Lock()
+	for _, attr := range attrs {
+		my.data.Attributes[attr.Key] = attr.Value
+	}
+	my.exporter.m.Unlock()
+}
+
+func (my *memorySpan) RecordError(err error) {
+	my.exporter.m.Lock()
+	my.data.Err = err
+	my.exporter.m.Unlock()
+}
+
+func (my *memorySpan) End() {
+	my.once.Do(func() {
+		my.exporter.m.Lock()
+		my.data.EndTime = time.Now()
+		my.exporter.spans = append(my.exporter.spans, my.data)
+		my.exporter.m.Unlock()
+	})
+}
+
+func (my *memoryMetric) Add(ctx context.Context, value float64, attrs ...Attribute) {
+	my.record(value, attrs)
+}
+
+func (my *memoryMetric) Record(ctx context.Context, value float64, attrs ...Attribute) {
+	my.record(value, attrs)
+}
+
+func (my *memoryMetric) record(value float64, attrs []Attribute) {
+	var point = MetricPoint{
+		Name:       my.name,
+		Value:      value,
+		Attributes: make(map[string]any, len(attrs)),
+		Time:       time.Now(),
+	}
+
+	for _, attr := range attrs {
+		point.Attributes[attr.Key] = attr.Value
+	}
+
+	my.exporter.m.Lock()
+	my.exporter.points = append(my.exporter.points, point)
+	my.exporter.m.Unlock()
+}
@@ -0,0 +1,64 @@
+package telemetry
+
+import "context"
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+// 这里只定义最小的接口, 可以很容易地适配到OpenTelemetry SDK, 测试中则使用MemoryExporter
+type (
+	Attribute struct {
+		Key   string
+		Value any
+	}
+
+	Tracer interface {
+		Start(ctx context.Context, name string) (context.Context, Span)
+	}
+
+	Span interface {
+		SetAttributes(attrs ...Attribute)
+		RecordError(err error)
+		End()
+	}
+
+	Meter interface {
+		Counter(name string) Counter
+		Histogram(name string) Histogram
+	}
+
+	Counter interface {
+		Add(ctx context.Context, value float64, attrs ...Attribute)
+	}
+
+	Histogram interface {
+		Record(ctx context.Context, value float64, attrs ...Attribute)
+	}
+)
+
+func Attr(key string, value any) Attribute {
+	return Attribute{Key: key, Value: value}
+}
+
+type (
+	noopTracer struct{}
+	noopSpan   struct{}
+	noopMeter  struct{}
+	noopMetric struct{}
+)
+
+func (noopTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+func (noopSpan) SetAttributes(attrs ...Attribute)                                {}
+func (noopSpan) RecordError(err error)                                           {}
+func (noopSpan) End()                                                            {}
+func (noopMeter) Counter(name string) Counter                                    { return noopMetric{} }
+func (noopMeter) Histogram(name string) Histogram                                { return noopMetric{} }
+func (noopMetric) Add(ctx context.Context, value float64, attrs ...Attribute)    {}
+func (noopMetric) Record(ctx context.Context, value float64, attrs ...Attribute) {}
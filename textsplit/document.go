@@ -0,0 +1,167 @@
+package textsplit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+const (
+	TypeText     = "text"
+	TypeMarkdown = "markdown"
+	TypeHtml     = "html"
+	TypeGo       = "go"
+)
+
+const (
+	MetaSource   = "source"
+	MetaType     = "type"
+	MetaHeadings = "headings" // markdown的标题路径, 比如"安装 > Linux"
+	MetaIndex    = "index"    // chunk在文档中的序号
+)
+
+const headingSeparator = " > "
+
+type (
+	Document struct {
+		Source   string // 文件路径或者url, 参与chunk ID的计算
+		Type     string // TypeText, TypeMarkdown, TypeHtml, TypeGo
+		Content  string
+		Metadata map[string]string
+	}
+
+	Chunk struct {
+		ID       string // 由source, 标题路径与内容计算得到, 内容不变时ID不变
+		Content  string
+		Metadata map[string]string
+	}
+)
+
+// LoadFile 读取文件, 根据扩展名判断类型
+func LoadFile(path string) (*Document, error) {
+	var bts, err = os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Document{
+		Source:  filepath.ToSlash(path),
+		Type:    DetectType(path),
+		Content: string(bts),
+	}, nil
+}
+
+// LoadDir 递归读取dir下扩展名在exts中的文件, exts为空时读取.md, .markdown, .txt, .html, .htm, .go
+func LoadDir(dir string, exts ...string) ([]*Document, error) {
+	if len(exts) == 0 {
+		exts = []string{".md", ".markdown", ".txt", ".html", ".htm", ".go"}
+	}
+
+	var documents []*Document
+	var err = filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		var ext = strings.ToLower(filepath.Ext(path))
+		for _, item := range exts {
+			if ext == item {
+				var document, err2 = LoadFile(path)
+				if err2 != nil {
+					return err2
+				}
+				documents = append(documents, document)
+				break
+			}
+		}
+
+		return nil
+	})
+
+	sort.Slice(documents, func(i, j int) bool {
+		return documents[i].Source < documents[j].Source
+	})
+
+	return documents, err
+}
+
+func DetectType(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown":
+		return TypeMarkdown
+	case ".html", ".htm":
+		return TypeHtml
+	case ".go":
+		return TypeGo
+	default:
+		return TypeText
+	}
+}
+
+// Split 根据文档类型选择切分方式, 返回的chunk带有source, type, headings, index等metadata
+func (my *Splitter) Split(document *Document) []*Chunk {
+	var sections []section
+	switch document.Type {
+	case TypeMarkdown:
+		sections = my.splitMarkdown(document.Content)
+	case TypeHtml:
+		sections = my.splitMarkdown(htmlToMarkdown(document.Content))
+	case TypeGo:
+		sections = []section{{texts: my.splitWith(document.Content, GoSeparators)}}
+	default:
+		sections = []section{{texts: my.SplitText(document.Content)}}
+	}
+
+	var chunks []*Chunk
+	var seen = make(map[string]int)
+	for _, item := range sections {
+		var headings = strings.Join(item.headings, headingSeparator)
+		for _, text := range item.texts {
+			var metadata = make(map[string]string, len(document.Metadata)+4)
+			for key, value := range document.Metadata {
+				metadata[key] = value
+			}
+
+			metadata[MetaSource] = document.Source
+			metadata[MetaType] = document.Type
+			metadata[MetaIndex] = strconv.Itoa(len(chunks))
+			if headings != "" {
+				metadata[MetaHeadings] = headings
+			}
+
+			var id = chunkID(document.Source, headings, text)
+			// 同一文档中内容完全相同的chunk, 依次加上序号
+			if count := seen[id]; count > 0 {
+				seen[id] = count + 1
+				id += "-" + strconv.Itoa(count+1)
+			} else {
+				seen[id] = 1
+			}
+
+			chunks = append(chunks, &Chunk{ID: id, Content: text, Metadata: metadata})
+		}
+	}
+
+	return chunks
+}
+
+func chunkID(source string, headings string, content string) string {
+	var hash = sha256.New()
+	hash.Write([]byte(source))
+	hash.Write([]byte{0})
+	hash.Write([]byte(headings))
+	hash.Write([]byte{0})
+	hash.Write([]byte(content))
+	return hex.EncodeToString(hash.Sum(nil))[:16]
+}
@@ -0,0 +1,49 @@
+package textsplit
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+var (
+	htmlInvisible = regexp.MustCompile(`(?is)<(script|style|noscript|head|template)[^>]*>.*?</(script|style|noscript|head|template)>|<!--.*?-->`)
+	htmlHeading   = regexp.MustCompile(`(?is)<h([1-6])[^>]*>(.*?)</h[1-6]>`)
+	htmlListItem  = regexp.MustCompile(`(?i)<li[^>]*>`)
+	htmlBlock     = regexp.MustCompile(`(?i)</?(p|div|section|article|main|header|footer|nav|aside|ul|ol|table|tr|blockquote|pre|br|hr)[^>]*>`)
+	htmlTag       = regexp.MustCompile(`(?s)<[^>]+>`)
+	blankLines    = regexp.MustCompile(`\n[ \t]*(\n[ \t]*)+`)
+	inlineSpaces  = regexp.MustCompile(`[ \t]+`)
+)
+
+// htmlToMarkdown 把html转换为近似的markdown: 标题变成#, 块级元素变成段落, 去掉其余的标签,
+// 这样就可以复用markdown的标题路径
+func htmlToMarkdown(text string) string {
+	text = htmlInvisible.ReplaceAllString(text, "")
+	text = htmlHeading.ReplaceAllStringFunc(text, func(match string) string {
+		var groups = htmlHeading.FindStringSubmatch(match)
+		var level = int(groups[1][0] - '0')
+		var title = strings.TrimSpace(htmlTag.ReplaceAllString(groups[2], ""))
+		return "\n\n" + strings.Repeat("#", level) + " " + title + "\n\n"
+	})
+	text = htmlListItem.ReplaceAllString(text, "\n- ")
+	text = htmlBlock.ReplaceAllString(text, "\n\n")
+	text = htmlTag.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+	text = inlineSpaces.ReplaceAllString(text, " ")
+	text = blankLines.ReplaceAllString(text, "\n\n")
+
+	var lines = strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,43 @@
+package textsplit
+
+import "unicode"
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+// LengthFunc 计算文本的长度, 通常是token数
+type LengthFunc func(text string) int
+
+// EstimateTokens 粗略估算token数: 每个CJK字符算1个token, 其余字符按4个字符1个token计算.
+// 需要精确值时可以通过WithLengthFunc()传入真正的tokenizer
+func EstimateTokens(text string) int {
+	var cjk, others = 0, 0
+	for _, r := range text {
+		if isCJK(r) {
+			cjk++
+		} else {
+			others++
+		}
+	}
+
+	return cjk + (others+3)/4
+}
+
+// RuneCount 按字符计算长度
+func RuneCount(text string) int {
+	var count = 0
+	for range text {
+		count++
+	}
+
+	return count
+}
+
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) ||
+		unicode.Is(unicode.Hangul, r) || r >= 0x3000 && r <= 0x303F || r >= 0xFF00 && r <= 0xFFEF
+}
@@ -0,0 +1,79 @@
+package textsplit
+
+import (
+	"strings"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+// section 是同一个标题下的正文切分结果
+type section struct {
+	headings []string
+	texts    []string
+}
+
+// SplitMarkdown 按标题把markdown分成若干节, 每节再单独切分, 返回的chunk不会跨越标题
+func (my *Splitter) SplitMarkdown(text string) []*Chunk {
+	return my.Split(&Document{Type: TypeMarkdown, Content: text})
+}
+
+func (my *Splitter) splitMarkdown(text string) []section {
+	var sections []section
+	var headings []string
+	var levels []int
+	var body strings.Builder
+	var inFence = false
+
+	var flush = func() {
+		var texts = my.SplitText(body.String())
+		if len(texts) > 0 {
+			sections = append(sections, section{headings: append([]string(nil), headings...), texts: texts})
+		}
+		body.Reset()
+	}
+
+	for _, line := range strings.SplitAfter(text, "\n") {
+		var trimmed = strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+		}
+
+		if level, title := parseHeading(trimmed); !inFence && level > 0 {
+			flush()
+			// 弹出同级以及更低级的标题, 允许跳级(比如#之后直接是###)
+			for len(levels) > 0 && levels[len(levels)-1] >= level {
+				levels = levels[:len(levels)-1]
+				headings = headings[:len(headings)-1]
+			}
+
+			levels = append(levels, level)
+			headings = append(headings, title)
+			continue
+		}
+
+		body.WriteString(line)
+	}
+
+	flush()
+	return sections
+}
+
+// parseHeading 解析ATX风格的标题, 比如"## 安装", 返回层级与标题; 不是标题时返回0
+func parseHeading(line string) (int, string) {
+	var level = 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+
+	if level == 0 || level > 6 || level < len(line) && line[level] != ' ' && line[level] != '\t' {
+		return 0, ""
+	}
+
+	var title = strings.TrimSpace(strings.TrimRight(strings.TrimSpace(line[level:]), "#"))
+	return level, title
+}
@@ -0,0 +1,252 @@
+package textsplit
+
+import (
+	"strings"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+type (
+	// Splitter 递归地按分隔符切分文本: 先尝试段落, 再尝试句子(包括中文标点), 最后按字符切分,
+	// 保证每个chunk的长度不超过chunkSize, 相邻chunk之间保留不超过overlap的重叠
+	Splitter struct {
+		chunkSize  int
+		overlap    int
+		length     LengthFunc
+		separators [][]string
+	}
+
+	splitterOptions struct {
+		chunkSize  int
+		overlap    int
+		length     LengthFunc
+		separators [][]string
+	}
+
+	SplitterOption func(*splitterOptions)
+)
+
+var (
+	// DefaultSeparators 从粗到细分为若干级: 段落, 行, 句子, 分句, 短语, 单词, 字符; 同一级内的分隔符同时生效, 中英文标点都有
+	DefaultSeparators = [][]string{{"\n\n"}, {"\n"}, {"。", "！", "？", "!", "?", ". "}, {"；", "; "}, {"，", "、", ", "}, {" "}, {""}}
+
+	// GoSeparators 优先在顶层声明处切分
+	GoSeparators = [][]string{{"\nfunc ", "\ntype ", "\nvar ", "\nconst "}, {"\n\n"}, {"\n"}, {" "}, {""}}
+)
+
+// WithChunkSize 每个chunk的最大长度, 单位由LengthFunc决定, 默认为512
+func WithChunkSize(size int) SplitterOption {
+	return func(options *splitterOptions) {
+		if size > 0 {
+			options.chunkSize = size
+		}
+	}
+}
+
+// WithOverlap 相邻chunk之间的重叠长度, 默认为64, 必须小于chunkSize
+func WithOverlap(overlap int) SplitterOption {
+	return func(options *splitterOptions) {
+		if overlap >= 0 {
+			options.overlap = overlap
+		}
+	}
+}
+
+// WithLengthFunc 默认为EstimateTokens
+func WithLengthFunc(length LengthFunc) SplitterOption {
+	return func(options *splitterOptions) {
+		if length != nil {
+			options.length = length
+		}
+	}
+}
+
+// WithSeparators 每个参数是一级分隔符, 从粗到细排列, 空字符串表示按字符切分
+func WithSeparators(separators ...[]string) SplitterOption {
+	return func(options *splitterOptions) {
+		if len(separators) > 0 {
+			options.separators = separators
+		}
+	}
+}
+
+func NewSplitter(opts ...SplitterOption) *Splitter {
+	// 默认值
+	var options = splitterOptions{
+		chunkSize:  512,
+		overlap:    64,
+		length:     EstimateTokens,
+		separators: DefaultSeparators,
+	}
+
+	// 初始化
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return &Splitter{
+		chunkSize:  options.chunkSize,
+		overlap:    min(options.overlap, options.chunkSize/2),
+		length:     options.length,
+		separators: options.separators,
+	}
+}
+
+// SplitText 使用默认的分隔符切分纯文本
+func (my *Splitter) SplitText(text string) []string {
+	return my.splitWith(text, my.separators)
+}
+
+func (my *Splitter) splitWith(text string, separators [][]string) []string {
+	var chunks = my.split(text, separators)
+	var results = make([]string, 0, len(chunks))
+	for _, chunk := range chunks {
+		if chunk = strings.TrimSpace(chunk); chunk != "" {
+			results = append(results, chunk)
+		}
+	}
+
+	return results
+}
+
+func (my *Splitter) split(text string, separators [][]string) []string {
+	if my.length(text) <= my.chunkSize {
+		return []string{text}
+	}
+
+	// 找到第一级在text中出现的分隔符
+	var level []string
+	var rest [][]string
+	for i, candidates := range separators {
+		if containsAny(text, candidates) {
+			level = candidates
+			rest = separators[i+1:]
+			break
+		}
+	}
+
+	var results []string
+	var pending []string
+	for _, piece := range splitKeep(text, level) {
+		if my.length(piece) <= my.chunkSize {
+			pending = append(pending, piece)
+			continue
+		}
+
+		// piece太长, 先合并之前的片段, 再用更细的分隔符递归切分piece
+		results = append(results, my.merge(pending)...)
+		pending = nil
+		if len(rest) > 0 {
+			results = append(results, my.split(piece, rest)...)
+		} else {
+			results = append(results, piece)
+		}
+	}
+
+	return append(results, my.merge(pending)...)
+}
+
+// merge 把小片段拼接为不超过chunkSize的chunk, 并在chunk之间保留overlap
+func (my *Splitter) merge(pieces []string) []string {
+	var results []string
+	var current []string
+	var total = 0
+
+	for _, piece := range pieces {
+		var size = my.length(piece)
+		if total+size > my.chunkSize && len(current) > 0 {
+			results = append(results, strings.Join(current, ""))
+
+			// 从末尾保留不超过overlap的片段作为下一个chunk的开头
+			for total > my.overlap || total+size > my.chunkSize && total > 0 {
+				total -= my.length(current[0])
+				current = current[1:]
+			}
+		}
+
+		current = append(current, piece)
+		total += size
+	}
+
+	if len(current) > 0 {
+		results = append(results, strings.Join(current, ""))
+	}
+
+	return results
+}
+
+// splitKeep 切分时保留分隔符: 以换行开头的声明类分隔符(比如"\nfunc ")留在下一个片段的开头, 标点类分隔符留在上一个片段的结尾.
+// level为空或者包含空字符串时按字符切分
+func splitKeep(text string, level []string) []string {
+	if len(level) == 0 || containsEmpty(level) {
+		var runes = make([]string, 0, len(text))
+		for _, r := range text {
+			runes = append(runes, string(r))
+		}
+		return runes
+	}
+
+	var results []string
+	var start = 0
+	for i := 0; i < len(text); {
+		var separator = matchAt(text, i, level)
+		if separator == "" {
+			i++
+			continue
+		}
+
+		var end = i + len(separator)
+		if strings.HasPrefix(separator, "\n") && strings.TrimSpace(separator) != "" {
+			end = i
+		}
+
+		if end > start {
+			results = append(results, text[start:end])
+			start = end
+		}
+		i += len(separator)
+	}
+
+	if start < len(text) {
+		results = append(results, text[start:])
+	}
+
+	return results
+}
+
+// matchAt 返回在text[i:]处匹配的最长分隔符, 没有则返回空字符串
+func matchAt(text string, i int, level []string) string {
+	var best = ""
+	for _, separator := range level {
+		if len(separator) > len(best) && strings.HasPrefix(text[i:], separator) {
+			best = separator
+		}
+	}
+
+	return best
+}
+
+func containsAny(text string, level []string) bool {
+	for _, separator := range level {
+		if separator == "" || strings.Contains(text, separator) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsEmpty(level []string) bool {
+	for _, separator := range level {
+		if separator == "" {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,92 @@
+package textsplit
+
+import (
+	"strings"
+	"testing"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+func TestSplitChinese(t *testing.T) {
+	var text = "今天天气怎么样？外面在下雨。我们去图书馆吧！图书馆里很安静，适合学习。晚上一起吃饭。"
+	var splitter = NewSplitter(WithChunkSize(16), WithOverlap(8), WithLengthFunc(RuneCount))
+	var chunks = splitter.SplitText(text)
+
+	for _, chunk := range chunks {
+		if RuneCount(chunk) > 16 {
+			t.Fatalf("chunk is too long: %q", chunk)
+		}
+		println(chunk)
+	}
+
+	// 按句子切分, 并且相邻chunk有重叠
+	if chunks[0] != "今天天气怎么样？外面在下雨。" || !strings.HasPrefix(chunks[1], "外面在下雨。") {
+		t.Fatalf("chunks=%q", chunks)
+	}
+
+	// 超长的句子退化为按逗号与字符切分
+	chunks = NewSplitter(WithChunkSize(5), WithOverlap(0), WithLengthFunc(RuneCount)).SplitText("一二三四五六七八九十")
+	if len(chunks) != 2 || chunks[0] != "一二三四五" {
+		t.Fatalf("chunks=%q", chunks)
+	}
+}
+
+func TestSplitMarkdown(t *testing.T) {
+	var text = `# 指南
+简介部分。
+
+## 安装
+### Linux
+运行install.sh。
+
+` + "```sh\n# 这不是标题\nmake\n```" + `
+
+## 使用
+先配置，再运行。
+`
+
+	var chunks = NewSplitter(WithChunkSize(100)).Split(&Document{Source: "guide.md", Type: TypeMarkdown, Content: text})
+	var headings []string
+	for _, chunk := range chunks {
+		headings = append(headings, chunk.Metadata[MetaHeadings])
+	}
+
+	var expected = []string{"指南", "指南 > 安装 > Linux", "指南 > 使用"}
+	if strings.Join(headings, "|") != strings.Join(expected, "|") {
+		t.Fatalf("headings=%q", headings)
+	}
+
+	if !strings.Contains(chunks[1].Content, "# 这不是标题") {
+		t.Fatalf("code fence is broken: %q", chunks[1].Content)
+	}
+
+	// 内容不变时ID不变
+	var again = NewSplitter(WithChunkSize(100)).Split(&Document{Source: "guide.md", Type: TypeMarkdown, Content: text})
+	if chunks[2].ID != again[2].ID || chunks[1].ID == chunks[2].ID {
+		t.Fatal("chunk id is not stable")
+	}
+}
+
+func TestSplitHtmlAndGo(t *testing.T) {
+	var page = `<html><head><title>x</title><style>p{}</style></head><body>
+<h1>产品</h1><p>这是&amp;一段介绍。</p><h2>价格</h2><ul><li>免费</li><li>专业版</li></ul></body></html>`
+
+	var chunks = NewSplitter().Split(&Document{Source: "page.html", Type: TypeHtml, Content: page})
+	if len(chunks) != 2 || chunks[0].Content != "这是&一段介绍。" || chunks[1].Metadata[MetaHeadings] != "产品 > 价格" {
+		for _, chunk := range chunks {
+			t.Logf("%q %v", chunk.Content, chunk.Metadata)
+		}
+		t.FailNow()
+	}
+
+	var source = "package main\n\nfunc a() {\n\tprintln(1)\n}\n\nfunc b() {\n\tprintln(2)\n}\n"
+	chunks = NewSplitter(WithChunkSize(8), WithOverlap(0)).Split(&Document{Source: "main.go", Type: TypeGo, Content: source})
+	if len(chunks) != 3 || !strings.HasPrefix(chunks[1].Content, "func a()") || !strings.HasPrefix(chunks[2].Content, "func b()") {
+		t.Fatalf("chunks=%v", chunks)
+	}
+}
@@ -0,0 +1,174 @@
+package transcript
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/lixianmin/agi/chat"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+type (
+	// openAIRecord 是OpenAI fine-tuning使用的jsonl格式, 每行一个对话
+	openAIRecord struct {
+		Messages []*chat.Message `json:"messages"`
+	}
+
+	// shareGPTRecord 是ShareGPT格式, 这里使用每行一个对话的jsonl
+	shareGPTRecord struct {
+		ID            string         `json:"id,omitempty"`
+		Conversations []shareGPTTurn `json:"conversations"`
+	}
+
+	shareGPTTurn struct {
+		From  string `json:"from"`
+		Value string `json:"value"`
+	}
+)
+
+// ExportOpenAI 把每个thread写为OpenAI fine-tuning jsonl中的一行
+func ExportOpenAI(writer io.Writer, threads ...*chat.Thread) error {
+	var encoder = json.NewEncoder(writer)
+	encoder.SetEscapeHTML(false)
+	for _, thread := range threads {
+		if err := encoder.Encode(openAIRecord{Messages: exportMessages(thread)}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ExportShareGPT 把每个thread写为ShareGPT jsonl中的一行, 角色被映射为system/human/gpt
+func ExportShareGPT(writer io.Writer, threads ...*chat.Thread) error {
+	var encoder = json.NewEncoder(writer)
+	encoder.SetEscapeHTML(false)
+	for _, thread := range threads {
+		var messages = exportMessages(thread)
+		var record = shareGPTRecord{Conversations: make([]shareGPTTurn, 0, len(messages))}
+		for _, message := range messages {
+			record.Conversations = append(record.Conversations, shareGPTTurn{From: toShareGPTRole(message.Role), Value: message.Content})
+		}
+
+		if err := encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ExportMarkdown 导出为便于阅读的对话记录, 回答后面附带model, token与耗时
+func ExportMarkdown(writer io.Writer, thread *chat.Thread) error {
+	var buffer = bufio.NewWriter(writer)
+	for _, turn := range exportTurns(thread) {
+		_, _ = fmt.Fprintf(buffer, "### %s\n\n%s\n\n", title(turn.Message.Role), turn.Message.Content)
+		if meta := formatMeta(turn); meta != "" {
+			_, _ = fmt.Fprintf(buffer, "> %s\n\n", meta)
+		}
+	}
+
+	return buffer.Flush()
+}
+
+// ExportHtml 导出为一个独立的html页面
+func ExportHtml(writer io.Writer, thread *chat.Thread) error {
+	var buffer = bufio.NewWriter(writer)
+	buffer.WriteString(htmlHeader)
+	for _, turn := range exportTurns(thread) {
+		var role = html.EscapeString(turn.Message.Role)
+		_, _ = fmt.Fprintf(buffer, "<div class=\"message %s\">\n<div class=\"role\">%s</div>\n<div class=\"content\">%s</div>\n", role, title(role), html.EscapeString(turn.Message.Content))
+		if meta := formatMeta(turn); meta != "" {
+			_, _ = fmt.Fprintf(buffer, "<div class=\"meta\">%s</div>\n", html.EscapeString(meta))
+		}
+		buffer.WriteString("</div>\n")
+	}
+	buffer.WriteString(htmlFooter)
+
+	return buffer.Flush()
+}
+
+// exportMessages 与发送给API的消息相同, 但是不包含空的system prompt
+func exportMessages(thread *chat.Thread) []*chat.Message {
+	var messages = thread.CloneMessages()
+	if len(messages) > 0 && messages[0].Role == "system" && messages[0].Content == "" {
+		messages = messages[1:]
+	}
+
+	return messages
+}
+
+// exportTurns 把system prompt与固定区也包装为Entry, 以便统一输出
+func exportTurns(thread *chat.Thread) []chat.Entry {
+	var turns []chat.Entry
+	if prompt := thread.GetPrompt(); prompt != "" {
+		turns = append(turns, chat.Entry{Message: &chat.Message{Role: "system", Content: prompt}})
+	}
+
+	for _, message := range thread.PinnedMessages() {
+		turns = append(turns, chat.Entry{Message: message})
+	}
+
+	return append(turns, thread.Entries()...)
+}
+
+func formatMeta(turn chat.Entry) string {
+	var items []string
+	if turn.Model != "" {
+		items = append(items, "model: "+turn.Model)
+	}
+
+	if turn.Usage != nil {
+		items = append(items, fmt.Sprintf("tokens: %d+%d", turn.Usage.PromptTokens, turn.Usage.CompletionTokens))
+	}
+
+	if turn.Latency > 0 {
+		items = append(items, "latency: "+turn.Latency.String())
+	}
+
+	if turn.FinishReason != "" {
+		items = append(items, "finish: "+turn.FinishReason)
+	}
+
+	return strings.Join(items, ", ")
+}
+
+func title(role string) string {
+	if role == "" {
+		return role
+	}
+
+	return strings.ToUpper(role[:1]) + role[1:]
+}
+
+const htmlHeader = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Conversation</title>
+<style>
+body { font-family: sans-serif; max-width: 800px; margin: 2em auto; }
+.message { margin: 1em 0; padding: 0.8em; border-radius: 8px; background: #f4f4f4; }
+.message.user { background: #e8f0fe; }
+.message.system { background: #fff8e1; }
+.role { font-weight: bold; margin-bottom: 0.4em; }
+.content { white-space: pre-wrap; }
+.meta { color: #888; font-size: 0.8em; margin-top: 0.4em; }
+</style>
+</head>
+<body>
+`
+
+const htmlFooter = `</body>
+</html>
+`
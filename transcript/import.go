@@ -0,0 +1,210 @@
+package transcript
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/lixianmin/agi/chat"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+const (
+	maxLineSize        = 16 * 1024 * 1024
+	defaultHistorySize = 20
+)
+
+type (
+	// LineError 指出导入时出错的行号(从1开始)
+	LineError struct {
+		Line int
+		Err  error
+	}
+
+	importOptions struct {
+		roles         map[string]string
+		threadOptions []chat.ThreadOption
+	}
+
+	ImportOption func(*importOptions)
+)
+
+// WithRoleMap 把源文件中的角色映射为system/user/assistant, 会合并到默认映射之上. 默认映射支持ShareGPT的human/gpt
+func WithRoleMap(roles map[string]string) ImportOption {
+	return func(options *importOptions) {
+		for from, to := range roles {
+			options.roles[from] = to
+		}
+	}
+}
+
+// WithThreadOptions 创建thread时使用的选项, 默认的historySize会放大到足以容纳整段对话
+func WithThreadOptions(opts ...chat.ThreadOption) ImportOption {
+	return func(options *importOptions) {
+		options.threadOptions = append(options.threadOptions, opts...)
+	}
+}
+
+// ImportOpenAI 读取OpenAI fine-tuning jsonl, 每行一个对话
+func ImportOpenAI(reader io.Reader, opts ...ImportOption) ([]*chat.Thread, error) {
+	return importLines(reader, opts, func(line []byte) ([]*chat.Message, error) {
+		var record openAIRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return nil, err
+		}
+
+		return record.Messages, nil
+	})
+}
+
+// ImportShareGPT 读取ShareGPT jsonl, 每行一个对话
+func ImportShareGPT(reader io.Reader, opts ...ImportOption) ([]*chat.Thread, error) {
+	return importLines(reader, opts, func(line []byte) ([]*chat.Message, error) {
+		var record shareGPTRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return nil, err
+		}
+
+		var messages = make([]*chat.Message, 0, len(record.Conversations))
+		for _, turn := range record.Conversations {
+			messages = append(messages, &chat.Message{Role: turn.From, Content: turn.Value})
+		}
+
+		return messages, nil
+	})
+}
+
+func importLines(reader io.Reader, opts []ImportOption, parse func(line []byte) ([]*chat.Message, error)) ([]*chat.Thread, error) {
+	// 默认值
+	var options = importOptions{
+		roles: map[string]string{
+			"system":    "system",
+			"user":      "user",
+			"human":     "user",
+			"assistant": "assistant",
+			"gpt":       "assistant",
+		},
+	}
+
+	// 初始化
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	var threads []*chat.Thread
+	var scanner = bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	var lineNumber = 0
+	for scanner.Scan() {
+		lineNumber++
+		var line = scanner.Bytes()
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+
+		var messages, err1 = parse(line)
+		if err1 != nil {
+			return nil, &LineError{Line: lineNumber, Err: err1}
+		}
+
+		var thread, err2 = options.newThread(messages)
+		if err2 != nil {
+			return nil, &LineError{Line: lineNumber, Err: err2}
+		}
+
+		threads = append(threads, thread)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, &LineError{Line: lineNumber + 1, Err: err}
+	}
+
+	return threads, nil
+}
+
+func (my *importOptions) newThread(messages []*chat.Message) (*chat.Thread, error) {
+	if len(messages) == 0 {
+		return nil, errors.New("conversation is empty")
+	}
+
+	var mapped = make([]*chat.Message, 0, len(messages))
+	for i, message := range messages {
+		if message == nil {
+			return nil, fmt.Errorf("message %d is null", i)
+		}
+
+		var role, ok = my.roles[message.Role]
+		if !ok {
+			return nil, fmt.Errorf("message %d: unknown role %q", i, message.Role)
+		}
+
+		if message.Content == "" {
+			return nil, fmt.Errorf("message %d: content is empty", i)
+		}
+
+		mapped = append(mapped, &chat.Message{Role: role, Content: message.Content})
+	}
+
+	// 第一条system消息作为prompt, 其余的消息按原样加入thread
+	var prompt = ""
+	if mapped[0].Role == "system" {
+		prompt = mapped[0].Content
+		mapped = mapped[1:]
+	}
+
+	var hasReply = false
+	for _, message := range mapped {
+		hasReply = hasReply || message.Role == "assistant"
+	}
+
+	if !hasReply {
+		return nil, errors.New("conversation has no assistant message")
+	}
+
+	var threadOptions = append([]chat.ThreadOption{chat.WithHistorySize(max(len(mapped)+1, defaultHistorySize))}, my.threadOptions...)
+	if prompt != "" {
+		threadOptions = append(threadOptions, chat.WithPrompt(prompt))
+	} else {
+		threadOptions = append(threadOptions, chat.WithoutSystemPrompt())
+	}
+
+	var thread, err = chat.NewThread(threadOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, message := range mapped {
+		thread.AddMessage(message)
+	}
+
+	return thread, nil
+}
+
+func (my *LineError) Error() string {
+	return fmt.Sprintf("line %d: %v", my.Line, my.Err)
+}
+
+func (my *LineError) Unwrap() error {
+	return my.Err
+}
+
+func toShareGPTRole(role string) string {
+	switch role {
+	case "user":
+		return "human"
+	case "assistant":
+		return "gpt"
+	default:
+		return role
+	}
+}
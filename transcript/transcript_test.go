@@ -0,0 +1,102 @@
+package transcript
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lixianmin/agi/chat"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+func newThread() *chat.Thread {
+	var thread, _ = chat.NewThread(chat.WithPrompt("你是一个翻译"))
+	thread.AddUserMessage("apple")
+	thread.AddResponse(&chat.Response{
+		Model:   "deepseek-chat",
+		Message: chat.Message{Role: "assistant", Content: "苹果 <fruit>"},
+		Usage:   &chat.Usage{PromptTokens: 8, CompletionTokens: 4, TotalTokens: 12},
+		Latency: 300 * time.Millisecond,
+	})
+
+	return thread
+}
+
+func TestRoundTrip(t *testing.T) {
+	var thread = newThread()
+
+	var openAI bytes.Buffer
+	if err := ExportOpenAI(&openAI, thread, thread); err != nil {
+		t.Fatal(err)
+	}
+	println(openAI.String())
+
+	var threads, err = ImportOpenAI(&openAI)
+	if err != nil || len(threads) != 2 {
+		t.Fatalf("threads=%d, err=%v", len(threads), err)
+	}
+
+	var messages = threads[0].CloneMessages()
+	if len(messages) != 3 || messages[0].Content != "你是一个翻译" || messages[2].Content != "苹果 <fruit>" {
+		t.Fatalf("unexpected messages: %d", len(messages))
+	}
+
+	var shareGPT bytes.Buffer
+	_ = ExportShareGPT(&shareGPT, thread)
+	println(shareGPT.String())
+	if !strings.Contains(shareGPT.String(), `"from":"gpt"`) {
+		t.Fatal("roles should be mapped to ShareGPT")
+	}
+
+	threads, err = ImportShareGPT(&shareGPT)
+	if err != nil || threads[0].HistoryMessages()[1].Role != "assistant" {
+		t.Fatalf("import ShareGPT failed: %v", err)
+	}
+}
+
+func TestImportErrors(t *testing.T) {
+	var input = `{"messages":[{"role":"user","content":"hi"},{"role":"assistant","content":"hello"}]}
+
+{"messages":[{"role":"user","content":"hi"},{"role":"robot","content":"hello"}]}
+`
+	var _, err = ImportOpenAI(strings.NewReader(input))
+	var lineErr *LineError
+	if !errors.As(err, &lineErr) || lineErr.Line != 3 {
+		t.Fatalf("err=%v", err)
+	}
+	println(err.Error())
+
+	var threads, err2 = ImportOpenAI(strings.NewReader(input), WithRoleMap(map[string]string{"robot": "assistant"}))
+	if err2 != nil || len(threads) != 2 {
+		t.Fatalf("role map is not applied: %v", err2)
+	}
+
+	if _, err3 := ImportShareGPT(strings.NewReader(`{"conversations":[{"from":"human","value":"hi"}]}`)); err3 == nil {
+		t.Fatal("conversation without reply should be rejected")
+	}
+}
+
+func TestExportDocuments(t *testing.T) {
+	var thread = newThread()
+
+	var markdown bytes.Buffer
+	_ = ExportMarkdown(&markdown, thread)
+	println(markdown.String())
+	if !strings.Contains(markdown.String(), "### Assistant") || !strings.Contains(markdown.String(), "model: deepseek-chat") {
+		t.Fatal("unexpected markdown")
+	}
+
+	var page bytes.Buffer
+	_ = ExportHtml(&page, thread)
+	if !strings.Contains(page.String(), "苹果 &lt;fruit&gt;") || !strings.Contains(page.String(), "latency: 300ms") {
+		t.Fatal("unexpected html")
+	}
+}
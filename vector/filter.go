@@ -0,0 +1,62 @@
+package vector
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+// Filter 根据metadata决定文档是否参与搜索, nil表示不过滤
+type Filter func(metadata map[string]string) bool
+
+// Equal metadata[key] == value
+func Equal(key string, value string) Filter {
+	return func(metadata map[string]string) bool {
+		return metadata[key] == value
+	}
+}
+
+// In metadata[key]是values中的一个
+func In(key string, values ...string) Filter {
+	var set = make(map[string]struct{}, len(values))
+	for _, value := range values {
+		set[value] = struct{}{}
+	}
+
+	return func(metadata map[string]string) bool {
+		var _, ok = set[metadata[key]]
+		return ok
+	}
+}
+
+// And 所有的filter都满足
+func And(filters ...Filter) Filter {
+	return func(metadata map[string]string) bool {
+		for _, filter := range filters {
+			if filter != nil && !filter(metadata) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or 任意一个filter满足
+func Or(filters ...Filter) Filter {
+	return func(metadata map[string]string) bool {
+		for _, filter := range filters {
+			if filter == nil || filter(metadata) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not 取反
+func Not(filter Filter) Filter {
+	return func(metadata map[string]string) bool {
+		return filter != nil && !filter(metadata)
+	}
+}
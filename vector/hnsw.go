@@ -0,0 +1,245 @@
+package vector
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+type (
+	// hnsw 是Hierarchical Navigable Small World图, 节点的下标与Store.entries一致.
+	// 删除的节点仍然保留在图中用于导航, 只是不出现在结果里
+	hnsw struct {
+		metric         Metric
+		vectors        func(index int) []float32
+		maxM           int // 第1层及以上每个节点的最大邻居数
+		maxM0          int // 第0层的最大邻居数
+		efConstruction int
+		efSearch       int
+		levelMult      float64
+
+		nodes      []*hnswNode
+		entryPoint int
+		maxLevel   int
+		random     *rand.Rand
+	}
+
+	hnswNode struct {
+		friends [][]int32 // friends[level]
+	}
+
+	candidate struct {
+		index    int
+		distance float32
+	}
+
+	minHeap []candidate // 距离最小的在堆顶
+	maxHeap []candidate // 距离最大的在堆顶
+)
+
+func newHnsw(metric Metric, vectors func(index int) []float32, m int, efConstruction int, efSearch int) *hnsw {
+	return &hnsw{
+		metric:         metric,
+		vectors:        vectors,
+		maxM:           m,
+		maxM0:          2 * m,
+		efConstruction: max(efConstruction, m),
+		efSearch:       efSearch,
+		levelMult:      1 / math.Log(float64(m)),
+		entryPoint:     -1,
+		random:         rand.New(rand.NewSource(1)),
+	}
+}
+
+func (my *hnsw) insert(index int) {
+	var level = int(-math.Log(1-my.random.Float64()) * my.levelMult)
+	var node = &hnswNode{friends: make([][]int32, level+1)}
+	my.nodes = append(my.nodes, node)
+
+	if my.entryPoint < 0 {
+		my.entryPoint = index
+		my.maxLevel = level
+		return
+	}
+
+	var query = my.vectors(index)
+	var ep = my.entryPoint
+	for layer := my.maxLevel; layer > level; layer-- {
+		ep = my.greedy(query, ep, layer)
+	}
+
+	for layer := min(level, my.maxLevel); layer >= 0; layer-- {
+		var candidates = my.searchLayer(query, ep, my.efConstruction, layer)
+		var limit = my.limit(layer)
+		var neighbors = candidates[:min(limit, len(candidates))]
+
+		node.friends[layer] = make([]int32, 0, len(neighbors))
+		for _, neighbor := range neighbors {
+			node.friends[layer] = append(node.friends[layer], int32(neighbor.index))
+			my.connect(neighbor.index, index, layer)
+		}
+
+		ep = candidates[0].index
+	}
+
+	if level > my.maxLevel {
+		my.maxLevel = level
+		my.entryPoint = index
+	}
+}
+
+// search 返回最多k个accept的节点, 按距离从小到大排序
+func (my *hnsw) search(query []float32, k int, accept func(index int) bool) []candidate {
+	if my.entryPoint < 0 {
+		return nil
+	}
+
+	var ep = my.entryPoint
+	for layer := my.maxLevel; layer > 0; layer-- {
+		ep = my.greedy(query, ep, layer)
+	}
+
+	var candidates = my.searchLayer(query, ep, max(my.efSearch, k), 0)
+	var results = make([]candidate, 0, k)
+	for _, item := range candidates {
+		if accept(item.index) {
+			results = append(results, item)
+			if len(results) == k {
+				break
+			}
+		}
+	}
+
+	return results
+}
+
+// connect 把to加入from在layer层的邻居中, 超出上限时只保留最近的邻居
+func (my *hnsw) connect(from int, to int, layer int) {
+	var node = my.nodes[from]
+	node.friends[layer] = append(node.friends[layer], int32(to))
+
+	var limit = my.limit(layer)
+	if len(node.friends[layer]) <= limit {
+		return
+	}
+
+	var base = my.vectors(from)
+	var items = make([]candidate, len(node.friends[layer]))
+	for i, friend := range node.friends[layer] {
+		items[i] = candidate{index: int(friend), distance: my.metric.distance(base, my.vectors(int(friend)))}
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].distance < items[j].distance
+	})
+
+	var friends = node.friends[layer][:0]
+	for _, item := range items[:limit] {
+		friends = append(friends, int32(item.index))
+	}
+	node.friends[layer] = friends
+}
+
+func (my *hnsw) greedy(query []float32, ep int, layer int) int {
+	var current = ep
+	var best = my.metric.distance(query, my.vectors(current))
+	for changed := true; changed; {
+		changed = false
+		for _, friend := range my.friends(current, layer) {
+			var distance = my.metric.distance(query, my.vectors(int(friend)))
+			if distance < best {
+				best = distance
+				current = int(friend)
+				changed = true
+			}
+		}
+	}
+
+	return current
+}
+
+// searchLayer 返回layer层中距离query最近的ef个节点, 按距离从小到大排序
+func (my *hnsw) searchLayer(query []float32, ep int, ef int, layer int) []candidate {
+	var visited = map[int]struct{}{ep: {}}
+	var start = candidate{index: ep, distance: my.metric.distance(query, my.vectors(ep))}
+	var candidates = &minHeap{start}
+	var results = &maxHeap{start}
+
+	for candidates.Len() > 0 {
+		var current = heap.Pop(candidates).(candidate)
+		if current.distance > (*results)[0].distance && results.Len() >= ef {
+			break
+		}
+
+		for _, friend := range my.friends(current.index, layer) {
+			var index = int(friend)
+			if _, ok := visited[index]; ok {
+				continue
+			}
+			visited[index] = struct{}{}
+
+			var distance = my.metric.distance(query, my.vectors(index))
+			if results.Len() < ef || distance < (*results)[0].distance {
+				heap.Push(candidates, candidate{index: index, distance: distance})
+				heap.Push(results, candidate{index: index, distance: distance})
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+
+	var sorted = make([]candidate, results.Len())
+	for i := len(sorted) - 1; i >= 0; i-- {
+		sorted[i] = heap.Pop(results).(candidate)
+	}
+
+	return sorted
+}
+
+func (my *hnsw) friends(index int, layer int) []int32 {
+	var node = my.nodes[index]
+	if layer < len(node.friends) {
+		return node.friends[layer]
+	}
+
+	return nil
+}
+
+func (my *hnsw) limit(layer int) int {
+	if layer == 0 {
+		return my.maxM0
+	}
+
+	return my.maxM
+}
+
+func (h minHeap) Len() int           { return len(h) }
+func (h minHeap) Less(i, j int) bool { return h[i].distance < h[j].distance }
+func (h minHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *minHeap) Push(x any)        { *h = append(*h, x.(candidate)) }
+func (h *minHeap) Pop() any {
+	var old = *h
+	var item = old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+func (h maxHeap) Len() int           { return len(h) }
+func (h maxHeap) Less(i, j int) bool { return h[i].distance > h[j].distance }
+func (h maxHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *maxHeap) Push(x any)        { *h = append(*h, x.(candidate)) }
+func (h *maxHeap) Pop() any {
+	var old = *h
+	var item = old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
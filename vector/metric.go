@@ -0,0 +1,86 @@
+package vector
+
+import (
+	"fmt"
+	"math"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+type Metric int
+
+const (
+	Cosine Metric = iota // 余弦相似度, 向量在加入时会被归一化
+	Dot                  // 内积
+	L2                   // 欧氏距离
+)
+
+func (metric Metric) String() string {
+	switch metric {
+	case Cosine:
+		return "cosine"
+	case Dot:
+		return "dot"
+	case L2:
+		return "l2"
+	default:
+		return fmt.Sprintf("metric(%d)", int(metric))
+	}
+}
+
+// distance 越小越相似, 用于索引内部的排序
+func (metric Metric) distance(a []float32, b []float32) float32 {
+	switch metric {
+	case L2:
+		var sum float32
+		for i := range a {
+			var d = a[i] - b[i]
+			sum += d * d
+		}
+		return sum
+	case Cosine:
+		return 1 - dot(a, b)
+	default:
+		return -dot(a, b)
+	}
+}
+
+// score 把distance转换为对外的分数, 越大越相似: cosine为余弦相似度, dot为内积, l2为负的欧氏距离
+func (metric Metric) score(distance float32) float32 {
+	switch metric {
+	case L2:
+		return -float32(math.Sqrt(float64(distance)))
+	case Cosine:
+		return 1 - distance
+	default:
+		return -distance
+	}
+}
+
+func dot(a []float32, b []float32) float32 {
+	var sum float32
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+
+	return sum
+}
+
+func normalize(v []float32) []float32 {
+	var norm = float32(math.Sqrt(float64(dot(v, v))))
+	var result = make([]float32, len(v))
+	if norm == 0 {
+		return result
+	}
+
+	for i := range v {
+		result[i] = v[i] / norm
+	}
+
+	return result
+}
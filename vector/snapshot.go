@@ -0,0 +1,85 @@
+package vector
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+type snapshot struct {
+	Version   int         `json:"version"`
+	Dimension int         `json:"dimension"`
+	Metric    string      `json:"metric"`
+	Documents []*Document `json:"documents"`
+}
+
+const snapshotVersion = 1
+
+// Save 把所有文档保存到path, HNSW图在Load时重建
+func (my *Store) Save(path string) error {
+	var data = snapshot{
+		Version:   snapshotVersion,
+		Dimension: my.dimension,
+		Metric:    my.metric.String(),
+		Documents: my.Documents(),
+	}
+
+	var bts, err1 = json.Marshal(data)
+	if err1 != nil {
+		return err1
+	}
+
+	if err2 := os.MkdirAll(filepath.Dir(path), 0755); err2 != nil {
+		return err2
+	}
+
+	var temp = path + ".tmp"
+	if err3 := os.WriteFile(temp, bts, 0644); err3 != nil {
+		return err3
+	}
+
+	return os.Rename(temp, path)
+}
+
+// Load 读取Save保存的快照, opts中的metric会被快照中的值覆盖
+func Load(path string, opts ...StoreOption) (*Store, error) {
+	var bts, err1 = os.ReadFile(path)
+	if err1 != nil {
+		return nil, err1
+	}
+
+	var data snapshot
+	if err2 := json.Unmarshal(bts, &data); err2 != nil {
+		return nil, err2
+	}
+
+	var metric, err3 = parseMetric(data.Metric)
+	if err3 != nil {
+		return nil, err3
+	}
+
+	var store = NewStore(data.Dimension, append(opts, WithMetric(metric))...)
+	if err4 := store.Upsert(data.Documents...); err4 != nil {
+		return nil, err4
+	}
+
+	return store, nil
+}
+
+func parseMetric(name string) (Metric, error) {
+	for _, metric := range []Metric{Cosine, Dot, L2} {
+		if metric.String() == name {
+			return metric, nil
+		}
+	}
+
+	return Cosine, fmt.Errorf("unknown metric: %s", name)
+}
@@ -0,0 +1,364 @@
+package vector
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/lixianmin/agi/ifs"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+type (
+	Document struct {
+		ID       string            `json:"id"`
+		Content  string            `json:"content,omitempty"`
+		Metadata map[string]string `json:"metadata,omitempty"`
+		Vector   []float32         `json:"vector"`
+	}
+
+	Result struct {
+		Document *Document
+		Score    float32 // 越大越相似: cosine为余弦相似度, dot为内积, l2为负的欧氏距离
+	}
+
+	// Store 是线程安全的内存向量索引, 默认为暴力搜索(flat), 使用WithHnsw()开启近似搜索
+	Store struct {
+		dimension int
+		metric    Metric
+		options   storeOptions
+
+		entries []*entry // 下标即hnsw中的节点编号, 删除后只做标记
+		ids     map[string]int
+		deleted int
+		graph   *hnsw
+		m       sync.RWMutex
+	}
+
+	entry struct {
+		document *Document
+		vector   []float32 // cosine时为归一化之后的向量
+		deleted  bool
+	}
+
+	storeOptions struct {
+		metric         Metric
+		hnsw           bool
+		m              int
+		efConstruction int
+		efSearch       int
+	}
+
+	StoreOption func(*storeOptions)
+)
+
+var ErrDimensionMismatch = errors.New("vector dimension mismatch")
+
+func WithMetric(metric Metric) StoreOption {
+	return func(options *storeOptions) {
+		options.metric = metric
+	}
+}
+
+// WithHnsw 使用HNSW图做近似搜索, m为每个节点的邻居数(常用16), ef越大召回率越高但越慢
+func WithHnsw(m int, efConstruction int, efSearch int) StoreOption {
+	return func(options *storeOptions) {
+		options.hnsw = true
+		if m > 1 {
+			options.m = m
+		}
+
+		if efConstruction > 0 {
+			options.efConstruction = efConstruction
+		}
+
+		if efSearch > 0 {
+			options.efSearch = efSearch
+		}
+	}
+}
+
+// NewStore dimension为向量的维度, 比如BAAI/bge-m3是1024
+func NewStore(dimension int, opts ...StoreOption) *Store {
+	// 默认值
+	var options = storeOptions{
+		metric:         Cosine,
+		m:              16,
+		efConstruction: 200,
+		efSearch:       64,
+	}
+
+	// 初始化
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	var store = &Store{
+		dimension: dimension,
+		metric:    options.metric,
+		options:   options,
+		ids:       make(map[string]int),
+	}
+	store.resetGraph()
+
+	return store
+}
+
+func (my *Store) Dimension() int {
+	return my.dimension
+}
+
+func (my *Store) Metric() Metric {
+	return my.metric
+}
+
+// Len 返回未删除的文档数
+func (my *Store) Len() int {
+	my.m.RLock()
+	defer my.m.RUnlock()
+	return len(my.ids)
+}
+
+// Upsert 插入或者替换同ID的文档
+func (my *Store) Upsert(documents ...*Document) error {
+	for _, document := range documents {
+		if document == nil || document.ID == "" {
+			return errors.New("document id is empty")
+		}
+
+		if len(document.Vector) != my.dimension {
+			return fmt.Errorf("%w: id=%s, expected=%d, got=%d", ErrDimensionMismatch, document.ID, my.dimension, len(document.Vector))
+		}
+	}
+
+	my.m.Lock()
+	defer my.m.Unlock()
+
+	for _, document := range documents {
+		my.remove(document.ID)
+
+		var vector = document.Vector
+		if my.metric == Cosine {
+			vector = normalize(vector)
+		}
+
+		var index = len(my.entries)
+		my.entries = append(my.entries, &entry{document: document, vector: vector})
+		my.ids[document.ID] = index
+		if my.graph != nil {
+			my.graph.insert(index)
+		}
+	}
+
+	my.compactIfNeeded()
+	return nil
+}
+
+// UpsertTexts 使用embedder计算Content的向量之后插入, 已经有向量的文档不会重新计算
+func (my *Store) UpsertTexts(ctx context.Context, embedder ifs.Embedder, documents ...*Document) error {
+	var texts []string
+	var targets []*Document
+	for _, document := range documents {
+		if document != nil && len(document.Vector) == 0 {
+			texts = append(texts, document.Content)
+			targets = append(targets, document)
+		}
+	}
+
+	if len(texts) > 0 {
+		var vectors, err = embedder.Embed(ctx, texts)
+		if err != nil {
+			return err
+		}
+
+		if len(vectors) != len(targets) {
+			return errors.New("embedding count mismatch")
+		}
+
+		for i, target := range targets {
+			target.Vector = vectors[i]
+		}
+	}
+
+	return my.Upsert(documents...)
+}
+
+// Delete 返回实际删除的文档数
+func (my *Store) Delete(ids ...string) int {
+	my.m.Lock()
+	defer my.m.Unlock()
+
+	var count = 0
+	for _, id := range ids {
+		if my.remove(id) {
+			count++
+		}
+	}
+
+	my.compactIfNeeded()
+	return count
+}
+
+func (my *Store) Get(id string) (*Document, bool) {
+	my.m.RLock()
+	defer my.m.RUnlock()
+
+	if index, ok := my.ids[id]; ok {
+		return my.entries[index].document, true
+	}
+
+	return nil, false
+}
+
+// Search 返回与query最相似的k个文档, 按Score从大到小排序
+func (my *Store) Search(query []float32, k int, filter Filter) ([]Result, error) {
+	if len(query) != my.dimension {
+		return nil, fmt.Errorf("%w: expected=%d, got=%d", ErrDimensionMismatch, my.dimension, len(query))
+	}
+
+	if k <= 0 {
+		return nil, nil
+	}
+
+	if my.metric == Cosine {
+		query = normalize(query)
+	}
+
+	my.m.RLock()
+	defer my.m.RUnlock()
+
+	var accept = func(index int) bool {
+		var item = my.entries[index]
+		return !item.deleted && (filter == nil || filter(item.document.Metadata))
+	}
+
+	var candidates []candidate
+	if my.graph != nil {
+		candidates = my.graph.search(query, k, accept)
+	}
+
+	// 过滤条件很严格时图搜索可能凑不够k个, 退回到暴力搜索
+	if len(candidates) < k && len(candidates) < len(my.ids) {
+		candidates = my.flatSearch(query, k, accept)
+	}
+
+	var results = make([]Result, len(candidates))
+	for i, item := range candidates {
+		results[i] = Result{
+			Document: my.entries[item.index].document,
+			Score:    my.metric.score(item.distance),
+		}
+	}
+
+	return results, nil
+}
+
+// SearchText 使用embedder计算text的向量之后搜索
+func (my *Store) SearchText(ctx context.Context, embedder ifs.Embedder, text string, k int, filter Filter) ([]Result, error) {
+	var vectors, err = embedder.Embed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(vectors) != 1 {
+		return nil, errors.New("embedding count mismatch")
+	}
+
+	return my.Search(vectors[0], k, filter)
+}
+
+// Documents 返回所有未删除的文档, 按ID排序
+func (my *Store) Documents() []*Document {
+	my.m.RLock()
+	var documents = make([]*Document, 0, len(my.ids))
+	for _, index := range my.ids {
+		documents = append(documents, my.entries[index].document)
+	}
+	my.m.RUnlock()
+
+	sort.Slice(documents, func(i, j int) bool {
+		return documents[i].ID < documents[j].ID
+	})
+
+	return documents
+}
+
+func (my *Store) flatSearch(query []float32, k int, accept func(index int) bool) []candidate {
+	var results = make(maxHeap, 0, k+1)
+	for index, item := range my.entries {
+		if !accept(index) {
+			continue
+		}
+
+		var distance = my.metric.distance(query, item.vector)
+		if results.Len() < k || distance < results[0].distance {
+			heap.Push(&results, candidate{index: index, distance: distance})
+			if results.Len() > k {
+				heap.Pop(&results)
+			}
+		}
+	}
+
+	var sorted = make([]candidate, results.Len())
+	for i := len(sorted) - 1; i >= 0; i-- {
+		sorted[i] = heap.Pop(&results).(candidate)
+	}
+
+	return sorted
+}
+
+// remove 调用时需要持有写锁
+func (my *Store) remove(id string) bool {
+	var index, ok = my.ids[id]
+	if !ok {
+		return false
+	}
+
+	my.entries[index].deleted = true
+	delete(my.ids, id)
+	my.deleted++
+	return true
+}
+
+// compactIfNeeded 删除的条目超过一半时重建索引, 调用时需要持有写锁
+func (my *Store) compactIfNeeded() {
+	if my.deleted < 64 || my.deleted*2 < len(my.entries) {
+		return
+	}
+
+	var entries = my.entries
+	my.entries = make([]*entry, 0, len(my.ids))
+	my.ids = make(map[string]int, len(my.ids))
+	my.deleted = 0
+	my.resetGraph()
+
+	for _, item := range entries {
+		if !item.deleted {
+			var index = len(my.entries)
+			my.entries = append(my.entries, item)
+			my.ids[item.document.ID] = index
+			if my.graph != nil {
+				my.graph.insert(index)
+			}
+		}
+	}
+}
+
+func (my *Store) resetGraph() {
+	my.graph = nil
+	if my.options.hnsw {
+		var vectors = func(index int) []float32 {
+			return my.entries[index].vector
+		}
+		my.graph = newHnsw(my.metric, vectors, my.options.m, my.options.efConstruction, my.options.efSearch)
+	}
+}
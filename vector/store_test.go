@@ -0,0 +1,128 @@
+package vector
+
+import (
+	"context"
+	"math/rand"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/lixianmin/agi/agitest"
+	"github.com/lixianmin/agi/siliconflow"
+)
+
+/********************************************************************
+created:    2026-10-19
+author:     lixianmin
+
+Copyright (C) - All Rights Reserved
+*********************************************************************/
+
+func randomDocuments(count int, dimension int) []*Document {
+	var random = rand.New(rand.NewSource(42))
+	var documents = make([]*Document, count)
+	for i := range documents {
+		var vector = make([]float32, dimension)
+		for j := range vector {
+			vector[j] = random.Float32()*2 - 1
+		}
+
+		documents[i] = &Document{
+			ID:       "doc-" + strconv.Itoa(i),
+			Metadata: map[string]string{"parity": strconv.Itoa(i % 2)},
+			Vector:   vector,
+		}
+	}
+
+	return documents
+}
+
+func TestHnswRecall(t *testing.T) {
+	const dimension = 32
+	var documents = randomDocuments(1000, dimension)
+	for _, metric := range []Metric{Cosine, Dot, L2} {
+		var flat = NewStore(dimension, WithMetric(metric))
+		var graph = NewStore(dimension, WithMetric(metric), WithHnsw(16, 100, 64))
+		_ = flat.Upsert(documents...)
+		_ = graph.Upsert(documents...)
+
+		var hits, total = 0, 0
+		for _, query := range randomDocuments(20, dimension) {
+			var expected, _ = flat.Search(query.Vector, 10, nil)
+			var actual, _ = graph.Search(query.Vector, 10, nil)
+			var set = make(map[string]bool)
+			for _, result := range expected {
+				set[result.Document.ID] = true
+			}
+
+			for _, result := range actual {
+				if set[result.Document.ID] {
+					hits++
+				}
+			}
+			total += len(expected)
+		}
+
+		if recall := float64(hits) / float64(total); recall < 0.9 {
+			t.Fatalf("metric=%s, recall=%.2f", metric, recall)
+		}
+	}
+}
+
+func TestUpsertDeleteFilter(t *testing.T) {
+	var store = NewStore(2, WithHnsw(4, 0, 0))
+	_ = store.Upsert(
+		&Document{ID: "a", Vector: []float32{1, 0}, Metadata: map[string]string{"lang": "zh"}},
+		&Document{ID: "b", Vector: []float32{0.9, 0.1}, Metadata: map[string]string{"lang": "en"}},
+		&Document{ID: "c", Vector: []float32{0, 1}, Metadata: map[string]string{"lang": "zh"}},
+	)
+
+	var results, _ = store.Search([]float32{1, 0}, 2, Equal("lang", "zh"))
+	if len(results) != 2 || results[0].Document.ID != "a" || results[1].Document.ID != "c" {
+		t.Fatalf("results=%v", results)
+	}
+
+	// 替换向量之后c变成最相似的
+	_ = store.Upsert(&Document{ID: "c", Vector: []float32{1, 0.01}, Metadata: map[string]string{"lang": "zh"}})
+	store.Delete("a")
+
+	results, _ = store.Search([]float32{1, 0}, 3, nil)
+	if store.Len() != 2 || len(results) != 2 || results[0].Document.ID != "c" {
+		t.Fatalf("results=%v", results)
+	}
+
+	if _, err := store.Search([]float32{1}, 1, nil); err == nil {
+		t.Fatal("dimension mismatch expected")
+	}
+}
+
+func TestSnapshotAndEmbedder(t *testing.T) {
+	var server = agitest.NewTestServer(t)
+	var client = siliconflow.NewSiliconClient("sk-test", siliconflow.WithBaseUrl(server.BaseUrl()))
+	var embedder = siliconflow.NewEmbedder(client, "BAAI/bge-m3")
+
+	var store = NewStore(64, WithHnsw(8, 50, 20))
+	var err = store.UpsertTexts(context.Background(), embedder,
+		&Document{ID: "1", Content: "今天天气很好"},
+		&Document{ID: "2", Content: "golang的并发模型"},
+		&Document{ID: "3", Content: "明天可能会下雨"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var path = filepath.Join(t.TempDir(), "store.json")
+	if err := store.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	var loaded, err2 = Load(path, WithHnsw(8, 50, 20))
+	if err2 != nil || loaded.Len() != 3 {
+		t.Fatalf("err=%v", err2)
+	}
+
+	var results, _ = loaded.SearchText(context.Background(), embedder, "golang的并发", 1, nil)
+	if len(results) != 1 || results[0].Document.ID != "2" {
+		t.Fatalf("results=%v", results)
+	}
+}